@@ -0,0 +1,136 @@
+// Package agenda lists the open tasks of all active projects by deadline.
+//
+// Days start and end in the time zone of the caller. Tasks are grouped by
+// the day of their deadline and by project, archived projects are left out.
+package agenda
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	// Time zones are also available on systems without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Views of the agenda
+const (
+	// Tasks due today
+	Today = "today"
+	// Tasks with a deadline in the past
+	Overdue = "overdue"
+	// Tasks due in the next days, starting tomorrow
+	Upcoming = "upcoming"
+)
+
+// Layout of the days of an agenda
+const DateLayout = "2006-01-02"
+
+// Upper limit for the days of the upcoming view
+const MaxDays = 90
+
+// Open tasks grouped by day and project
+type Agenda struct {
+	View     string `json:"view"`
+	Timezone string `json:"timezone"`
+	// Range of the deadlines, From is nil for overdue tasks
+	From  *time.Time `json:"from,omitempty"`
+	To    time.Time  `json:"to"`
+	Count int        `json:"count"`
+	Days  []Day      `json:"days"`
+}
+
+type Day struct {
+	Date     string    `json:"date"`
+	Projects []Project `json:"projects"`
+}
+
+type Project struct {
+	Project string       `json:"project"`
+	Tasks   []model.Task `json:"tasks"`
+}
+
+// Returns the range of deadlines of a view in location.
+// days is the number of days of the upcoming view
+func Range(view string, days int, now time.Time, location *time.Location) (*time.Time, time.Time, error) {
+	now = now.In(location)
+	year, month, date := now.Date()
+	today := time.Date(year, month, date, 0, 0, 0, 0, location)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	switch view {
+	case Today:
+		return &today, tomorrow, nil
+	case Overdue:
+		return nil, now, nil
+	case Upcoming:
+		if days < 1 || days > MaxDays {
+			return nil, now, fmt.Errorf("days must be between 1 and %d", MaxDays)
+		}
+		return &tomorrow, tomorrow.AddDate(0, 0, days), nil
+	}
+	return nil, now, errors.New("unknown view " + view)
+}
+
+// Lists the open tasks of all projects that are not archived with
+// a deadline in the range of the view
+func New(t store.TodoStore, view string, days int, now time.Time, location *time.Location) (Agenda, error) {
+	agenda := Agenda{View: view, Timezone: location.String(), Days: []Day{}}
+
+	from, to, err := Range(view, days, now, location)
+	if err != nil {
+		return agenda, err
+	}
+	agenda.From = from
+	agenda.To = to
+
+	projectNames := map[uint]string{}
+	projectIDs := []uint{}
+	for _, project := range t.GetAllProjects() {
+		if !project.Archived {
+			projectNames[project.ID] = project.Name
+			projectIDs = append(projectIDs, project.ID)
+		}
+	}
+
+	tasks := []model.Task{}
+	for _, task := range t.GetOpenTasksWithDeadline(projectIDs) {
+		if (from == nil || !task.Deadline.Before(*from)) && task.Deadline.Before(to) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Deadline.Before(*tasks[j].Deadline) })
+	dependency.MarkBlocked(t, tasks)
+
+	// Indexes of the days and projects of the agenda
+	dayIndex := map[string]int{}
+	projectIndex := map[string]map[string]int{}
+	for _, task := range tasks {
+		date := task.Deadline.In(location).Format(DateLayout)
+		if _, ok := dayIndex[date]; !ok {
+			dayIndex[date] = len(agenda.Days)
+			projectIndex[date] = map[string]int{}
+			agenda.Days = append(agenda.Days, Day{Date: date, Projects: []Project{}})
+		}
+		day := &agenda.Days[dayIndex[date]]
+
+		name := projectNames[task.ProjectID]
+		if _, ok := projectIndex[date][name]; !ok {
+			projectIndex[date][name] = len(day.Projects)
+			day.Projects = append(day.Projects, Project{Project: name, Tasks: []model.Task{}})
+		}
+		project := &day.Projects[projectIndex[date][name]]
+		project.Tasks = append(project.Tasks, task)
+		agenda.Count++
+	}
+
+	for _, day := range agenda.Days {
+		sort.Slice(day.Projects, func(i, j int) bool { return day.Projects[i].Project < day.Projects[j].Project })
+	}
+	return agenda, nil
+}
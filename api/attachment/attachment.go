@@ -0,0 +1,144 @@
+// Package attachment adds files to tasks.
+//
+// The metadata of attachments is kept in the TodoStore, their contents in a
+// blob.Store. Uploads are limited in size and content type, which is
+// detected from the content. Their SHA-256 checksum is stored and can be
+// verified against the checksum sent by the client.
+package attachment
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+var (
+	ErrTooLarge    = errors.New("attachment is too large")
+	ErrContentType = errors.New("content type is not allowed")
+	ErrChecksum    = errors.New("checksum does not match the content")
+	ErrName        = errors.New("attachment needs a file name")
+)
+
+// Limits of uploads
+type Limits struct {
+	// Maximum size in bytes
+	MaxSize int64
+	// Allowed media types as detected from the content, e.g. image/png
+	ContentTypes []string
+}
+
+// Screenshots, PDFs, text files and office documents up to 10 MiB.
+// Office documents are detected as application/zip
+var DefaultLimits = Limits{
+	MaxSize:      10 << 20,
+	ContentTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain", "application/zip"},
+}
+
+// Checks if the media type of a content type is allowed
+func (l Limits) Allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range l.ContentTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// An upload to add to a task
+type Upload struct {
+	Name    string
+	Content io.Reader
+	// Hex encoded SHA-256 checksum sent by the client, empty to skip the check
+	SHA256 string
+}
+
+// Stores the content of the upload in blobs and adds it to the task.
+// Nothing is kept if the upload exceeds the limits or its checksum is wrong
+func Add(t store.TodoStore, blobs blob.Store, limits Limits, task model.Task, upload Upload) (model.Attachment, error) {
+	attachment := model.Attachment{TaskID: task.ID, Name: filepath.Base(strings.ReplaceAll(upload.Name, "\\", "/"))}
+	if upload.Name == "" || attachment.Name == "." || attachment.Name == "/" {
+		return attachment, ErrName
+	}
+
+	// The first 512 bytes decide about the content type
+	content := bufio.NewReaderSize(upload.Content, 512)
+	head, err := content.Peek(512)
+	if err != nil && err != io.EOF {
+		return attachment, err
+	}
+	attachment.ContentType = http.DetectContentType(head)
+	if !limits.Allows(attachment.ContentType) {
+		return attachment, fmt.Errorf("%w: %s", ErrContentType, attachment.ContentType)
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return attachment, err
+	}
+	attachment.BlobKey = key
+
+	// One byte more than allowed shows that the content is too large
+	hash := sha256.New()
+	attachment.Size, err = blobs.Put(key, io.TeeReader(io.LimitReader(content, limits.MaxSize+1), hash))
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	switch {
+	case err != nil:
+	case attachment.Size > limits.MaxSize:
+		err = fmt.Errorf("%w: at most %d bytes are allowed", ErrTooLarge, limits.MaxSize)
+	case upload.SHA256 != "" && !strings.EqualFold(upload.SHA256, attachment.SHA256):
+		err = fmt.Errorf("%w: the content has the SHA-256 checksum %s", ErrChecksum, attachment.SHA256)
+	default:
+		err = t.PostAttachment(attachment)
+	}
+	if err != nil {
+		blobs.Delete(key)
+		return attachment, err
+	}
+
+	// Return the stored attachment with its ID
+	for _, stored := range t.GetAttachments(task.ID) {
+		if stored.BlobKey == key {
+			attachment = stored
+		}
+	}
+	return attachment, nil
+}
+
+// Opens the content of an attachment
+func Open(blobs blob.Store, attachment model.Attachment) (io.ReadCloser, error) {
+	return blobs.Get(attachment.BlobKey)
+}
+
+// Deletes an attachment and its blob
+func Delete(t store.TodoStore, blobs blob.Store, attachment model.Attachment) error {
+	if err := t.DeleteAttachment(attachment); err != nil {
+		return err
+	}
+	return blobs.Delete(attachment.BlobKey)
+}
+
+// Returns a random key for a blob
+func newKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
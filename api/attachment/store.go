@@ -0,0 +1,128 @@
+package attachment
+
+import (
+	"context"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// TodoStore deleting the blobs of the attachments of purged tasks and projects.
+// Blobs of tasks deleted in a transaction are deleted after the commit,
+// unless the transaction added attachments referring to them again,
+// as restoring a backup does
+type Store struct {
+	store.TodoStore
+	blobs blob.Store
+
+	// Keys of the blobs to delete after the commit, nil outside of transactions
+	pending *[]string
+	// Context of the request, its logger reports failed deletions
+	ctx context.Context
+}
+
+func NewStore(t store.TodoStore, blobs blob.Store) *Store {
+	return &Store{TodoStore: t, blobs: blobs}
+}
+
+// Runs fn in a transaction and deletes the blobs of its purged tasks after the commit
+func (s *Store) Transaction(fn func(t store.TodoStore) error) error {
+	tx := &Store{blobs: s.blobs, pending: &[]string{}, ctx: s.ctx}
+	err := s.TodoStore.Transaction(func(t store.TodoStore) error {
+		tx.TodoStore = t
+		return fn(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.purge(unreferenced(s.TodoStore, *tx.pending))
+	return nil
+}
+
+func (s *Store) WithContext(ctx context.Context) store.TodoStore {
+	return &Store{TodoStore: s.TodoStore.WithContext(ctx), blobs: s.blobs, pending: s.pending, ctx: ctx}
+}
+
+func (s *Store) DeleteTask(task model.Task) error {
+	attachments := []model.Attachment{}
+	if task.ID != 0 {
+		attachments = s.TodoStore.GetAttachments(task.ID)
+	}
+
+	if err := s.TodoStore.DeleteTask(task); err != nil {
+		return err
+	}
+	s.purge(blobKeys(attachments))
+	return nil
+}
+
+func (s *Store) DeleteProject(name string) error {
+	attachments := []model.Attachment{}
+	if project := s.TodoStore.GetProject(name); project.ID != 0 {
+		for _, task := range s.TodoStore.GetAllProjectTasks(project) {
+			attachments = append(attachments, s.TodoStore.GetAttachments(task.ID)...)
+		}
+	}
+
+	if err := s.TodoStore.DeleteProject(name); err != nil {
+		return err
+	}
+	s.purge(blobKeys(attachments))
+	return nil
+}
+
+func (s *Store) DeleteAll() error {
+	attachments := s.TodoStore.GetAllAttachments()
+
+	if err := s.TodoStore.DeleteAll(); err != nil {
+		return err
+	}
+	s.purge(blobKeys(attachments))
+	return nil
+}
+
+// Deletes the blobs, or remembers them until the transaction is committed.
+// The metadata is gone already, so failures are only logged
+func (s *Store) purge(keys []string) {
+	if s.pending != nil {
+		*s.pending = append(*s.pending, keys...)
+		return
+	}
+
+	for _, key := range keys {
+		if err := s.blobs.Delete(key); err != nil {
+			logging.FromContext(s.ctx).Error("could not delete blob", "key", key, "error", err)
+		}
+	}
+}
+
+// Returns the keys without attachments referring to them
+func unreferenced(t store.TodoStore, keys []string) []string {
+	if len(keys) == 0 {
+		return keys
+	}
+
+	referenced := map[string]bool{}
+	for _, attachment := range t.GetAllAttachments() {
+		referenced[attachment.BlobKey] = true
+	}
+	unused := []string{}
+	for _, key := range keys {
+		if !referenced[key] {
+			unused = append(unused, key)
+		}
+	}
+	return unused
+}
+
+// Returns the blob keys of the attachments
+func blobKeys(attachments []model.Attachment) []string {
+	keys := []string{}
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.BlobKey)
+	}
+	return keys
+}
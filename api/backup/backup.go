@@ -0,0 +1,397 @@
+// Package backup dumps all data of a TodoStore as portable JSON
+// and restores such dumps.
+//
+// Dumps carry a schema version. Restoring checks the version,
+// assigns new IDs to all records and runs in one transaction.
+// Dumps contain the metadata of attachments, not their contents,
+// which stay in the blob store under the same keys.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+)
+
+// Identifies backup files
+const Format = "go-todo-backup"
+
+// Version of the dump layout written by Create.
+// Dumps of older versions can still be restored
+//
+//	1: projects, tasks and feed tokens
+//	2: workflows of projects and states of tasks
+//	3: time entries
+//	4: dependencies
+//	5: comments
+//	6: attachments without their contents
+const SchemaVersion = 6
+
+// Upper limit for the size of restored backups used by the server
+const DefaultMaxSize = 1 << 30
+
+// A complete dump. IDs are only used to link the records of a dump
+type Dump struct {
+	Format        string             `json:"format"`
+	SchemaVersion int                `json:"schema_version"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Projects      []ProjectRecord    `json:"projects"`
+	Tasks         []TaskRecord       `json:"tasks"`
+	FeedTokens    []FeedTokenRecord  `json:"feed_tokens"`
+	TimeEntries   []TimeEntryRecord  `json:"time_entries"`
+	Dependencies  []DependencyRecord `json:"dependencies"`
+	Comments      []CommentRecord    `json:"comments"`
+	Attachments   []AttachmentRecord `json:"attachments"`
+}
+
+type ProjectRecord struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"created_at"`
+	// Empty for projects with the default workflow
+	Workflow []WorkflowStateRecord `json:"workflow,omitempty"`
+}
+
+type WorkflowStateRecord struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	WIPLimit int    `json:"wip_limit"`
+}
+
+type TaskRecord struct {
+	ID          uint       `json:"id"`
+	ProjectID   uint       `json:"project_id"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
+	Name        string     `json:"name"`
+	Priority    string     `json:"priority"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	Done        bool       `json:"done"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Notes       string     `json:"notes"`
+	Recurrence  string     `json:"recurrence"`
+	Tags        string     `json:"tags"`
+	ExternalUID string     `json:"external_uid"`
+	Rank        string     `json:"rank,omitempty"`
+	State       string     `json:"state,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type TimeEntryRecord struct {
+	TaskID uint       `json:"task_id"`
+	User   string     `json:"user"`
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"`
+	Notes  string     `json:"notes"`
+}
+
+// The task with TaskID is blocked by the task with BlockedByID
+type DependencyRecord struct {
+	TaskID      uint `json:"task_id"`
+	BlockedByID uint `json:"blocked_by_id"`
+}
+
+type CommentRecord struct {
+	TaskID    uint       `json:"task_id"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+// Metadata of an attachment, the content is kept in the blob with BlobKey
+type AttachmentRecord struct {
+	TaskID      uint      `json:"task_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	BlobKey     string    `json:"blob_key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type FeedTokenRecord struct {
+	Token     string    `json:"token"`
+	ProjectID *uint     `json:"project_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reads all data of the store
+func Create(t store.TodoStore) Dump {
+	dump := Dump{
+		Format:        Format,
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+		Projects:      []ProjectRecord{},
+		Tasks:         []TaskRecord{},
+		FeedTokens:    []FeedTokenRecord{},
+		TimeEntries:   []TimeEntryRecord{},
+		Dependencies:  []DependencyRecord{},
+		Comments:      []CommentRecord{},
+		Attachments:   []AttachmentRecord{},
+	}
+
+	for _, project := range t.GetAllProjects() {
+		record := ProjectRecord{
+			ID:        project.ID,
+			Name:      project.Name,
+			Archived:  project.Archived,
+			CreatedAt: project.CreatedAt,
+		}
+		for _, state := range t.GetWorkflowStates(project.ID) {
+			record.Workflow = append(record.Workflow, WorkflowStateRecord{
+				Name:     state.Name,
+				Category: state.Category,
+				WIPLimit: state.WIPLimit,
+			})
+		}
+		dump.Projects = append(dump.Projects, record)
+
+		for _, task := range t.GetAllProjectTasks(project) {
+			comments, _ := t.GetComments(task.ID, 0, -1)
+			for _, comment := range comments {
+				dump.Comments = append(dump.Comments, CommentRecord{
+					TaskID:    comment.TaskID,
+					Author:    comment.Author,
+					Body:      comment.Body,
+					CreatedAt: comment.CreatedAt,
+					EditedAt:  comment.EditedAt,
+				})
+			}
+
+			dump.Tasks = append(dump.Tasks, TaskRecord{
+				ID:          task.ID,
+				ProjectID:   task.ProjectID,
+				ParentID:    task.ParentID,
+				Name:        task.Name,
+				Priority:    task.Priority,
+				Deadline:    task.Deadline,
+				Done:        task.Done,
+				CompletedAt: task.CompletedAt,
+				Notes:       task.Notes,
+				Recurrence:  task.Recurrence,
+				Tags:        task.Tags,
+				ExternalUID: task.ExternalUID,
+				Rank:        task.Rank,
+				State:       task.State,
+				CreatedAt:   task.CreatedAt,
+			})
+		}
+	}
+
+	for _, entry := range t.GetTimeEntries(store.TimeEntryQuery{}) {
+		dump.TimeEntries = append(dump.TimeEntries, TimeEntryRecord{
+			TaskID: entry.TaskID,
+			User:   entry.User,
+			Start:  entry.Start,
+			End:    entry.End,
+			Notes:  entry.Notes,
+		})
+	}
+
+	for _, dependency := range t.GetAllDependencies() {
+		dump.Dependencies = append(dump.Dependencies, DependencyRecord{
+			TaskID:      dependency.TaskID,
+			BlockedByID: dependency.BlockedByID,
+		})
+	}
+
+	for _, attachment := range t.GetAllAttachments() {
+		dump.Attachments = append(dump.Attachments, AttachmentRecord{
+			TaskID:      attachment.TaskID,
+			Name:        attachment.Name,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			SHA256:      attachment.SHA256,
+			BlobKey:     attachment.BlobKey,
+			CreatedAt:   attachment.CreatedAt,
+		})
+	}
+
+	for _, feedToken := range t.GetAllFeedTokens() {
+		dump.FeedTokens = append(dump.FeedTokens, FeedTokenRecord{
+			Token:     feedToken.Token,
+			ProjectID: feedToken.ProjectID,
+			CreatedAt: feedToken.CreatedAt,
+		})
+	}
+
+	return dump
+}
+
+// Writes a dump of the store as indented JSON
+func Write(t store.TodoStore, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Create(t))
+}
+
+// Reads and validates a dump
+func Read(r io.Reader) (Dump, error) {
+	dump := Dump{}
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return dump, fmt.Errorf("invalid backup: %v", err)
+	}
+	return dump, dump.Validate()
+}
+
+// Checks the format, the schema version and the references between records
+func (d Dump) Validate() error {
+	if d.Format != Format {
+		return fmt.Errorf("invalid backup: format must be %s", Format)
+	}
+	if d.SchemaVersion < 1 || d.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("unsupported schema version %d, supported are 1 to %d", d.SchemaVersion, SchemaVersion)
+	}
+
+	projects := map[uint]bool{}
+	projectNames := map[string]bool{}
+	for _, project := range d.Projects {
+		if project.Name == "" {
+			return fmt.Errorf("invalid backup: project %d has no name", project.ID)
+		}
+		if projects[project.ID] || projectNames[project.Name] {
+			return fmt.Errorf("invalid backup: project %s is duplicated", project.Name)
+		}
+		if len(project.Workflow) > 0 {
+			if err := workflow.Validate(project.workflow()); err != nil {
+				return fmt.Errorf("invalid backup: workflow of project %s: %v", project.Name, err)
+			}
+		}
+		projects[project.ID] = true
+		projectNames[project.Name] = true
+	}
+
+	tasks := map[uint]bool{}
+	taskNames := map[string]bool{}
+	for _, task := range d.Tasks {
+		key := fmt.Sprintf("%d/%s", task.ProjectID, task.Name)
+		switch {
+		case task.Name == "":
+			return fmt.Errorf("invalid backup: task %d has no name", task.ID)
+		case !projects[task.ProjectID]:
+			return fmt.Errorf("invalid backup: task %s belongs to unknown project %d", task.Name, task.ProjectID)
+		case tasks[task.ID] || taskNames[key]:
+			return fmt.Errorf("invalid backup: task %s is duplicated", task.Name)
+		}
+		if err := ical.ValidateRecurrence(task.Recurrence); err != nil {
+			return fmt.Errorf("invalid backup: task %s: %v", task.Name, err)
+		}
+		tasks[task.ID] = true
+		taskNames[key] = true
+	}
+	for _, task := range d.Tasks {
+		if task.ParentID != nil && !tasks[*task.ParentID] {
+			return fmt.Errorf("invalid backup: task %s has unknown parent %d", task.Name, *task.ParentID)
+		}
+	}
+
+	for _, entry := range d.TimeEntries {
+		if !tasks[entry.TaskID] {
+			return fmt.Errorf("invalid backup: time entry of unknown task %d", entry.TaskID)
+		}
+	}
+
+	for _, comment := range d.Comments {
+		if !tasks[comment.TaskID] {
+			return fmt.Errorf("invalid backup: comment of unknown task %d", comment.TaskID)
+		}
+	}
+
+	for _, dependency := range d.Dependencies {
+		if !tasks[dependency.TaskID] || !tasks[dependency.BlockedByID] {
+			return fmt.Errorf("invalid backup: dependency of unknown task %d", dependency.TaskID)
+		}
+	}
+
+	blobKeys := map[string]bool{}
+	for _, attachment := range d.Attachments {
+		switch {
+		case !tasks[attachment.TaskID]:
+			return fmt.Errorf("invalid backup: attachment of unknown task %d", attachment.TaskID)
+		case attachment.BlobKey == "":
+			return fmt.Errorf("invalid backup: attachment %s without blob key", attachment.Name)
+		case blobKeys[attachment.BlobKey]:
+			return fmt.Errorf("invalid backup: blob %s is duplicated", attachment.BlobKey)
+		}
+		blobKeys[attachment.BlobKey] = true
+	}
+
+	for _, feedToken := range d.FeedTokens {
+		if feedToken.Token == "" {
+			return fmt.Errorf("invalid backup: feed token without token")
+		}
+		if feedToken.ProjectID != nil && !projects[*feedToken.ProjectID] {
+			return fmt.Errorf("invalid backup: feed token of unknown project %d", *feedToken.ProjectID)
+		}
+	}
+	return nil
+}
+
+// Converts the workflow of a project record to workflow states
+func (r ProjectRecord) workflow() []model.WorkflowState {
+	states := []model.WorkflowState{}
+	for _, state := range r.Workflow {
+		states = append(states, model.WorkflowState{Name: state.Name, Category: state.Category, WIPLimit: state.WIPLimit})
+	}
+	return states
+}
+
+// Converts a task record to a task of the project
+func (r TaskRecord) task(projectID uint) model.Task {
+	task := model.Task{
+		Name:        r.Name,
+		Priority:    r.Priority,
+		Deadline:    r.Deadline,
+		Done:        r.Done,
+		CompletedAt: r.CompletedAt,
+		Notes:       r.Notes,
+		Recurrence:  r.Recurrence,
+		Tags:        r.Tags,
+		ExternalUID: r.ExternalUID,
+		Rank:        r.Rank,
+		State:       r.State,
+		ProjectID:   projectID,
+	}
+	task.CreatedAt = r.CreatedAt
+	return task
+}
+
+// Converts a time entry record to a time entry of the task
+func (r TimeEntryRecord) timeEntry(taskID uint) model.TimeEntry {
+	return model.TimeEntry{TaskID: taskID, User: r.User, Start: r.Start, End: r.End, Notes: r.Notes}
+}
+
+// Converts a comment record to a comment on the task
+func (r CommentRecord) comment(taskID uint) model.Comment {
+	comment := model.Comment{TaskID: taskID, Author: r.Author, Body: r.Body, EditedAt: r.EditedAt}
+	comment.CreatedAt = r.CreatedAt
+	return comment
+}
+
+// Converts an attachment record to an attachment of the task
+func (r AttachmentRecord) attachment(taskID uint) model.Attachment {
+	attachment := model.Attachment{
+		TaskID:      taskID,
+		Name:        r.Name,
+		ContentType: r.ContentType,
+		Size:        r.Size,
+		SHA256:      r.SHA256,
+		BlobKey:     r.BlobKey,
+	}
+	attachment.CreatedAt = r.CreatedAt
+	return attachment
+}
+
+// Converts a feed token record to a feed token without project
+func (r FeedTokenRecord) feedToken() model.FeedToken {
+	feedToken := model.FeedToken{Token: r.Token}
+	feedToken.CreatedAt = r.CreatedAt
+	return feedToken
+}
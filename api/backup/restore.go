@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Restore modes
+const (
+	// Keeps existing data. Projects are matched by name,
+	// tasks by project and name and updated from the backup
+	ModeMerge = "merge"
+	// Deletes all existing data before restoring
+	ModeReplace = "replace"
+)
+
+// Number of restored records
+type RestoreReport struct {
+	Mode         string `json:"mode"`
+	Projects     int    `json:"projects"`
+	Tasks        int    `json:"tasks"`
+	FeedTokens   int    `json:"feed_tokens"`
+	TimeEntries  int    `json:"time_entries"`
+	Dependencies int    `json:"dependencies"`
+	Comments     int    `json:"comments"`
+	Attachments  int    `json:"attachments"`
+}
+
+// Restores a validated dump in one transaction.
+// Records get new IDs, references between them are remapped.
+// Attachments refer to the blobs of the dump, stores deleting the blobs
+// of deleted attachments must keep blobs that are referenced again
+// once the transaction is committed
+func Restore(t store.TodoStore, dump Dump, mode string) (RestoreReport, error) {
+	report := RestoreReport{Mode: mode}
+	if mode != ModeMerge && mode != ModeReplace {
+		return report, fmt.Errorf("mode must be %s or %s", ModeMerge, ModeReplace)
+	}
+
+	err := t.Transaction(func(tx store.TodoStore) error {
+		if mode == ModeReplace {
+			if err := tx.DeleteAll(); err != nil {
+				return err
+			}
+		}
+
+		// Maps the IDs of the dump to the new IDs
+		projectIDs := map[uint]uint{}
+		projectNames := map[uint]string{}
+		taskIDs := map[uint]uint{}
+
+		for _, record := range dump.Projects {
+			project := tx.GetProject(record.Name)
+			if project.Name == "" {
+				if err := tx.PostProject(record.Name); err != nil {
+					return err
+				}
+				project = tx.GetProject(record.Name)
+				project.CreatedAt = record.CreatedAt
+			}
+
+			project.Archived = record.Archived
+			if err := tx.UpdateProject(project); err != nil {
+				return err
+			}
+			if len(record.Workflow) > 0 {
+				if err := tx.SetWorkflowStates(project.ID, record.workflow()); err != nil {
+					return err
+				}
+			}
+			projectIDs[record.ID] = project.ID
+			projectNames[record.ID] = project.Name
+			report.Projects++
+		}
+
+		for _, record := range dump.Tasks {
+			task := record.task(projectIDs[record.ProjectID])
+			existing := tx.GetTask(projectNames[record.ProjectID], record.Name)
+
+			var err error
+			if existing.ID != 0 {
+				task.Model = existing.Model
+				// Dumps without ranks keep the order of existing tasks
+				if task.Rank == "" {
+					task.Rank = existing.Rank
+				}
+				err = tx.UpdateTask(task)
+			} else {
+				err = tx.PostTask(task)
+			}
+			if err != nil {
+				return err
+			}
+			taskIDs[record.ID] = tx.GetTask(projectNames[record.ProjectID], record.Name).ID
+			report.Tasks++
+		}
+
+		// Parents are linked once all tasks have their new IDs
+		for _, record := range dump.Tasks {
+			task := tx.GetTask(projectNames[record.ProjectID], record.Name)
+			task.ParentID = nil
+			if record.ParentID != nil {
+				parentID := taskIDs[*record.ParentID]
+				task.ParentID = &parentID
+			}
+			if err := tx.UpdateTask(task); err != nil {
+				return err
+			}
+		}
+
+		for _, record := range dump.TimeEntries {
+			entry := record.timeEntry(taskIDs[record.TaskID])
+
+			// Merging the same dump again keeps the entries once
+			duplicate := false
+			for _, existing := range tx.GetTimeEntries(store.TimeEntryQuery{TaskIDs: []uint{entry.TaskID}, User: entry.User}) {
+				duplicate = duplicate || existing.Start.Equal(entry.Start)
+			}
+			if duplicate {
+				continue
+			}
+
+			if _, err := tx.PostTimeEntry(entry); err != nil {
+				return err
+			}
+			report.TimeEntries++
+		}
+
+		for _, record := range dump.Comments {
+			comment := record.comment(taskIDs[record.TaskID])
+
+			// Merging the same dump again keeps the comments once
+			duplicate := false
+			existing, _ := tx.GetComments(comment.TaskID, 0, -1)
+			for _, other := range existing {
+				duplicate = duplicate || (other.Author == comment.Author && other.CreatedAt.Equal(comment.CreatedAt))
+			}
+			if duplicate {
+				continue
+			}
+
+			if err := tx.PostComment(comment); err != nil {
+				return err
+			}
+			report.Comments++
+		}
+
+		// Dependencies that exist already are kept once,
+		// dependencies creating a cycle fail the restore
+		for _, record := range dump.Dependencies {
+			tasks := tx.GetTasksByID([]uint{taskIDs[record.TaskID], taskIDs[record.BlockedByID]})
+			if len(tasks) != 2 {
+				return fmt.Errorf("dependency of unknown task %d", record.TaskID)
+			}
+			task, blocker := tasks[0], tasks[1]
+			if task.ID != taskIDs[record.TaskID] {
+				task, blocker = blocker, task
+			}
+
+			_, err := dependency.Add(tx, task, blocker)
+			if errors.Is(err, dependency.ErrExists) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			report.Dependencies++
+		}
+
+		// Blobs are shared by one attachment,
+		// so merging the same dump again keeps the attachments once
+		blobKeys := map[string]bool{}
+		for _, existing := range tx.GetAllAttachments() {
+			blobKeys[existing.BlobKey] = true
+		}
+		for _, record := range dump.Attachments {
+			if blobKeys[record.BlobKey] {
+				continue
+			}
+			if err := tx.PostAttachment(record.attachment(taskIDs[record.TaskID])); err != nil {
+				return err
+			}
+			report.Attachments++
+		}
+
+		for _, record := range dump.FeedTokens {
+			if tx.GetFeedToken(record.Token).Token != "" {
+				continue
+			}
+
+			feedToken := record.feedToken()
+			if record.ProjectID != nil {
+				projectID := projectIDs[*record.ProjectID]
+				feedToken.ProjectID = &projectID
+			}
+			if err := tx.PostFeedToken(feedToken); err != nil {
+				return err
+			}
+			report.FeedTokens++
+		}
+		return nil
+	})
+
+	if err != nil {
+		return RestoreReport{Mode: mode}, err
+	}
+	return report, nil
+}
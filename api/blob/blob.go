@@ -0,0 +1,112 @@
+// Package blob stores the contents of attachments.
+//
+// Store is implemented by FileStore, which keeps every blob in a file of
+// a local directory. Other implementations, e.g. for object storage,
+// can be passed to the server instead.
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Stores blobs by key. Keys consist of letters, digits, - and _
+type Store interface {
+	// Stores the content under the key, replacing existing content.
+	// Returns the number of bytes written
+	Put(key string, content io.Reader) (int64, error)
+	// Opens the content of the key. Fails with ErrNotFound for unknown keys
+	Get(key string) (io.ReadCloser, error)
+	// Deletes the content of the key. Unknown keys are no error
+	Delete(key string) error
+}
+
+// Matches valid keys
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Stores blobs in a directory of the local filesystem
+type FileStore struct {
+	dir string
+}
+
+// Creates a FileStore and its directory if it does not exist
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Writes the content to a temporary file, which replaces the blob
+// once it is complete. Readers never see partly written blobs
+func (f *FileStore) Put(key string, content io.Reader) (int64, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return written, err
+	}
+	if err := file.Close(); err != nil {
+		return written, err
+	}
+	return written, os.Rename(file.Name(), path)
+}
+
+func (f *FileStore) Get(key string) (io.ReadCloser, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (f *FileStore) Delete(key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Returns the path of the file of a key. Blobs are spread over
+// subdirectories named after the first two characters of their keys
+func (f *FileStore) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	prefix := key
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(f.dir, prefix, key), nil
+}
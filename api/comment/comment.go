@@ -0,0 +1,102 @@
+// Package comment manages the comment threads of tasks.
+//
+// Comment bodies are Markdown. They are rendered to HTML without
+// raw HTML and images and with links to trusted protocols only.
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/russross/blackfriday/v2"
+)
+
+// Comments per page
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Upper limit for the length of comment bodies
+const MaxBodyLength = 10000
+
+var (
+	ErrNotAuthor = errors.New("comments can only be changed by their author")
+	ErrBody      = fmt.Errorf("body must have between 1 and %d characters", MaxBodyLength)
+)
+
+// Flags of the HTML renderer. Raw HTML is dropped, links to
+// untrusted protocols like javascript: are rendered as text.
+// Images are dropped as well, Safelink does not check their URLs
+const htmlFlags = blackfriday.CommonHTMLFlags | blackfriday.SkipHTML | blackfriday.SkipImages |
+	blackfriday.Safelink | blackfriday.NofollowLinks | blackfriday.NoreferrerLinks | blackfriday.NoopenerLinks
+
+// A page of the comments of a task
+type Page struct {
+	// Number of all comments of the task
+	Total    int             `json:"total"`
+	Offset   int             `json:"offset"`
+	Limit    int             `json:"limit"`
+	Comments []model.Comment `json:"comments"`
+}
+
+// Renders a Markdown body to HTML
+func Render(body string) string {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: htmlFlags})
+	return string(blackfriday.Run([]byte(body), blackfriday.WithRenderer(renderer)))
+}
+
+// Returns a page of the comments of a task with rendered bodies
+func List(t store.TodoStore, task model.Task, offset, limit int) Page {
+	comments, total := t.GetComments(task.ID, offset, limit)
+	for i := range comments {
+		comments[i].BodyHTML = Render(comments[i].Body)
+	}
+	return Page{Total: total, Offset: offset, Limit: limit, Comments: comments}
+}
+
+// Adds a comment of author to the task
+func Post(t store.TodoStore, task model.Task, author, body string) error {
+	if err := validate(body); err != nil {
+		return err
+	}
+	return t.PostComment(model.Comment{TaskID: task.ID, Author: author, Body: body})
+}
+
+// Replaces the body of a comment. Only the author can edit a comment
+func Edit(t store.TodoStore, comment model.Comment, author, body string) (model.Comment, error) {
+	if comment.Author != author {
+		return comment, ErrNotAuthor
+	}
+	if err := validate(body); err != nil {
+		return comment, err
+	}
+	if body == comment.Body {
+		return comment, nil
+	}
+
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = &now
+	return comment, t.UpdateComment(comment)
+}
+
+// Deletes a comment. Only the author can delete a comment
+func Delete(t store.TodoStore, comment model.Comment, author string) error {
+	if comment.Author != author {
+		return ErrNotAuthor
+	}
+	return t.DeleteComment(comment)
+}
+
+// Checks that a body is not blank and not too long
+func validate(body string) error {
+	if strings.TrimSpace(body) == "" || len([]rune(body)) > MaxBodyLength {
+		return ErrBody
+	}
+	return nil
+}
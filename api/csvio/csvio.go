@@ -0,0 +1,96 @@
+// Package csvio exports and imports tasks as CSV.
+//
+// Exported files use the columns listed in Columns:
+//
+//	project     name of the project
+//	name        name of the task
+//	priority    priority of the task
+//	deadline    RFC 3339 timestamp, empty if the task has no deadline
+//	done        true or false
+//	notes       notes of the task
+//	tags        comma separated tags
+//	recurrence  RRULE of recurring tasks, e.g. FREQ=WEEKLY
+//
+// Imports accept the same columns in any order. Only the name column
+// is required, other header names can be mapped to columns.
+//
+// Cells starting with =, +, - or @ are exported with a leading ' so
+// spreadsheets don't run them as formulas. Imports strip it again.
+package csvio
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Columns of exported files
+var Columns = []string{"project", "name", "priority", "deadline", "done", "notes", "tags", "recurrence"}
+
+// Writes tasks as CSV rows
+type Writer struct {
+	csv *csv.Writer
+}
+
+// Creates a Writer and writes the header row
+func NewWriter(w io.Writer) (*Writer, error) {
+	writer := &Writer{csv: csv.NewWriter(w)}
+	err := writer.csv.Write(Columns)
+	return writer, err
+}
+
+// Writes a task of the project
+func (w *Writer) Write(project model.Project, task model.Task) error {
+	deadline := ""
+	if task.Deadline != nil {
+		deadline = task.Deadline.UTC().Format(time.RFC3339)
+	}
+
+	record := []string{
+		project.Name,
+		task.Name,
+		task.Priority,
+		deadline,
+		strconv.FormatBool(task.Done),
+		task.Notes,
+		task.Tags,
+		task.Recurrence,
+	}
+	for i := range record {
+		record[i] = escapeFormula(record[i])
+	}
+	return w.csv.Write(record)
+}
+
+// Writes buffered rows to the underlying writer
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Checks if a spreadsheet would run the cell as formula,
+// also if it is already escaped by apostrophes
+func isFormula(cell string) bool {
+	cell = strings.TrimLeft(cell, "'")
+	return cell != "" && strings.ContainsRune("=+-@", rune(cell[0]))
+}
+
+// Prefixes formulas with an apostrophe
+func escapeFormula(cell string) string {
+	if isFormula(cell) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// Removes the apostrophe added by escapeFormula
+func unescapeFormula(cell string) string {
+	if strings.HasPrefix(cell, "'") && isFormula(cell) {
+		return cell[1:]
+	}
+	return cell
+}
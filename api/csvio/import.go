@@ -0,0 +1,374 @@
+package csvio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Returned by Import if rows are invalid. Nothing was imported
+var ErrInvalidRows = errors.New("csv contains invalid rows")
+
+// Header names that are understood without an explicit mapping
+var headerAliases = map[string]string{
+	"title":       "name",
+	"task":        "name",
+	"due":         "deadline",
+	"due date":    "deadline",
+	"completed":   "done",
+	"description": "notes",
+	"categories":  "tags",
+	"rrule":       "recurrence",
+}
+
+// Accepted layouts of the deadline column
+var deadlineLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// Options of an import
+type ImportOptions struct {
+	// Import into this project. If empty the project column is required
+	Project string
+	// Maps header names of the file to column names
+	Mapping map[string]string
+	// Only validate the file and count the changes
+	DryRun bool
+}
+
+// Result of an import
+type ImportReport struct {
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	DryRun  bool       `json:"dry_run"`
+	Errors  []RowError `json:"errors"`
+}
+
+// A validation error of a row
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// A parsed row. Only the columns present in the file are applied
+type row struct {
+	line    int
+	project string
+	values  map[string]string
+	task    model.Task
+}
+
+// Imports or updates tasks from CSV. Tasks are matched by project and name.
+// All rows are applied in one transaction, if any row is invalid or
+// completes a task with open blockers nothing is imported and
+// ErrInvalidRows is returned with the row errors
+func Import(t store.TodoStore, options ImportOptions, r io.Reader) (ImportReport, error) {
+	report := ImportReport{DryRun: options.DryRun, Errors: []RowError{}}
+
+	rows, err := parse(r, options, &report)
+	if err != nil {
+		return report, err
+	}
+	if len(report.Errors) > 0 {
+		return report, ErrInvalidRows
+	}
+
+	if options.DryRun {
+		for _, row := range rows {
+			task := t.GetTask(row.project, row.task.Name)
+			if task.Name == "" {
+				report.Created++
+				continue
+			}
+			if err := checkBlocked(t, row, task); err != nil {
+				report.Errors = append(report.Errors, RowError{Row: row.line, Column: "done", Message: err.Error()})
+			}
+			report.Updated++
+		}
+		if len(report.Errors) > 0 {
+			report.Created, report.Updated = 0, 0
+			return report, ErrInvalidRows
+		}
+		return report, nil
+	}
+
+	err = t.Transaction(func(tx store.TodoStore) error {
+		for _, row := range rows {
+			created, err := apply(tx, row)
+			if errors.Is(err, dependency.ErrBlocked) {
+				report.Errors = append(report.Errors, RowError{Row: row.line, Column: "done", Message: err.Error()})
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("row %d: %v", row.line, err)
+			}
+			if created {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+		}
+		if len(report.Errors) > 0 {
+			return ErrInvalidRows
+		}
+		return nil
+	})
+	if err != nil {
+		report.Created, report.Updated = 0, 0
+	}
+	return report, err
+}
+
+// Reads and validates all rows
+func parse(r io.Reader, options ImportOptions, report *ImportReport) ([]row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	// Problems with the header are reported as errors of the first row
+	headerError := func(message string) ([]row, error) {
+		report.Errors = append(report.Errors, RowError{Row: 1, Message: message})
+		return nil, nil
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return headerError("csv is empty")
+	}
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return headerError(parseErr.Err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := mapHeader(header, options.Mapping)
+	if err != nil {
+		return headerError(err.Error())
+	}
+	if !contains(columns, "project") && options.Project == "" {
+		return headerError("column project is required")
+	}
+
+	rows := []row{}
+	seen := map[string]int{}
+	// The header is row 1
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			report.Errors = append(report.Errors, RowError{Row: line, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := map[string]string{}
+		for i, column := range columns {
+			if column != "" && i < len(record) {
+				values[column] = unescapeFormula(strings.TrimSpace(record[i]))
+			}
+		}
+
+		parsed, rowErrors := parseRow(line, values, options)
+		report.Errors = append(report.Errors, rowErrors...)
+		if len(rowErrors) > 0 {
+			continue
+		}
+
+		key := parsed.project + "\x00" + parsed.task.Name
+		if first, ok := seen[key]; ok {
+			report.Errors = append(report.Errors, RowError{
+				Row:     line,
+				Column:  "name",
+				Message: fmt.Sprintf("task is already imported by row %d", first),
+			})
+			continue
+		}
+		seen[key] = line
+		rows = append(rows, parsed)
+	}
+
+	return rows, nil
+}
+
+// Maps the header row to column names. Unknown headers are ignored
+func mapHeader(header []string, mapping map[string]string) ([]string, error) {
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		column, ok := mapping[name]
+		if !ok {
+			column = strings.ToLower(name)
+			if alias, ok := headerAliases[column]; ok {
+				column = alias
+			}
+		}
+		if !contains(Columns, column) {
+			continue
+		}
+		if contains(columns, column) {
+			return nil, fmt.Errorf("column %s is mapped twice", column)
+		}
+		columns[i] = column
+	}
+
+	if !contains(columns, "name") {
+		return nil, fmt.Errorf("column name is required")
+	}
+	return columns, nil
+}
+
+// Validates the values of a row
+func parseRow(line int, values map[string]string, options ImportOptions) (row, []RowError) {
+	parsed := row{line: line, values: values, project: options.Project}
+	errs := []RowError{}
+	fail := func(column, message string) {
+		errs = append(errs, RowError{Row: line, Column: column, Message: message})
+	}
+
+	parsed.task.Name = values["name"]
+	if parsed.task.Name == "" {
+		fail("name", "name is required")
+	}
+
+	if project, ok := values["project"]; ok {
+		switch {
+		case options.Project != "" && project != "" && project != options.Project:
+			fail("project", fmt.Sprintf("task belongs to project %s", project))
+		case options.Project == "" && project == "":
+			fail("project", "project is required")
+		case options.Project == "":
+			parsed.project = project
+		}
+	}
+
+	parsed.task.Priority = values["priority"]
+	parsed.task.Notes = values["notes"]
+	parsed.task.SetTags(strings.Split(values["tags"], ","))
+
+	if deadline := values["deadline"]; deadline != "" {
+		parsedDeadline, err := parseDeadline(deadline)
+		if err != nil {
+			fail("deadline", err.Error())
+		}
+		parsed.task.Deadline = parsedDeadline
+	}
+
+	if done := values["done"]; done != "" {
+		parsedDone, err := parseDone(done)
+		if err != nil {
+			fail("done", err.Error())
+		}
+		parsed.task.Done = parsedDone
+	}
+
+	parsed.task.Recurrence = values["recurrence"]
+	if err := ical.ValidateRecurrence(parsed.task.Recurrence); err != nil {
+		fail("recurrence", err.Error())
+	}
+
+	return parsed, errs
+}
+
+// Creates or updates the task of a row. Missing projects are created
+func apply(t store.TodoStore, row row) (bool, error) {
+	project := t.GetProject(row.project)
+	if project.Name == "" {
+		if err := t.PostProject(row.project); err != nil {
+			return false, err
+		}
+		project = t.GetProject(row.project)
+	}
+
+	task := t.GetTask(project.Name, row.task.Name)
+	if task.Name == "" {
+		task = row.task
+		task.ProjectID = project.ID
+		if task.Done {
+			task.Done = false
+			task.CompleteTask()
+		}
+		return true, t.PostTask(task)
+	}
+
+	if err := checkBlocked(t, row, task); err != nil {
+		return false, err
+	}
+
+	// Only overwrite the columns present in the file
+	for column := range row.values {
+		switch column {
+		case "priority":
+			task.Priority = row.task.Priority
+		case "deadline":
+			task.Deadline = row.task.Deadline
+		case "done":
+			if row.task.Done {
+				task.CompleteTask()
+			} else {
+				task.ReopenTask()
+			}
+		case "notes":
+			task.Notes = row.task.Notes
+		case "tags":
+			task.Tags = row.task.Tags
+		case "recurrence":
+			task.Recurrence = row.task.Recurrence
+		}
+	}
+	return false, t.UpdateTask(task)
+}
+
+// Fails with dependency.ErrBlocked if the row completes
+// an existing task with open blockers
+func checkBlocked(t store.TodoStore, row row, task model.Task) error {
+	if _, ok := row.values["done"]; !ok || !row.task.Done || task.Done {
+		return nil
+	}
+	return dependency.CheckDone(t, task)
+}
+
+func parseDeadline(value string) (*time.Time, error) {
+	for _, layout := range deadlineLayouts {
+		if deadline, err := time.Parse(layout, value); err == nil {
+			return &deadline, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid deadline %q", value)
+}
+
+func parseDone(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "x", "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+
+	done, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid done value %q", value)
+	}
+	return done, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
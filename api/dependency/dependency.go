@@ -0,0 +1,203 @@
+// Package dependency links tasks that can't start until other tasks are done.
+//
+// A task is blocked while one of its blockers is open. Blockers can belong
+// to other projects. Dependencies that would create a cycle are refused.
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+var (
+	ErrSelf    = errors.New("a task can't block itself")
+	ErrExists  = errors.New("dependency already exists")
+	ErrCycle   = errors.New("dependency would create a cycle")
+	ErrBlocked = errors.New("task is blocked")
+)
+
+// A task on the other side of a dependency
+type Link struct {
+	// ID of the dependency
+	ID      uint       `json:"id"`
+	Project string     `json:"project"`
+	Task    model.Task `json:"task"`
+}
+
+// The dependencies of a task
+type Dependencies struct {
+	Blocked bool `json:"blocked"`
+	// Tasks that have to be done before the task
+	BlockedBy []Link `json:"blocked_by"`
+	// Tasks waiting for the task
+	Blocks []Link `json:"blocks"`
+}
+
+// Returns the dependencies of a task in both directions
+func Get(t store.TodoStore, task model.Task) Dependencies {
+	result := Dependencies{BlockedBy: []Link{}, Blocks: []Link{}}
+
+	ids := []uint{}
+	dependencies := t.GetDependencies([]uint{task.ID}, []uint{task.ID})
+	for _, dependency := range dependencies {
+		if dependency.TaskID == task.ID {
+			ids = append(ids, dependency.BlockedByID)
+		} else {
+			ids = append(ids, dependency.TaskID)
+		}
+	}
+
+	tasks := tasksByID(t, ids)
+	projectNames := map[uint]string{}
+	for _, project := range t.GetAllProjects() {
+		projectNames[project.ID] = project.Name
+	}
+
+	for _, dependency := range dependencies {
+		if dependency.TaskID == task.ID {
+			blocker := tasks[dependency.BlockedByID]
+			result.BlockedBy = append(result.BlockedBy, Link{ID: dependency.ID, Project: projectNames[blocker.ProjectID], Task: blocker})
+			result.Blocked = result.Blocked || !blocker.Done
+		} else {
+			blocked := tasks[dependency.TaskID]
+			result.Blocks = append(result.Blocks, Link{ID: dependency.ID, Project: projectNames[blocked.ProjectID], Task: blocked})
+		}
+	}
+	return result
+}
+
+// Makes task wait for blocker. Fails if task is blocker, the dependency
+// exists or blocker already waits for task, directly or through other tasks.
+// The checks run in the transaction that adds the dependency, so concurrent
+// calls can't create a cycle together
+func Add(t store.TodoStore, task, blocker model.Task) (model.TaskDependency, error) {
+	dependency := model.TaskDependency{TaskID: task.ID, BlockedByID: blocker.ID}
+	if task.ID == blocker.ID {
+		return dependency, ErrSelf
+	}
+
+	err := t.Transaction(func(tx store.TodoStore) error {
+		for _, existing := range tx.GetDependencies([]uint{task.ID}, nil) {
+			if existing.BlockedByID == blocker.ID {
+				return ErrExists
+			}
+		}
+
+		if path := findPath(tx, blocker.ID, task.ID); path != nil {
+			names := []string{task.Name}
+			tasks := tasksByID(tx, path)
+			for _, id := range path {
+				names = append(names, tasks[id].Name)
+			}
+			return fmt.Errorf("%w: %s", ErrCycle, strings.Join(names, " -> "))
+		}
+
+		if err := tx.PostDependency(dependency); err != nil {
+			return err
+		}
+		// Touch the task, its blocked status is cached with its Last-Modified
+		return tx.UpdateTask(task)
+	})
+	return dependency, err
+}
+
+// Deletes a dependency and touches the task that was blocked
+func Remove(t store.TodoStore, dependency model.TaskDependency) error {
+	return t.Transaction(func(tx store.TodoStore) error {
+		if err := tx.DeleteDependency(dependency); err != nil {
+			return err
+		}
+		for _, task := range tx.GetTasksByID([]uint{dependency.TaskID}) {
+			if err := tx.UpdateTask(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Fails with ErrBlocked if a blocker of the task is open
+func CheckDone(t store.TodoStore, task model.Task) error {
+	open := []string{}
+	for _, link := range Get(t, task).BlockedBy {
+		if !link.Task.Done {
+			open = append(open, link.Project+"/"+link.Task.Name)
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("%w by %s", ErrBlocked, strings.Join(open, ", "))
+	}
+	return nil
+}
+
+// Sets the blocked status of the tasks
+func MarkBlocked(t store.TodoStore, tasks []model.Task) {
+	blockers := map[uint][]uint{}
+	ids := []uint{}
+	for _, dependency := range t.GetDependencies(taskIDs(tasks), nil) {
+		blockers[dependency.TaskID] = append(blockers[dependency.TaskID], dependency.BlockedByID)
+		ids = append(ids, dependency.BlockedByID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	blockerTasks := tasksByID(t, ids)
+	for i := range tasks {
+		for _, id := range blockers[tasks[i].ID] {
+			tasks[i].Blocked = tasks[i].Blocked || !blockerTasks[id].Done
+		}
+	}
+}
+
+// Returns the path of blockers leading from one task to another,
+// including both tasks. nil if there is none
+func findPath(t store.TodoStore, from, to uint) []uint {
+	// Breadth-first search remembering where each task was reached from,
+	// the blockers of each level are loaded with one query
+	previous := map[uint]uint{from: from}
+	level := []uint{from}
+	for len(level) > 0 {
+		for _, id := range level {
+			if id == to {
+				path := []uint{}
+				for ; id != from; id = previous[id] {
+					path = append([]uint{id}, path...)
+				}
+				return append([]uint{from}, path...)
+			}
+		}
+
+		next := []uint{}
+		for _, dependency := range t.GetDependencies(level, nil) {
+			if _, seen := previous[dependency.BlockedByID]; !seen {
+				previous[dependency.BlockedByID] = dependency.TaskID
+				next = append(next, dependency.BlockedByID)
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// Returns the IDs of the tasks
+func taskIDs(tasks []model.Task) []uint {
+	ids := []uint{}
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+// Returns the tasks with the IDs by ID
+func tasksByID(t store.TodoStore, ids []uint) map[uint]model.Task {
+	tasks := map[uint]model.Task{}
+	for _, task := range t.GetTasksByID(ids) {
+		tasks[task.ID] = task
+	}
+	return tasks
+}
@@ -0,0 +1,10 @@
+package api
+
+import (
+	_ "embed"
+)
+
+// OpenAPI 3 document describing all routes of the TodoServer
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
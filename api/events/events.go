@@ -0,0 +1,172 @@
+// Package events publishes changes of tasks to subscribers.
+//
+// Store wraps a TodoStore and publishes an event for every task that is
+// created, updated or deleted through it. Changes made in a transaction
+// are published after the transaction is committed.
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Number of events buffered for each subscriber. Further events
+// are dropped until the subscriber catches up
+const bufferSize = 64
+
+type EventType string
+
+const (
+	TaskCreated EventType = "created"
+	TaskUpdated EventType = "updated"
+	TaskDeleted EventType = "deleted"
+)
+
+// A change of a task
+type TaskEvent struct {
+	Type    EventType
+	Project string
+	Task    model.Task
+}
+
+// Distributes events to all subscribers
+type Broker struct {
+	mutex       sync.Mutex
+	subscribers map[chan TaskEvent]bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[chan TaskEvent]bool{}}
+}
+
+// Returns a channel receiving all events published from now on and
+// a function to cancel the subscription, which closes the channel
+func (b *Broker) Subscribe() (<-chan TaskEvent, func()) {
+	events := make(chan TaskEvent, bufferSize)
+
+	b.mutex.Lock()
+	b.subscribers[events] = true
+	b.mutex.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, events)
+			b.mutex.Unlock()
+			close(events)
+		})
+	}
+	return events, cancel
+}
+
+// Sends the event to all subscribers without blocking
+func (b *Broker) Publish(event TaskEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// TodoStore publishing the changes of tasks
+type Store struct {
+	store.TodoStore
+	broker *Broker
+
+	// Events of an uncommitted transaction, nil outside of transactions
+	pending *[]TaskEvent
+}
+
+func NewStore(t store.TodoStore, broker *Broker) *Store {
+	return &Store{TodoStore: t, broker: broker}
+}
+
+// Runs fn in a transaction and publishes its events after the commit
+func (s *Store) Transaction(fn func(t store.TodoStore) error) error {
+	tx := &Store{broker: s.broker, pending: &[]TaskEvent{}}
+	err := s.TodoStore.Transaction(func(t store.TodoStore) error {
+		tx.TodoStore = t
+		return fn(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range *tx.pending {
+		s.publish(event)
+	}
+	return nil
+}
+
+// Returns a Store publishing to the same broker whose queries use the context
+func (s *Store) WithContext(ctx context.Context) store.TodoStore {
+	return &Store{TodoStore: s.TodoStore.WithContext(ctx), broker: s.broker, pending: s.pending}
+}
+
+func (s *Store) PostTask(task model.Task) error {
+	if err := s.TodoStore.PostTask(task); err != nil {
+		return err
+	}
+
+	// Publish the stored task with its ID
+	project := s.projectName(task.ProjectID)
+	if stored := s.TodoStore.GetTask(project, task.Name); stored.Name != "" {
+		task = stored
+	}
+	s.publish(TaskEvent{Type: TaskCreated, Project: project, Task: task})
+	return nil
+}
+
+func (s *Store) UpdateTask(task model.Task) error {
+	if err := s.TodoStore.UpdateTask(task); err != nil {
+		return err
+	}
+	s.publish(TaskEvent{Type: TaskUpdated, Project: s.projectName(task.ProjectID), Task: task})
+	return nil
+}
+
+func (s *Store) DeleteTask(task model.Task) error {
+	if err := s.TodoStore.DeleteTask(task); err != nil {
+		return err
+	}
+	s.publish(TaskEvent{Type: TaskDeleted, Project: s.projectName(task.ProjectID), Task: task})
+	return nil
+}
+
+// Publishes the deletion of every task of the project
+func (s *Store) DeleteProject(name string) error {
+	project := s.TodoStore.GetProject(name)
+	tasks := []model.Task{}
+	if project.ID != 0 {
+		tasks = s.TodoStore.GetAllProjectTasks(project)
+	}
+
+	if err := s.TodoStore.DeleteProject(name); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		s.publish(TaskEvent{Type: TaskDeleted, Project: project.Name, Task: task})
+	}
+	return nil
+}
+
+func (s *Store) publish(event TaskEvent) {
+	if s.pending != nil {
+		*s.pending = append(*s.pending, event)
+		return
+	}
+	s.broker.Publish(event)
+}
+
+// Looks up the name of a project by ID
+func (s *Store) projectName(id uint) string {
+	return s.TodoStore.GetProjectByID(id).Name
+}
@@ -0,0 +1,89 @@
+// Package graphqlapi serves projects and tasks through GraphQL.
+//
+// The tasks of all projects of a query are loaded together, so nested
+// queries need one database query per level instead of one per project.
+// Queries exceeding the Limits are rejected before they are executed.
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// A GraphQL request as sent by clients
+type Request struct {
+	Query         string                 `json:"query" form:"query" binding:"required"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+
+	// Rejects mutations, used for GET requests
+	QueryOnly bool `json:"-" form:"-"`
+}
+
+// Parses, validates and executes a request. executed is false if
+// the request was rejected before execution
+func Execute(ctx context.Context, t store.TodoStore, request Request, limits Limits) (result *graphql.Result, executed bool) {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(request.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	validation := graphql.ValidateDocument(&schema, document, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}, false
+	}
+
+	operation, fragments, err := findOperation(document, request.OperationName)
+	if err == nil && request.QueryOnly && operation.Operation != ast.OperationTypeQuery {
+		err = fmt.Errorf("%s operations are only allowed with POST", operation.Operation)
+	}
+	if err == nil {
+		err = limits.check(operation, fragments)
+	}
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       withState(ctx, newRequestState(t)),
+	}), true
+}
+
+// Returns the operation to execute and the fragments of the document
+func findOperation(document *ast.Document, name string) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition, error) {
+	var operation *ast.OperationDefinition
+	fragments := map[string]*ast.FragmentDefinition{}
+
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.OperationDefinition:
+			if name == "" && operation != nil {
+				return nil, nil, fmt.Errorf("operationName is required for documents with several operations")
+			}
+			if name == "" || (definition.Name != nil && definition.Name.Value == name) {
+				operation = definition
+			}
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		}
+	}
+
+	if operation == nil {
+		return nil, nil, fmt.Errorf("unknown operation %s", name)
+	}
+	return operation, fragments, nil
+}
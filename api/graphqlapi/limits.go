@@ -0,0 +1,105 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits of queries, checked before a query is executed
+type Limits struct {
+	// Maximum nesting of fields
+	MaxDepth int
+	// Maximum number of fields that can be resolved. Fields of lists
+	// count once per item, with the argument first or ListSize items
+	MaxComplexity int
+	// Assumed number of items of lists without argument first
+	ListSize int
+}
+
+var DefaultLimits = Limits{MaxDepth: 8, MaxComplexity: 5000, ListSize: 20}
+
+// Fields returning lists
+var listFields = map[string]bool{
+	"projects": true,
+	"tasks":    true,
+}
+
+// Checks depth and complexity of an operation. Introspection fields are not counted
+func (l Limits) check(operation *ast.OperationDefinition, fragments map[string]*ast.FragmentDefinition) error {
+	walker := limitWalker{limits: l, fragments: fragments}
+	depth, complexity := walker.walk(operation.SelectionSet, map[string]bool{})
+
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query has depth %d, the maximum is %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query has complexity %d, the maximum is %d", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+type limitWalker struct {
+	limits    Limits
+	fragments map[string]*ast.FragmentDefinition
+}
+
+// Returns depth and complexity of a selection set. visiting holds the
+// fragments on the current path, cycles are rejected by the validation
+func (w limitWalker) walk(selectionSet *ast.SelectionSet, visiting map[string]bool) (int, int) {
+	if selectionSet == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, selection := range selectionSet.Selections {
+		var childDepth, childComplexity int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			childDepth, childComplexity = w.walk(selection.SelectionSet, visiting)
+			childDepth++
+			childComplexity = 1 + childComplexity*w.listSize(selection)
+		case *ast.InlineFragment:
+			childDepth, childComplexity = w.walk(selection.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			childDepth, childComplexity = w.walk(fragment.SelectionSet, visiting)
+			delete(visiting, name)
+		}
+
+		if childDepth > depth {
+			depth = childDepth
+		}
+		complexity += childComplexity
+	}
+	return depth, complexity
+}
+
+// Number of items assumed for the children of a field
+func (w limitWalker) listSize(field *ast.Field) int {
+	if !listFields[field.Name.Value] {
+		return 1
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		if value, ok := argument.Value.GetValue().(string); ok {
+			var first int
+			if _, err := fmt.Sscan(value, &first); err == nil && first >= 0 {
+				return first
+			}
+		}
+	}
+	return w.limits.ListSize
+}
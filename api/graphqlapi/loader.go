@@ -0,0 +1,118 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+type contextKey struct{}
+
+// State of one request. The loaders collect the keys requested by all
+// resolvers of a level of the query and load them in one query,
+// instead of one query per project
+type requestState struct {
+	store    store.TodoStore
+	tasks    *taskLoader
+	projects *projectLoader
+}
+
+func newRequestState(t store.TodoStore) *requestState {
+	return &requestState{
+		store:    t,
+		tasks:    &taskLoader{store: t, pending: map[uint]bool{}, loaded: map[uint][]model.Task{}},
+		projects: &projectLoader{store: t},
+	}
+}
+
+func withState(ctx context.Context, state *requestState) context.Context {
+	return context.WithValue(ctx, contextKey{}, state)
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	return ctx.Value(contextKey{}).(*requestState)
+}
+
+// Drops loaded data after changes
+func (s *requestState) reset() {
+	s.tasks.reset()
+	s.projects.reset()
+}
+
+// Loads the tasks of projects
+type taskLoader struct {
+	store   store.TodoStore
+	mutex   sync.Mutex
+	pending map[uint]bool
+	loaded  map[uint][]model.Task
+}
+
+// Registers the project and returns a function returning its tasks.
+// The first call of such a function loads the tasks of all registered projects
+func (l *taskLoader) load(projectID uint) func() []model.Task {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, ok := l.loaded[projectID]; !ok {
+		l.pending[projectID] = true
+	}
+
+	return func() []model.Task {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.fetch()
+		return l.loaded[projectID]
+	}
+}
+
+func (l *taskLoader) fetch() {
+	if len(l.pending) == 0 {
+		return
+	}
+
+	ids := []uint{}
+	for id := range l.pending {
+		ids = append(ids, id)
+		l.loaded[id] = []model.Task{}
+	}
+	for _, task := range l.store.GetTasksOfProjects(ids) {
+		l.loaded[task.ProjectID] = append(l.loaded[task.ProjectID], task)
+	}
+	l.pending = map[uint]bool{}
+}
+
+func (l *taskLoader) reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.pending = map[uint]bool{}
+	l.loaded = map[uint][]model.Task{}
+}
+
+// Loads projects by ID. All projects are loaded with the first call
+type projectLoader struct {
+	store  store.TodoStore
+	mutex  sync.Mutex
+	loaded map[uint]model.Project
+}
+
+func (l *projectLoader) load(id uint) func() (model.Project, bool) {
+	return func() (model.Project, bool) {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if l.loaded == nil {
+			l.loaded = map[uint]model.Project{}
+			for _, project := range l.store.GetAllProjects() {
+				l.loaded[project.ID] = project
+			}
+		}
+		project, ok := l.loaded[id]
+		return project, ok
+	}
+}
+
+func (l *projectLoader) reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.loaded = nil
+}
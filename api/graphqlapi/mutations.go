@@ -0,0 +1,209 @@
+package graphqlapi
+
+import (
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+)
+
+// Errors with the messages of the REST API
+var (
+	errProjectNotFound = errors.New("project not found")
+	errProjectExists   = errors.New("project already existing")
+	errTaskNotFound    = errors.New("task not found")
+	errInternal        = errors.New("internal server error")
+)
+
+// Unexpected error of the store, only its message is sent to clients
+type storeError struct {
+	err error
+}
+
+func (e storeError) Error() string {
+	return e.err.Error()
+}
+
+// Wraps a mutation. Loaded data is dropped after the change.
+// Store errors are logged with the request ID and sent as errInternal
+func mutation(fn func(t store.TodoStore, args map[string]interface{}) (interface{}, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		state := stateFrom(p.Context)
+		defer state.reset()
+
+		result, err := fn(state.store, p.Args)
+		var internal storeError
+		if errors.As(err, &internal) {
+			logging.FromContext(p.Context).Error("internal error", "field", p.Info.FieldName, "error", internal.err)
+			return nil, errInternal
+		}
+		return result, err
+	}
+}
+
+func createProject(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	name := args["name"].(string)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if t.GetProject(name).Name != "" {
+		return nil, errProjectExists
+	}
+
+	if err := t.PostProject(name); err != nil {
+		return nil, storeError{err}
+	}
+	return t.GetProject(name), nil
+}
+
+func renameProject(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	project, err := getProject(t, args["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	newName := args["newName"].(string)
+	if newName == "" {
+		return nil, errors.New("new name is required")
+	}
+	if newName != project.Name && t.GetProject(newName).Name != "" {
+		return nil, errProjectExists
+	}
+
+	project.Name = newName
+	if err := t.UpdateProject(project); err != nil {
+		return nil, storeError{err}
+	}
+	return t.GetProject(newName), nil
+}
+
+func archiveProject(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	project, err := getProject(t, args["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if args["archived"].(bool) {
+		project.ArchiveProject()
+	} else {
+		project.UnArchiveProject()
+	}
+	if err := t.UpdateProject(project); err != nil {
+		return nil, storeError{err}
+	}
+	return t.GetProject(project.Name), nil
+}
+
+func deleteProject(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	project, err := getProject(t, args["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.DeleteProject(project.Name); err != nil {
+		return nil, storeError{err}
+	}
+	return true, nil
+}
+
+func createTask(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	project, err := getProject(t, args["project"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	input := args["input"].(map[string]interface{})
+	if err := validateTaskInput(input); err != nil {
+		return nil, err
+	}
+
+	task := model.Task{ProjectID: project.ID}
+	applyTaskInput(&task, input)
+	if err := t.PostTask(task); err != nil {
+		return nil, storeError{err}
+	}
+	return t.GetTask(project.Name, task.Name), nil
+}
+
+func updateTask(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	projectName := args["project"].(string)
+	task, err := getTask(t, projectName, args["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	input := args["input"].(map[string]interface{})
+	if err := validateTaskInput(input); err != nil {
+		return nil, err
+	}
+
+	applyTaskInput(&task, input)
+	if err := t.UpdateTask(task); err != nil {
+		return nil, storeError{err}
+	}
+	return t.GetTask(projectName, task.Name), nil
+}
+
+func completeTask(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	task, err := getTask(t, args["project"].(string), args["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	// Move the task into the first closed or open state of the workflow
+	task, err = workflow.Complete(t, t.GetProject(args["project"].(string)), task, args["done"].(bool), args["force"].(bool))
+	if err != nil && !errors.Is(err, workflow.ErrWIPLimit) && !errors.Is(err, dependency.ErrBlocked) {
+		return nil, storeError{err}
+	}
+	return task, err
+}
+
+func deleteTask(t store.TodoStore, args map[string]interface{}) (interface{}, error) {
+	task, err := getTask(t, args["project"].(string), args["name"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.DeleteTask(task); err != nil {
+		return nil, storeError{err}
+	}
+	return true, nil
+}
+
+func getProject(t store.TodoStore, name string) (model.Project, error) {
+	project := t.GetProject(name)
+	if project.Name == "" {
+		return project, errProjectNotFound
+	}
+	return project, nil
+}
+
+func getTask(t store.TodoStore, projectName, taskName string) (model.Task, error) {
+	if _, err := getProject(t, projectName); err != nil {
+		return model.Task{}, err
+	}
+
+	task := t.GetTask(projectName, taskName)
+	if task.Name == "" {
+		return task, errTaskNotFound
+	}
+	return task, nil
+}
+
+// Copies the fields of a TaskInput into a task
+func applyTaskInput(task *model.Task, input map[string]interface{}) {
+	task.Name, _ = input["name"].(string)
+	task.Priority, _ = input["priority"].(string)
+	task.Notes, _ = input["notes"].(string)
+	task.Recurrence, _ = input["recurrence"].(string)
+
+	task.Deadline = nil
+	if deadline, ok := input["deadline"].(time.Time); ok {
+		task.Deadline = &deadline
+	}
+}
@@ -0,0 +1,110 @@
+package graphqlapi
+
+import (
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Query.projects
+func resolveProjects(p graphql.ResolveParams) (interface{}, error) {
+	state := stateFrom(p.Context)
+
+	projects := []model.Project{}
+	for _, project := range state.store.GetAllProjects() {
+		if archived, ok := p.Args["archived"].(bool); ok && project.Archived != archived {
+			continue
+		}
+		projects = append(projects, project)
+	}
+
+	if first, ok := p.Args["first"].(int); ok && first >= 0 && first < len(projects) {
+		projects = projects[:first]
+	}
+	return projects, nil
+}
+
+// Query.project, null if the project does not exist
+func resolveProject(p graphql.ResolveParams) (interface{}, error) {
+	project := stateFrom(p.Context).store.GetProject(p.Args["name"].(string))
+	if project.Name == "" {
+		return nil, nil
+	}
+	return project, nil
+}
+
+// Query.task, null if the task does not exist
+func resolveTask(p graphql.ResolveParams) (interface{}, error) {
+	task := stateFrom(p.Context).store.GetTask(p.Args["project"].(string), p.Args["name"].(string))
+	if task.Name == "" {
+		return nil, nil
+	}
+	return task, nil
+}
+
+// Project.tasks, loaded together with the tasks of the other projects of the query
+func resolveProjectTasks(p graphql.ResolveParams) (interface{}, error) {
+	load := stateFrom(p.Context).tasks.load(p.Source.(model.Project).ID)
+
+	return func() (interface{}, error) {
+		tasks := filterTasks(load(), p.Args)
+		if first, ok := p.Args["first"].(int); ok && first >= 0 && first < len(tasks) {
+			tasks = tasks[:first]
+		}
+		return tasks, nil
+	}, nil
+}
+
+// Project.taskCount
+func resolveProjectTaskCount(p graphql.ResolveParams) (interface{}, error) {
+	load := stateFrom(p.Context).tasks.load(p.Source.(model.Project).ID)
+
+	return func() (interface{}, error) {
+		return len(filterTasks(load(), p.Args)), nil
+	}, nil
+}
+
+// Task.project, all projects are loaded once
+func resolveTaskProject(p graphql.ResolveParams) (interface{}, error) {
+	load := stateFrom(p.Context).projects.load(p.Source.(model.Task).ProjectID)
+
+	return func() (interface{}, error) {
+		project, ok := load()
+		if !ok {
+			return nil, errors.New("project not found")
+		}
+		return project, nil
+	}, nil
+}
+
+// Applies the filters done, dueBefore, dueAfter and tag
+func filterTasks(tasks []model.Task, args map[string]interface{}) []model.Task {
+	filtered := []model.Task{}
+	for _, task := range tasks {
+		if done, ok := args["done"].(bool); ok && task.Done != done {
+			continue
+		}
+		if dueBefore, ok := args["dueBefore"].(time.Time); ok && (task.Deadline == nil || !task.Deadline.Before(dueBefore)) {
+			continue
+		}
+		if dueAfter, ok := args["dueAfter"].(time.Time); ok && (task.Deadline == nil || !task.Deadline.After(dueAfter)) {
+			continue
+		}
+		if tag, ok := args["tag"].(string); ok && !hasTag(task, tag) {
+			continue
+		}
+		filtered = append(filtered, task)
+	}
+	return filtered
+}
+
+func hasTag(task model.Task, tag string) bool {
+	for _, t := range task.TagList() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
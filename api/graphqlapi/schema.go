@@ -0,0 +1,231 @@
+package graphqlapi
+
+import (
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+var projectType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Project",
+	Fields: graphql.Fields{
+		"id":        projectField(graphql.NewNonNull(graphql.ID), func(p model.Project) interface{} { return p.ID }),
+		"name":      projectField(graphql.NewNonNull(graphql.String), func(p model.Project) interface{} { return p.Name }),
+		"archived":  projectField(graphql.NewNonNull(graphql.Boolean), func(p model.Project) interface{} { return p.Archived }),
+		"createdAt": projectField(graphql.NewNonNull(graphql.DateTime), func(p model.Project) interface{} { return p.CreatedAt }),
+		"updatedAt": projectField(graphql.NewNonNull(graphql.DateTime), func(p model.Project) interface{} { return p.UpdatedAt }),
+	},
+})
+
+var taskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Task",
+	Fields: graphql.Fields{
+		"id":          taskField(graphql.NewNonNull(graphql.ID), func(t model.Task) interface{} { return t.ID }),
+		"name":        taskField(graphql.NewNonNull(graphql.String), func(t model.Task) interface{} { return t.Name }),
+		"priority":    taskField(graphql.NewNonNull(graphql.String), func(t model.Task) interface{} { return t.Priority }),
+		"deadline":    taskField(graphql.DateTime, func(t model.Task) interface{} { return optionalTime(t.Deadline) }),
+		"done":        taskField(graphql.NewNonNull(graphql.Boolean), func(t model.Task) interface{} { return t.Done }),
+		"completedAt": taskField(graphql.DateTime, func(t model.Task) interface{} { return optionalTime(t.CompletedAt) }),
+		"notes":       taskField(graphql.NewNonNull(graphql.String), func(t model.Task) interface{} { return t.Notes }),
+		"recurrence":  taskField(graphql.NewNonNull(graphql.String), func(t model.Task) interface{} { return t.Recurrence }),
+		"tags":        taskField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), func(t model.Task) interface{} { return t.TagList() }),
+		"rank":        taskField(graphql.NewNonNull(graphql.String), func(t model.Task) interface{} { return t.Rank }),
+		"createdAt":   taskField(graphql.NewNonNull(graphql.DateTime), func(t model.Task) interface{} { return t.CreatedAt }),
+		"updatedAt":   taskField(graphql.NewNonNull(graphql.DateTime), func(t model.Task) interface{} { return t.UpdatedAt }),
+	},
+})
+
+var taskInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"priority":   &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+		"deadline":   &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"notes":      &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+		"recurrence": &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: "", Description: "RRULE of recurring tasks, e.g. FREQ=WEEKLY"},
+	},
+})
+
+// Filters of the tasks of a project
+var taskFilterArgs = graphql.FieldConfigArgument{
+	"done":      &graphql.ArgumentConfig{Type: graphql.Boolean},
+	"dueBefore": &graphql.ArgumentConfig{Type: graphql.DateTime},
+	"dueAfter":  &graphql.ArgumentConfig{Type: graphql.DateTime},
+	"tag":       &graphql.ArgumentConfig{Type: graphql.String},
+}
+
+var schema graphql.Schema
+
+func init() {
+	// Fields referencing the other type are added after both types exist
+	projectType.AddFieldConfig("tasks", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType))),
+		Args: withArgs(taskFilterArgs, graphql.FieldConfigArgument{
+			"first": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Maximum number of tasks"},
+		}),
+		Resolve: resolveProjectTasks,
+	})
+	projectType.AddFieldConfig("taskCount", &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.Int),
+		Args:    taskFilterArgs,
+		Resolve: resolveProjectTaskCount,
+	})
+	taskType.AddFieldConfig("project", &graphql.Field{
+		Type:    graphql.NewNonNull(projectType),
+		Resolve: resolveTaskProject,
+	})
+
+	var err error
+	schema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"projects": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(projectType))),
+			Args: graphql.FieldConfigArgument{
+				"archived": &graphql.ArgumentConfig{Type: graphql.Boolean},
+				"first":    &graphql.ArgumentConfig{Type: graphql.Int, Description: "Maximum number of projects"},
+			},
+			Resolve: resolveProjects,
+		},
+		"project": &graphql.Field{
+			Type:    projectType,
+			Args:    graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+			Resolve: resolveProject,
+		},
+		"task": &graphql.Field{
+			Type: taskType,
+			Args: graphql.FieldConfigArgument{
+				"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"name":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: resolveTask,
+		},
+	},
+})
+
+var projectNameArgs = graphql.FieldConfigArgument{
+	"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+}
+
+var taskNameArgs = graphql.FieldConfigArgument{
+	"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	"name":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+}
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createProject": &graphql.Field{
+			Type:    graphql.NewNonNull(projectType),
+			Args:    projectNameArgs,
+			Resolve: mutation(createProject),
+		},
+		"renameProject": &graphql.Field{
+			Type: graphql.NewNonNull(projectType),
+			Args: withArgs(projectNameArgs, graphql.FieldConfigArgument{
+				"newName": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			}),
+			Resolve: mutation(renameProject),
+		},
+		"archiveProject": &graphql.Field{
+			Type: graphql.NewNonNull(projectType),
+			Args: withArgs(projectNameArgs, graphql.FieldConfigArgument{
+				"archived": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true, Description: "false restores the project"},
+			}),
+			Resolve: mutation(archiveProject),
+		},
+		"deleteProject": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.Boolean),
+			Args:    projectNameArgs,
+			Resolve: mutation(deleteProject),
+		},
+		"createTask": &graphql.Field{
+			Type: graphql.NewNonNull(taskType),
+			Args: graphql.FieldConfigArgument{
+				"project": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"input":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+			},
+			Resolve: mutation(createTask),
+		},
+		"updateTask": &graphql.Field{
+			Type: graphql.NewNonNull(taskType),
+			Args: withArgs(taskNameArgs, graphql.FieldConfigArgument{
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+			}),
+			Resolve: mutation(updateTask),
+		},
+		"completeTask": &graphql.Field{
+			Type: graphql.NewNonNull(taskType),
+			Args: withArgs(taskNameArgs, graphql.FieldConfigArgument{
+				"done":  &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true, Description: "false reopens the task"},
+				"force": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false, Description: "Completes tasks with open blockers"},
+			}),
+			Resolve: mutation(completeTask),
+		},
+		"deleteTask": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.Boolean),
+			Args:    taskNameArgs,
+			Resolve: mutation(deleteTask),
+		},
+	},
+})
+
+// A field of Project resolved by fn
+func projectField(fieldType graphql.Output, fn func(model.Project) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(model.Project)), nil
+		},
+	}
+}
+
+// A field of Task resolved by fn
+func taskField(fieldType graphql.Output, fn func(model.Task) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(model.Task)), nil
+		},
+	}
+}
+
+// Merges argument definitions
+func withArgs(args ...graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	merged := graphql.FieldConfigArgument{}
+	for _, arg := range args {
+		for name, config := range arg {
+			merged[name] = config
+		}
+	}
+	return merged
+}
+
+// Missing times are null instead of a nil pointer
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// Checks the name and the RRULE of a TaskInput
+func validateTaskInput(input map[string]interface{}) error {
+	if input["name"] == "" {
+		return errors.New("name is required")
+	}
+	recurrence, _ := input["recurrence"].(string)
+	return ical.ValidateRecurrence(recurrence)
+}
@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/events"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event types of the events package
+var eventTypes = map[events.EventType]TaskEvent_Type{
+	events.TaskCreated: TaskEvent_TYPE_CREATED,
+	events.TaskUpdated: TaskEvent_TYPE_UPDATED,
+	events.TaskDeleted: TaskEvent_TYPE_DELETED,
+}
+
+func toProject(project model.Project) *Project {
+	return &Project{
+		Id:        uint32(project.ID),
+		Name:      project.Name,
+		Archived:  project.Archived,
+		CreatedAt: timestamppb.New(project.CreatedAt),
+		UpdatedAt: timestamppb.New(project.UpdatedAt),
+	}
+}
+
+func toTask(task model.Task) *Task {
+	message := &Task{
+		Id:          uint32(task.ID),
+		ProjectId:   uint32(task.ProjectID),
+		Name:        task.Name,
+		Priority:    task.Priority,
+		Deadline:    toTimestamp(task.Deadline),
+		Done:        task.Done,
+		CompletedAt: toTimestamp(task.CompletedAt),
+		Notes:       task.Notes,
+		Rank:        task.Rank,
+		Recurrence:  task.Recurrence,
+		Tags:        task.TagList(),
+		CreatedAt:   timestamppb.New(task.CreatedAt),
+		UpdatedAt:   timestamppb.New(task.UpdatedAt),
+	}
+	if task.ParentID != nil {
+		message.ParentId = uint32(*task.ParentID)
+	}
+	return message
+}
+
+func toTaskEvent(event events.TaskEvent) *TaskEvent {
+	return &TaskEvent{
+		Type:    eventTypes[event.Type],
+		Project: event.Project,
+		Task:    toTask(event.Task),
+	}
+}
+
+// Converts optional times, nil stays nil
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// Copies the properties of a TaskInput into a task
+func (i *TaskInput) apply(task *model.Task) {
+	task.Name = i.GetName()
+	task.Priority = i.GetPriority()
+	task.Notes = i.GetNotes()
+	task.Recurrence = i.GetRecurrence()
+
+	task.Deadline = nil
+	if i.GetDeadline() != nil {
+		deadline := i.GetDeadline().AsTime()
+		task.Deadline = &deadline
+	}
+}
@@ -0,0 +1,6 @@
+// Package grpcapi serves the project and task operations of the REST API
+// as gRPC service todo.v1.TodoService, defined in todo.proto.
+package grpcapi
+
+// Requires buf, protoc-gen-go v1.27.1 and protoc-gen-go-grpc v1.1.0
+//go:generate buf generate
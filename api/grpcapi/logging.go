@@ -0,0 +1,55 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata carrying the ID of a call, like the X-Request-ID header
+const requestIDKey = "x-request-id"
+
+// Interceptor that assigns every call an ID. The ID is taken from the
+// x-request-id metadata or generated and sent back as header metadata.
+// The context of the call carries a logger adding the ID to every line
+func RequestIDUnary(logger *logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, id := withRequestID(ctx, logger)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+		return handler(ctx, request)
+	}
+}
+
+// Like RequestIDUnary for streaming calls
+func RequestIDStream(logger *logging.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := withRequestID(stream.Context(), logger)
+		stream.SetHeader(metadata.Pairs(requestIDKey, id))
+		return handler(server, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// Returns the context with a logger adding the ID of the call
+func withRequestID(ctx context.Context, logger *logging.Logger) (context.Context, string) {
+	sent := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			sent = values[0]
+		}
+	}
+
+	id := logging.RequestID(sent)
+	return logging.NewContext(ctx, logger.With("request_id", id)), id
+}
+
+// ServerStream with a different context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
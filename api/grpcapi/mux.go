@@ -0,0 +1,22 @@
+package grpcapi
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// Serves gRPC requests and other HTTP requests on the same listener.
+// gRPC clients have to connect with HTTP/2 without TLS (h2c)
+func Multiplex(grpcServer *grpc.Server, h http.Handler) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
@@ -0,0 +1,83 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Interceptor limiting the calls of every client with the same groups and
+// key as the REST API. Calls are matched as the POST requests gRPC sends,
+// to their full method name like "/todo.v1.TodoService/CreateProject"
+func RateLimitUnary(groups *ratelimit.Groups, key func(r *http.Request) string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allow(ctx, groups, key, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// Like RateLimitUnary for streaming calls, which count once when they start
+func RateLimitStream(groups *ratelimit.Groups, key func(r *http.Request) string) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allow(stream.Context(), groups, key, info.FullMethod, stream.SetHeader); err != nil {
+			return err
+		}
+		return handler(server, stream)
+	}
+}
+
+// Counts the call and sends the limit as header metadata.
+// Rejected calls fail with codes.ResourceExhausted
+func allow(ctx context.Context, groups *ratelimit.Groups, key func(r *http.Request) string, method string, setHeader func(metadata.MD) error) error {
+	result, ok := groups.Allow(http.MethodPost, method, key(callRequest(ctx, method)), time.Now())
+	if !ok {
+		return nil
+	}
+
+	md := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(result.Limit),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		md.Set("retry-after", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	}
+	setHeader(md)
+
+	if !result.Allowed {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return nil
+}
+
+// Returns the HTTP request a call was sent with, made of its metadata
+// and peer, so the key functions of the REST API work for gRPC as well
+func callRequest(ctx context.Context, method string) *http.Request {
+	r := &http.Request{Method: http.MethodPost, URL: &url.URL{Path: method}, Header: http.Header{}}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for name, values := range md {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+	return r.WithContext(ctx)
+}
+
+// Rounds up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
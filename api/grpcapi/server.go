@@ -0,0 +1,279 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/events"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Implements TodoServiceServer on a TodoStore
+type Server struct {
+	UnimplementedTodoServiceServer
+
+	store  store.TodoStore
+	broker *events.Broker
+}
+
+// Creates a Server. The TodoStore should be an events.Store publishing
+// to broker, so WatchTasks streams the changes of all APIs
+func NewServer(t store.TodoStore, broker *events.Broker) *Server {
+	return &Server{store: t, broker: broker}
+}
+
+func (s *Server) ListProjects(ctx context.Context, request *ListProjectsRequest) (*ListProjectsResponse, error) {
+	t := s.store.WithContext(ctx)
+	response := &ListProjectsResponse{Projects: []*Project{}}
+	for _, project := range t.GetAllProjects() {
+		response.Projects = append(response.Projects, toProject(project))
+	}
+	return response, nil
+}
+
+func (s *Server) GetProject(ctx context.Context, request *GetProjectRequest) (*Project, error) {
+	t := s.store.WithContext(ctx)
+	project, err := findProject(t, request.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return toProject(project), nil
+}
+
+func (s *Server) CreateProject(ctx context.Context, request *CreateProjectRequest) (*Project, error) {
+	t := s.store.WithContext(ctx)
+	if request.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if t.GetProject(request.GetName()).Name != "" {
+		return nil, status.Error(codes.AlreadyExists, "project already existing")
+	}
+
+	if err := t.PostProject(request.GetName()); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return toProject(t.GetProject(request.GetName())), nil
+}
+
+func (s *Server) RenameProject(ctx context.Context, request *RenameProjectRequest) (*Project, error) {
+	t := s.store.WithContext(ctx)
+	if request.GetNewName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "new name is required")
+	}
+	project, err := findProject(t, request.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if request.GetNewName() != project.Name && t.GetProject(request.GetNewName()).Name != "" {
+		return nil, status.Error(codes.AlreadyExists, "project already existing")
+	}
+
+	project.Name = request.GetNewName()
+	if err := t.UpdateProject(project); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return toProject(t.GetProject(project.Name)), nil
+}
+
+func (s *Server) DeleteProject(ctx context.Context, request *DeleteProjectRequest) (*emptypb.Empty, error) {
+	t := s.store.WithContext(ctx)
+	if _, err := findProject(t, request.GetName()); err != nil {
+		return nil, err
+	}
+
+	if err := t.DeleteProject(request.GetName()); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) ArchiveProject(ctx context.Context, request *ArchiveProjectRequest) (*Project, error) {
+	t := s.store.WithContext(ctx)
+	project, err := findProject(t, request.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	if request.GetArchived() {
+		project.ArchiveProject()
+	} else {
+		project.UnArchiveProject()
+	}
+	if err := t.UpdateProject(project); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return toProject(t.GetProject(project.Name)), nil
+}
+
+func (s *Server) ListTasks(ctx context.Context, request *ListTasksRequest) (*ListTasksResponse, error) {
+	t := s.store.WithContext(ctx)
+	project, err := findProject(t, request.GetProject())
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListTasksResponse{Tasks: []*Task{}}
+	for _, task := range t.GetAllProjectTasks(project) {
+		response.Tasks = append(response.Tasks, toTask(task))
+	}
+	return response, nil
+}
+
+func (s *Server) GetTask(ctx context.Context, request *GetTaskRequest) (*Task, error) {
+	t := s.store.WithContext(ctx)
+	task, err := findTask(t, request.GetProject(), request.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+func (s *Server) CreateTask(ctx context.Context, request *CreateTaskRequest) (*Task, error) {
+	t := s.store.WithContext(ctx)
+	project, err := findProject(t, request.GetProject())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTaskInput(request.GetTask()); err != nil {
+		return nil, err
+	}
+
+	task := model.Task{ProjectID: project.ID}
+	request.GetTask().apply(&task)
+	if err := t.PostTask(task); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return toTask(t.GetTask(project.Name, task.Name)), nil
+}
+
+func (s *Server) UpdateTask(ctx context.Context, request *UpdateTaskRequest) (*Task, error) {
+	t := s.store.WithContext(ctx)
+	task, err := findTask(t, request.GetProject(), request.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTaskInput(request.GetTask()); err != nil {
+		return nil, err
+	}
+
+	request.GetTask().apply(&task)
+	if err := t.UpdateTask(task); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return toTask(t.GetTask(request.GetProject(), task.Name)), nil
+}
+
+func (s *Server) DeleteTask(ctx context.Context, request *DeleteTaskRequest) (*emptypb.Empty, error) {
+	t := s.store.WithContext(ctx)
+	task, err := findTask(t, request.GetProject(), request.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.DeleteTask(task); err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) CompleteTask(ctx context.Context, request *CompleteTaskRequest) (*Task, error) {
+	t := s.store.WithContext(ctx)
+	task, err := findTask(t, request.GetProject(), request.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	// Move the task into the first closed or open state of the workflow
+	task, err = workflow.Complete(t, t.GetProject(request.GetProject()), task, request.GetDone(), request.GetForce())
+	if errors.Is(err, workflow.ErrWIPLimit) || errors.Is(err, dependency.ErrBlocked) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	return toTask(task), nil
+}
+
+func (s *Server) WatchTasks(request *WatchTasksRequest, stream TodoService_WatchTasksServer) error {
+	if s.broker == nil {
+		return status.Error(codes.Unimplemented, "task events are not enabled")
+	}
+	if request.GetProject() != "" {
+		if _, err := findProject(s.store.WithContext(stream.Context()), request.GetProject()); err != nil {
+			return err
+		}
+	}
+
+	taskEvents, cancel := s.broker.Subscribe()
+	defer cancel()
+
+	// Tells the client that the subscription is active
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-taskEvents:
+			if request.GetProject() != "" && event.Project != request.GetProject() {
+				continue
+			}
+			if err := stream.Send(toTaskEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Gets a project or returns a NotFound error
+func findProject(t store.TodoStore, name string) (model.Project, error) {
+	project := t.GetProject(name)
+	if project.Name == "" {
+		return project, status.Error(codes.NotFound, "project not found")
+	}
+	return project, nil
+}
+
+// Gets a task or returns a NotFound error
+func findTask(t store.TodoStore, projectName, taskName string) (model.Task, error) {
+	if _, err := findProject(t, projectName); err != nil {
+		return model.Task{}, err
+	}
+
+	task := t.GetTask(projectName, taskName)
+	if task.Name == "" {
+		return task, status.Error(codes.NotFound, "task not found")
+	}
+	return task, nil
+}
+
+// Logs an unexpected error with the request ID and returns
+// codes.Internal without the details of the error
+func internalError(ctx context.Context, err error) error {
+	method, _ := grpc.Method(ctx)
+	logging.FromContext(ctx).Error("internal error", "method", method, "error", err)
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// Checks the name and the RRULE of a task
+func validateTaskInput(input *TaskInput) error {
+	if input.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "name is required")
+	}
+	if err := ical.ValidateRecurrence(input.GetRecurrence()); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/backup"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Middleware that only lets requests with "Authorization: Bearer <token>" pass
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": "invalid token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Handler for GET /admin/backup
+func BackupHandler(t store.TodoStore, c *gin.Context) {
+	filename := "backup-" + time.Now().UTC().Format("2006-01-02") + ".json"
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, backup.Create(t))
+}
+
+// Handler for POST /admin/restore. Backups may have up to maxSize
+// bytes, zero disables the limit
+func RestoreHandler(t store.TodoStore, maxSize int64, c *gin.Context) {
+	mode := c.DefaultQuery("mode", backup.ModeMerge)
+	if mode != backup.ModeMerge && mode != backup.ModeReplace {
+		sendJSONResponse(c, http.StatusBadRequest, "mode must be merge or replace")
+		return
+	}
+
+	upload, err := openUpload(c, maxSize)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer upload.Close()
+
+	dump, err := backup.Read(upload)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := backup.Restore(t, dump, mode)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
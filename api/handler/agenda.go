@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/agenda"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Days of the upcoming agenda without days parameter
+const defaultAgendaDays = 7
+
+// Handler for GET /agenda/today, /agenda/overdue and /agenda/upcoming
+func AgendaHandler(t store.TodoStore, view string, c *gin.Context) {
+	location := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if location, err = time.LoadLocation(tz); err != nil {
+			sendJSONResponse(c, http.StatusBadRequest, "unknown time zone "+tz)
+			return
+		}
+	}
+
+	days := defaultAgendaDays
+	if value := c.Query("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil {
+			sendJSONResponse(c, http.StatusBadRequest, "days must be a number")
+			return
+		}
+	}
+
+	result, err := agenda.New(t, view, days, time.Now(), location)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The agenda changes with the time, so only the ETag is used
+	sendCacheableJSON(c, result, time.Time{})
+}
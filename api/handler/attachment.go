@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/attachment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Allowance for the multipart encoding around uploaded files
+const multipartOverhead = 1 << 20
+
+// Handler for GET /projects/:projectName/tasks/:taskName/attachments
+func GetAttachmentsHandler(t store.TodoStore, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	// Deleted attachments leave no timestamp, so only the ETag is used
+	sendCacheableJSON(c, t.GetAttachments(task.ID), time.Time{})
+}
+
+// Handler for POST /projects/:projectName/tasks/:taskName/attachments.
+// The file is the form field "file" of a multipart request,
+// the optional form field "sha256" is its hex encoded checksum
+func PostAttachmentHandler(t store.TodoStore, blobs blob.Store, limits attachment.Limits, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	if c.Request.ContentLength > limits.MaxSize+multipartOverhead {
+		sendJSONResponse(c, http.StatusRequestEntityTooLarge, attachment.ErrTooLarge.Error())
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if header.Size > limits.MaxSize {
+		sendJSONResponse(c, http.StatusRequestEntityTooLarge, attachment.ErrTooLarge.Error())
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	checksum := c.PostForm("sha256")
+	if _, err := hex.DecodeString(checksum); err != nil || (checksum != "" && len(checksum) != 64) {
+		sendJSONResponse(c, http.StatusBadRequest, "sha256 must be a hex encoded SHA-256 checksum")
+		return
+	}
+
+	created, err := attachment.Add(t, blobs, limits, task, attachment.Upload{Name: header.Filename, Content: file, SHA256: checksum})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, created)
+	case errors.Is(err, attachment.ErrName), errors.Is(err, attachment.ErrChecksum):
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, attachment.ErrTooLarge):
+		sendJSONResponse(c, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, attachment.ErrContentType):
+		sendJSONResponse(c, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		sendInternalError(c, err)
+	}
+}
+
+// Handler for GET /projects/:projectName/tasks/:taskName/attachments/:id.
+// Sends the content with its checksum as ETag and Digest header
+func GetAttachmentHandler(t store.TodoStore, blobs blob.Store, c *gin.Context) {
+	existing := checkIfAttachmentExistsOr404(t, c)
+	if existing.ID == 0 {
+		return
+	}
+
+	if setCacheHeaders(c, "\""+existing.SHA256+"\"", existing.CreatedAt) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	content, err := attachment.Open(blobs, existing)
+	if errors.Is(err, blob.ErrNotFound) {
+		sendJSONResponse(c, http.StatusNotFound, "content of the attachment not found")
+		return
+	}
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	defer content.Close()
+
+	sum, _ := hex.DecodeString(existing.SHA256)
+	c.DataFromReader(http.StatusOK, existing.Size, existing.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": existing.Name}),
+		"Digest":                 "sha-256=" + base64.StdEncoding.EncodeToString(sum),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// Handler for DELETE /projects/:projectName/tasks/:taskName/attachments/:id
+func DeleteAttachmentHandler(t store.TodoStore, blobs blob.Store, c *gin.Context) {
+	existing := checkIfAttachmentExistsOr404(t, c)
+	if existing.ID == 0 {
+		return
+	}
+
+	if err := attachment.Delete(t, blobs, existing); err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "attachment deleted")
+}
+
+// Checks if the task of the route has the attachment with the id of the route.
+// If not the context is aborted and a response is send
+func checkIfAttachmentExistsOr404(t store.TodoStore, c *gin.Context) model.Attachment {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return model.Attachment{}
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	existing := t.GetAttachment(uint(id))
+	if existing.ID == 0 || existing.TaskID != task.ID {
+		sendJSONResponse(c, http.StatusNotFound, "attachment not found")
+		return model.Attachment{}
+	}
+	return existing
+}
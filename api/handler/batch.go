@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+)
+
+// Modes of batch requests
+const (
+	// All operations succeed or none
+	BatchModeAtomic = "atomic"
+	// Every operation is executed on its own
+	BatchModeBestEffort = "best_effort"
+)
+
+// Upper limit for operations of a batch
+const maxBatchOperations = 100
+
+// Operations of batches
+var batchOperations = map[string]bool{
+	"create_project":    true,
+	"archive_project":   true,
+	"unarchive_project": true,
+	"create_task":       true,
+	"update_task":       true,
+	"complete_task":     true,
+	"reopen_task":       true,
+	"delete_task":       true,
+}
+
+// Rolls back atomic batches
+var errBatchFailed = errors.New("batch failed")
+
+// For json validation of POST /batch
+type Batch struct {
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations" binding:"required"`
+}
+
+// An operation of a batch. Op is one of create_project, archive_project,
+// unarchive_project, create_task, update_task, complete_task, reopen_task
+// and delete_task
+type BatchOperation struct {
+	Op string `json:"op"`
+	// Project of task operations
+	Project string `json:"project"`
+	// Project of project operations, task of task operations
+	Name string `json:"name"`
+	// Task of create_task and update_task
+	Task *Task `json:"task"`
+	// Completes tasks with open blockers in complete_task
+	Force bool `json:"force"`
+}
+
+// Result of an operation, Status is the HTTP status
+// the operation would have as single request
+type BatchResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+type BatchResponse struct {
+	Mode string `json:"mode"`
+	// false if an atomic batch was rolled back
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// Handler for POST /batch
+func BatchHandler(t store.TodoStore, c *gin.Context) {
+	var batch Batch
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if batch.Mode == "" {
+		batch.Mode = BatchModeAtomic
+	}
+	if batch.Mode != BatchModeAtomic && batch.Mode != BatchModeBestEffort {
+		sendJSONResponse(c, http.StatusBadRequest, "mode must be atomic or best_effort")
+		return
+	}
+	if len(batch.Operations) > maxBatchOperations {
+		sendJSONResponse(c, http.StatusBadRequest, fmt.Sprintf("a batch can have at most %d operations", maxBatchOperations))
+		return
+	}
+
+	response := BatchResponse{Mode: batch.Mode, Committed: true, Results: []BatchResult{}}
+	log := logging.FromContext(c.Request.Context())
+
+	if batch.Mode == BatchModeBestEffort {
+		for i, operation := range batch.Operations {
+			response.Results = append(response.Results, runBatchOperation(t, log, i, operation))
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Atomic batches stop at the first failed operation
+	err := t.Transaction(func(tx store.TodoStore) error {
+		for i, operation := range batch.Operations {
+			result := runBatchOperation(tx, log, i, operation)
+			response.Results = append(response.Results, result)
+			if result.Status >= http.StatusBadRequest {
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+
+	if err == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	response.Committed = false
+	if err != errBatchFailed {
+		sendInternalError(c, err)
+		return
+	}
+	for i := len(response.Results); i < len(batch.Operations); i++ {
+		response.Results = append(response.Results, BatchResult{
+			Index:   i,
+			Op:      batch.Operations[i].Op,
+			Status:  http.StatusFailedDependency,
+			Message: "not executed",
+		})
+	}
+	c.JSON(http.StatusUnprocessableEntity, response)
+}
+
+// Executes an operation with the checks of the single requests.
+// Unexpected errors are logged and not sent to the client
+func runBatchOperation(t store.TodoStore, log *logging.Logger, index int, operation BatchOperation) BatchResult {
+	status, message := batchOperationStatus(t, operation)
+	if status >= http.StatusInternalServerError {
+		log.Error("batch operation failed", "index", index, "op", operation.Op, "error", message)
+		message = "internal server error"
+	}
+	return BatchResult{Index: index, Op: operation.Op, Status: status, Message: message}
+}
+
+func batchOperationStatus(t store.TodoStore, operation BatchOperation) (int, string) {
+	if !batchOperations[operation.Op] {
+		return http.StatusBadRequest, "unknown operation " + operation.Op
+	}
+
+	switch operation.Op {
+	case "create_project":
+		if operation.Name == "" {
+			return http.StatusBadRequest, "name is required"
+		}
+		if t.GetProject(operation.Name).Name != "" {
+			return http.StatusBadRequest, "project already existing"
+		}
+		if err := t.PostProject(operation.Name); err != nil {
+			return http.StatusInternalServerError, err.Error()
+		}
+		return http.StatusCreated, "project created"
+
+	case "archive_project", "unarchive_project":
+		project := t.GetProject(operation.Name)
+		if project.Name == "" {
+			return http.StatusNotFound, "project not found"
+		}
+		message := "project archived"
+		if operation.Op == "archive_project" {
+			project.ArchiveProject()
+		} else {
+			project.UnArchiveProject()
+			message = "project unarchived"
+		}
+		if err := t.UpdateProject(project); err != nil {
+			return http.StatusInternalServerError, err.Error()
+		}
+		return http.StatusOK, message
+
+	case "create_task":
+		project := t.GetProject(operation.Project)
+		if project.Name == "" {
+			return http.StatusNotFound, "project not found"
+		}
+		task := model.Task{ProjectID: project.ID}
+		if status, message := applyBatchTask(operation, &task); status != 0 {
+			return status, message
+		}
+		if err := t.PostTask(task); err != nil {
+			return http.StatusInternalServerError, err.Error()
+		}
+		return http.StatusCreated, "task created"
+	}
+
+	// Operations on existing tasks
+	task := t.GetTask(operation.Project, operation.Name)
+	if task.Name == "" {
+		return http.StatusNotFound, "task not found"
+	}
+
+	var err error
+	var message string
+	switch operation.Op {
+	case "update_task":
+		if status, message := applyBatchTask(operation, &task); status != 0 {
+			return status, message
+		}
+		err = t.UpdateTask(task)
+		message = "task updated"
+	case "complete_task":
+		_, err = workflow.Complete(t, t.GetProject(operation.Project), task, true, operation.Force)
+		message = "task completed"
+	case "reopen_task":
+		_, err = workflow.Complete(t, t.GetProject(operation.Project), task, false, false)
+		message = "task undone"
+	case "delete_task":
+		err = t.DeleteTask(task)
+		message = "task deleted"
+	}
+
+	if errors.Is(err, workflow.ErrWIPLimit) || errors.Is(err, dependency.ErrBlocked) {
+		return http.StatusConflict, err.Error()
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err.Error()
+	}
+	return http.StatusOK, message
+}
+
+// Validates the task of an operation like the body of a task request
+// and copies it into task. Returns status 0 on success
+func applyBatchTask(operation BatchOperation, task *model.Task) (int, string) {
+	if operation.Task == nil {
+		return http.StatusBadRequest, "task is required"
+	}
+	if err := binding.Validator.ValidateStruct(operation.Task); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if err := operation.Task.applyTo(task); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	return 0, ""
+}
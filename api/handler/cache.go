@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context key under which the cache policy of a route is stored
+const cachePolicyKey = "cachePolicy"
+
+// CachePolicy describes the caching behaviour of a GET route.
+// It controls the Cache-Control header and whether weak or
+// strong ETags are sent
+type CachePolicy struct {
+	MaxAge         time.Duration
+	Private        bool
+	NoCache        bool
+	NoStore        bool
+	MustRevalidate bool
+	WeakETag       bool
+}
+
+// Used for every GET route without its own policy.
+// Clients may keep responses but have to revalidate them,
+// which is cheap thanks to ETag and Last-Modified
+var DefaultCachePolicy = CachePolicy{NoCache: true}
+
+// Returns the value for the Cache-Control header
+func (p CachePolicy) HeaderValue() string {
+	directives := []string{}
+
+	if p.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if p.Private {
+		directives = append(directives, "private")
+	} else {
+		directives = append(directives, "public")
+	}
+	directives = append(directives, fmt.Sprintf("max-age=%d", int(p.MaxAge.Seconds())))
+	if p.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// Middleware that stores the cache policy of a route in the context.
+// It is read by sendCacheableJSON
+func CacheControl(policy CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(cachePolicyKey, policy)
+		c.Next()
+	}
+}
+
+// Sends obj as JSON with ETag, Last-Modified and Cache-Control headers.
+// Answers with 304 Not Modified if the request preconditions match.
+// lastModified may be zero if it is unknown
+func sendCacheableJSON(c *gin.Context, obj interface{}, lastModified time.Time) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+
+	sendCacheable(c, "application/json; charset=utf-8", body, lastModified)
+}
+
+// Like sendCacheableJSON, but for an already rendered body
+func sendCacheable(c *gin.Context, contentType string, body []byte, lastModified time.Time) {
+	if setCacheHeaders(c, makeETag(body, cachePolicy(c).WeakETag), lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// Sets the ETag, Last-Modified and Cache-Control headers of a response.
+// Returns true if the request preconditions match and 304 Not Modified
+// can be sent instead of the body
+func setCacheHeaders(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cachePolicy(c).HeaderValue())
+
+	// HTTP dates only have a precision of seconds
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	return isNotModified(c.Request, etag, lastModified)
+}
+
+// Returns the cache policy stored by CacheControl
+func cachePolicy(c *gin.Context) CachePolicy {
+	if value, ok := c.Get(cachePolicyKey); ok {
+		return value.(CachePolicy)
+	}
+	return DefaultCachePolicy
+}
+
+// Creates an ETag from the hash of the response body
+func makeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf("\"%x\"", sum[:16])
+
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// Evaluates If-None-Match and If-Modified-Since.
+// If-None-Match takes precedence as described in RFC 7232
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
+
+// Weak comparison of an If-None-Match header against an ETag
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Number of random bytes of a feed token
+const feedTokenLength = 32
+
+// Handler for POST /calendar/tokens and POST /projects/:projectName/calendar/tokens.
+// Tasks have no assignee, so there is no feed of the tasks of a user.
+// Every user creates their own token for the feed of all projects instead
+func PostFeedTokenHandler(t store.TodoStore, c *gin.Context) {
+	feedToken := model.FeedToken{}
+	feedURL := "/calendar.ics"
+
+	// Tokens created for a project only grant access to that project
+	if projectName := c.Param("projectName"); projectName != "" {
+		project := checkIfProjectExistsOr404(t, c, projectName)
+		if project.Name == "" {
+			return
+		}
+		feedToken.ProjectID = &project.ID
+		feedURL = "/projects/" + url.PathEscape(project.Name) + "/calendar.ics"
+	}
+
+	token, err := newFeedToken()
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	feedToken.Token = token
+
+	err = t.PostFeedToken(feedToken)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"url":   feedURL + "?token=" + token,
+	})
+}
+
+// Handler for DELETE /calendar/tokens/:token
+func DeleteFeedTokenHandler(t store.TodoStore, c *gin.Context) {
+	feedToken := t.GetFeedToken(c.Param("token"))
+	if feedToken.Token == "" {
+		sendJSONResponse(c, http.StatusNotFound, "feed token not found")
+		return
+	}
+
+	err := t.DeleteFeedToken(feedToken)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "feed token deleted")
+}
+
+// Handler for GET /calendar.ics and GET /projects/:projectName/calendar.ics
+func GetCalendarHandler(t store.TodoStore, c *gin.Context) {
+	token := c.Query("token")
+	feedToken := t.GetFeedToken(token)
+	if token == "" || feedToken.Token == "" {
+		sendJSONResponse(c, http.StatusForbidden, "invalid feed token")
+		return
+	}
+
+	component := ical.ComponentTodo
+	switch strings.ToLower(c.Query("component")) {
+	case "", "vtodo":
+	case "vevent":
+		component = ical.ComponentEvent
+	default:
+		sendJSONResponse(c, http.StatusBadRequest, "component must be vtodo or vevent")
+		return
+	}
+
+	// Collect the projects of the feed
+	projects := []model.Project{}
+	calendarName := "All projects"
+	if projectName := c.Param("projectName"); projectName != "" {
+		project := checkIfProjectExistsOr404(t, c, projectName)
+		if project.Name == "" {
+			return
+		}
+		if !feedToken.AllowsProject(project) {
+			sendJSONResponse(c, http.StatusForbidden, "invalid feed token")
+			return
+		}
+		projects = append(projects, project)
+		calendarName = project.Name
+	} else {
+		for _, project := range t.GetAllProjects() {
+			if !project.Archived && feedToken.AllowsProject(project) {
+				projects = append(projects, project)
+			}
+		}
+	}
+
+	tasks := []model.Task{}
+	for _, project := range projects {
+		tasks = append(tasks, t.GetAllProjectTasks(project)...)
+	}
+
+	var body bytes.Buffer
+	err := ical.Encode(&body, calendarName, component, tasks)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+
+	// Deleted tasks leave no trace in the newest UpdatedAt,
+	// feeds are only revalidated with their ETag
+	sendCacheable(c, "text/calendar; charset=utf-8", body.Bytes(), time.Time{})
+}
+
+// Handler for POST /projects/:projectName/import/ics
+func ImportCalendarHandler(t store.TodoStore, c *gin.Context) {
+	projectName := c.Param("projectName")
+
+	// Check if project exists
+	project := checkIfProjectExistsOr404(t, c, projectName)
+	if project.Name == "" {
+		return
+	}
+
+	upload, err := openUpload(c, maxUploadSize)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer upload.Close()
+
+	report, err := ical.Import(t, project, upload)
+	if errors.Is(err, ical.ErrInvalidDocument) {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Creates a random hex encoded feed token
+func newFeedToken() (string, error) {
+	token := make([]byte, feedTokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
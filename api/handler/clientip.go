@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Returns the IP address of the client sending the request. The address
+// of the peer is used unless the peer is one of the trusted proxies, then
+// the X-Forwarded-For header is followed from the right to the first
+// address that is not a trusted proxy. Headers of other peers are ignored,
+// as anyone can send them
+func ClientIP(r *http.Request, proxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(r.RemoteAddr)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && isTrustedProxy(ip, proxies); i-- {
+		next := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if next == nil {
+			break
+		}
+		ip = next
+	}
+	return ip.String()
+}
+
+func isTrustedProxy(ip net.IP, proxies []*net.IPNet) bool {
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
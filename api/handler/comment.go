@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/comment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// For json validation of POST and PUT /projects/:projectName/tasks/:taskName/comments
+type Comment struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// Handler for GET /projects/:projectName/tasks/:taskName/comments
+func GetCommentsHandler(t store.TodoStore, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	offset := 0
+	if value := c.Query("offset"); value != "" {
+		var err error
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			sendJSONResponse(c, http.StatusBadRequest, "offset must be a number of at least 0")
+			return
+		}
+	}
+
+	limit := comment.DefaultLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > comment.MaxLimit {
+			sendJSONResponse(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", comment.MaxLimit))
+			return
+		}
+	}
+
+	// Deleted comments leave no timestamp, so only the ETag is used
+	sendCacheableJSON(c, comment.List(t, task, offset, limit), time.Time{})
+}
+
+// Handler for POST /projects/:projectName/tasks/:taskName/comments
+func PostCommentHandler(t store.TodoStore, c *gin.Context) {
+	var json Comment
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	if sendCommentError(c, comment.Post(t, task, requestUser(c), json.Body)) {
+		return
+	}
+	sendJSONResponse(c, http.StatusCreated, "comment created")
+}
+
+// Handler for GET /projects/:projectName/tasks/:taskName/comments/:id
+func GetCommentHandler(t store.TodoStore, c *gin.Context) {
+	existing := checkIfCommentExistsOr404(t, c)
+	if existing.ID == 0 {
+		return
+	}
+
+	existing.BodyHTML = comment.Render(existing.Body)
+	sendCacheableJSON(c, existing, existing.UpdatedAt)
+}
+
+// Handler for PUT /projects/:projectName/tasks/:taskName/comments/:id
+func PutCommentHandler(t store.TodoStore, c *gin.Context) {
+	var json Comment
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing := checkIfCommentExistsOr404(t, c)
+	if existing.ID == 0 {
+		return
+	}
+
+	_, err := comment.Edit(t, existing, requestUser(c), json.Body)
+	if sendCommentError(c, err) {
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "comment updated")
+}
+
+// Handler for DELETE /projects/:projectName/tasks/:taskName/comments/:id
+func DeleteCommentHandler(t store.TodoStore, c *gin.Context) {
+	existing := checkIfCommentExistsOr404(t, c)
+	if existing.ID == 0 {
+		return
+	}
+
+	if sendCommentError(c, comment.Delete(t, existing, requestUser(c))) {
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "comment deleted")
+}
+
+// Checks if the task of the route has the comment with the id of the route.
+// If not the context is aborted and a response is send
+func checkIfCommentExistsOr404(t store.TodoStore, c *gin.Context) model.Comment {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return model.Comment{}
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	existing := t.GetComment(uint(id))
+	if existing.ID == 0 || existing.TaskID != task.ID {
+		sendJSONResponse(c, http.StatusNotFound, "comment not found")
+		return model.Comment{}
+	}
+	return existing
+}
+
+// Sends the response for errors of comment changes.
+// Returns false if there was no error
+func sendCommentError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, comment.ErrBody):
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, comment.ErrNotAuthor):
+		sendJSONResponse(c, http.StatusForbidden, err.Error())
+	default:
+		sendInternalError(c, err)
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/csvio"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Handler for GET /tasks.csv and GET /projects/:projectName/tasks.csv
+func ExportCSVHandler(t store.TodoStore, c *gin.Context) {
+	projects := t.GetAllProjects()
+	filename := "tasks.csv"
+
+	if projectName := c.Param("projectName"); projectName != "" {
+		// Check if project exists
+		project := checkIfProjectExistsOr404(t, c, projectName)
+		if project.Name == "" {
+			return
+		}
+		projects = []model.Project{project}
+		filename = project.Name + ".csv"
+	}
+
+	// The export is rendered completely, the ETag needs the whole body
+	var body bytes.Buffer
+	writer, err := csvio.NewWriter(&body)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	for _, project := range projects {
+		for _, task := range t.GetAllProjectTasks(project) {
+			if err := writer.Write(project, task); err != nil {
+				sendInternalError(c, err)
+				return
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		sendInternalError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	sendCacheable(c, "text/csv; charset=utf-8", body.Bytes(), time.Time{})
+}
+
+// Handler for POST /tasks.csv and POST /projects/:projectName/tasks.csv
+func ImportCSVHandler(t store.TodoStore, c *gin.Context) {
+	options := csvio.ImportOptions{}
+
+	if projectName := c.Param("projectName"); projectName != "" {
+		// Check if project exists
+		project := checkIfProjectExistsOr404(t, c, projectName)
+		if project.Name == "" {
+			return
+		}
+		options.Project = project.Name
+	}
+
+	upload, err := openUpload(c, maxUploadSize)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer upload.Close()
+
+	// Options can be query parameters or form fields
+	if dryRun := formOrQuery(c, "dry_run"); dryRun != "" {
+		options.DryRun, err = strconv.ParseBool(dryRun)
+		if err != nil {
+			sendJSONResponse(c, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+	if mapping := formOrQuery(c, "mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			sendJSONResponse(c, http.StatusBadRequest, "mapping must be a JSON object")
+			return
+		}
+	}
+
+	report, err := csvio.Import(t, options, upload)
+	switch {
+	case err == csvio.ErrInvalidRows:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	case err != nil:
+		sendInternalError(c, err)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// Returns a form field or query parameter
+func formOrQuery(c *gin.Context, key string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return c.Query(key)
+}
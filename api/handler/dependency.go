@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// For json validation of POST /projects/:projectName/tasks/:taskName/dependencies.
+// Project defaults to the project of the blocked task
+type Dependency struct {
+	Project string `json:"project"`
+	Name    string `json:"name" binding:"required"`
+}
+
+// Handler for GET /projects/:projectName/tasks/:taskName/dependencies
+func GetDependenciesHandler(t store.TodoStore, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	// Removed dependencies leave no trace in the newest UpdatedAt,
+	// lists are only revalidated with their ETag
+	sendCacheableJSON(c, dependency.Get(t, task), time.Time{})
+}
+
+// Handler for POST /projects/:projectName/tasks/:taskName/dependencies
+func PostDependencyHandler(t store.TodoStore, c *gin.Context) {
+	var json Dependency
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	projectName := c.Param("projectName")
+	task := checkIfTaskExistsOr404(t, c, projectName, c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	if json.Project == "" {
+		json.Project = projectName
+	}
+	blocker := t.GetTask(json.Project, json.Name)
+	if blocker.Name == "" {
+		sendJSONResponse(c, http.StatusNotFound, "blocking task not found")
+		return
+	}
+
+	_, err := dependency.Add(t, task, blocker)
+	switch {
+	case err == nil:
+		sendJSONResponse(c, http.StatusCreated, "dependency created")
+	case errors.Is(err, dependency.ErrSelf):
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, dependency.ErrExists), errors.Is(err, dependency.ErrCycle):
+		sendJSONResponse(c, http.StatusConflict, err.Error())
+	default:
+		sendInternalError(c, err)
+	}
+}
+
+// Handler for DELETE /projects/:projectName/tasks/:taskName/dependencies/:id
+func DeleteDependencyHandler(t store.TodoStore, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	// Dependencies can be deleted from both of their tasks
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	existing := t.GetDependency(uint(id))
+	if existing.ID == 0 || (existing.TaskID != task.ID && existing.BlockedByID != task.ID) {
+		sendJSONResponse(c, http.StatusNotFound, "dependency not found")
+		return
+	}
+
+	if err := dependency.Remove(t, existing); err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "dependency deleted")
+}
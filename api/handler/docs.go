@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Release of Redoc loaded by the docs page. It is pinned,
+// so new releases on the CDN do not change the page unnoticed
+const RedocVersion = "2.1.5"
+
+// Redoc page rendering the spec at /openapi.json
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>Go-Todo-REST-API-V2</title>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+<redoc spec-url="/openapi.json"></redoc>
+<script src="https://cdn.jsdelivr.net/npm/redoc@` + RedocVersion + `/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// Handler for GET /openapi.json
+func OpenAPIHandler(spec []byte, c *gin.Context) {
+	sendCacheable(c, "application/json; charset=utf-8", spec, time.Time{})
+}
+
+// Handler for GET /docs
+func DocsHandler(c *gin.Context) {
+	sendCacheable(c, "text/html; charset=utf-8", []byte(docsPage), time.Time{})
+}
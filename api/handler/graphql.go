@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/graphqlapi"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Handler for GET and POST /graphql.
+// GET requests send the query as parameters and can not run mutations
+func GraphQLHandler(t store.TodoStore, limits graphqlapi.Limits, c *gin.Context) {
+	request := graphqlapi.Request{}
+	if c.Request.Method == http.MethodGet {
+		request.Query = c.Query("query")
+		request.OperationName = c.Query("operationName")
+		request.QueryOnly = true
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				sendJSONResponse(c, http.StatusBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&request); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if request.Query == "" {
+		sendJSONResponse(c, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	// Errors during execution are part of the result
+	result, executed := graphqlapi.Execute(c.Request.Context(), t, request, limits)
+	if !executed {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+	if request.QueryOnly {
+		sendCacheableJSON(c, result, time.Time{})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
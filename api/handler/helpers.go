@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
 )
@@ -17,7 +23,53 @@ type Post struct {
 type Task struct {
 	Name     string `json:"name" binding:"required"`
 	Priority string `json:"priority" binding:"required"`
-	Deadline string `json:"deadline" binding:"required"`
+	// Tasks without deadline if empty
+	Deadline   string `json:"deadline"`
+	Notes      string `json:"notes"`
+	Recurrence string `json:"recurrence"`
+}
+
+// Layout of deadlines in task requests
+const deadlineLayout = "2006-01-02 15:04:05 +0000 UTC"
+
+// Copies the fields of a task request into a task.
+// Fails for invalid RRULEs and deadlines
+func (json Task) applyTo(task *model.Task) error {
+	// Validate the RRULE of recurring tasks
+	if err := ical.ValidateRecurrence(json.Recurrence); err != nil {
+		return err
+	}
+
+	// Parse the deadline string as a time.Time{}
+	var deadline *time.Time
+	if json.Deadline != "" {
+		parsed, err := time.Parse(deadlineLayout, json.Deadline)
+		if err != nil {
+			return err
+		}
+		deadline = &parsed
+	}
+
+	task.Name = json.Name
+	task.Priority = json.Priority
+	task.Notes = json.Notes
+	task.Recurrence = json.Recurrence
+	task.Deadline = deadline
+	return nil
+}
+
+// Header identifying the user of a request, e.g. for timers
+const userHeader = "X-User"
+
+// User of requests without user header
+const defaultUser = "anonymous"
+
+// Returns the user of the request
+func requestUser(c *gin.Context) string {
+	if user := strings.TrimSpace(c.GetHeader(userHeader)); user != "" {
+		return user
+	}
+	return defaultUser
 }
 
 // Checks if a project with that name exist.
@@ -54,3 +106,57 @@ func sendJSONResponse(c *gin.Context, statusCode int, message string) {
 		"message": message,
 	})
 }
+
+// Parses an optional query parameter as RFC 3339 timestamp or date
+func parseQueryTime(c *gin.Context, key string) (*time.Time, error) {
+	return parseTime(key, c.Query(key))
+}
+
+// Parses an optional boolean query parameter, false if it is missing
+func parseQueryBool(c *gin.Context, key string) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+	return parsed, nil
+}
+
+// Parses an optional RFC 3339 timestamp or date
+func parseTime(key, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be a RFC 3339 timestamp or a date", key)
+}
+
+// Upper limit for uploaded import files
+const maxUploadSize = 10 << 20
+
+// Returns the uploaded document of an import request, which may have
+// up to maxSize bytes or any size if maxSize is zero.
+// It is either the form file "file" of a multipart request or the request body
+func openUpload(c *gin.Context, maxSize int64) (io.ReadCloser, error) {
+	if maxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+	}
+
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return header.Open()
+	}
+	return c.Request.Body, nil
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+)
+
+// Header carrying the ID of a request
+const requestIDHeader = "X-Request-ID"
+
+// Middleware that assigns every request an ID. The ID is taken from the
+// X-Request-ID header or generated and sent back in the same header.
+// The request context carries a logger adding the ID to every line
+func RequestID(logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.RequestID(c.GetHeader(requestIDHeader))
+
+		c.Header(requestIDHeader, id)
+		ctx := logging.NewContext(c.Request.Context(), logger.With("request_id", id))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Middleware that logs every request once it is handled.
+// Server errors are logged at level error, clients with their IP address
+// as returned by ClientIP
+func AccessLog(proxies []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		level := logging.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = logging.LevelError
+		}
+		logging.FromContext(c.Request.Context()).Log(level, "request",
+			"method", c.Request.Method,
+			"path", path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"bytes", c.Writer.Size(),
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"client_ip", ClientIP(c.Request, proxies),
+		)
+	}
+}
+
+// Middleware that answers panics with 500 Internal Server Error
+// and logs them with their stack trace
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logging.FromContext(c.Request.Context()).Error("panic",
+					"error", recovered,
+					"stack", string(debug.Stack()),
+				)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"message": "internal server error",
+				})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// Logs an unexpected error with the request ID and sends
+// 500 Internal Server Error without the details of the error
+func sendInternalError(c *gin.Context, err error) {
+	logging.FromContext(c.Request.Context()).Error("internal error",
+		"route", c.FullPath(),
+		"error", err,
+	)
+	sendJSONResponse(c, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/markdown"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Handler for GET /projects/:projectName/tasks.md
+func ExportMarkdownHandler(t store.TodoStore, c *gin.Context) {
+	projectName := c.Param("projectName")
+
+	// Check if project exists
+	project := checkIfProjectExistsOr404(t, c, projectName)
+	if project.Name == "" {
+		return
+	}
+
+	var body bytes.Buffer
+	if err := markdown.Render(&body, project, t.GetAllProjectTasks(project)); err != nil {
+		sendInternalError(c, err)
+		return
+	}
+
+	// Deleted tasks leave no trace in the newest UpdatedAt,
+	// exports are only revalidated with their ETag
+	sendCacheable(c, "text/markdown; charset=utf-8", body.Bytes(), time.Time{})
+}
+
+// Handler for POST /projects/:projectName/tasks.md
+func ImportMarkdownHandler(t store.TodoStore, c *gin.Context) {
+	projectName := c.Param("projectName")
+
+	// Check if project exists
+	project := checkIfProjectExistsOr404(t, c, projectName)
+	if project.Name == "" {
+		return
+	}
+
+	upload, err := openUpload(c, maxUploadSize)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer upload.Close()
+
+	items, err := markdown.Parse(upload)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := markdown.Import(t, project, items)
+	if errors.Is(err, dependency.ErrBlocked) {
+		sendJSONResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/rank"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// For json validation of PUT /projects/:projectName/tasks/:taskName/position.
+// Exactly one of the names of the neighbour tasks is required
+type Position struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Handler for PUT /projects/:projectName/tasks/:taskName/position
+func PutTaskPositionHandler(t store.TodoStore, c *gin.Context) {
+	var json Position
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (json.Before == "") == (json.After == "") {
+		sendJSONResponse(c, http.StatusBadRequest, "either before or after is required")
+		return
+	}
+
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+	task := checkIfTaskExistsOr404(t, c, project.Name, c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	neighbourName := json.Before + json.After
+	if neighbourName == task.Name {
+		sendJSONResponse(c, http.StatusBadRequest, "a task can not be moved next to itself")
+		return
+	}
+	neighbour := t.GetTask(project.Name, neighbourName)
+	if neighbour.Name == "" {
+		sendJSONResponse(c, http.StatusNotFound, "task "+neighbourName+" not found")
+		return
+	}
+
+	err := t.Transaction(func(tx store.TodoStore) error {
+		return moveTask(tx, project, task, neighbour, json.After != "")
+	})
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "task moved")
+}
+
+// Moves task next to neighbour. Usually only the rank of the task changes,
+// the project is ranked again if the neighbours have equal ranks
+func moveTask(t store.TodoStore, project model.Project, task, neighbour model.Task, after bool) error {
+	// Tasks in order without the moved task
+	tasks := []model.Task{}
+	for _, other := range t.GetAllProjectTasks(project) {
+		if other.ID != task.ID {
+			tasks = append(tasks, other)
+		}
+	}
+
+	index := 0
+	for i, other := range tasks {
+		if other.ID == neighbour.ID {
+			index = i
+		}
+	}
+	if after {
+		index++
+	}
+
+	var before, next string
+	if index > 0 {
+		before = tasks[index-1].Rank
+	}
+	if index < len(tasks) {
+		next = tasks[index].Rank
+	}
+
+	if task.Rank = rank.Between(before, next); task.Rank != "" {
+		return t.UpdateTask(task)
+	}
+
+	// Insert the task and rank all tasks
+	tasks = append(tasks[:index], append([]model.Task{task}, tasks[index:]...)...)
+	for i, ranked := range rank.Sequence(len(tasks)) {
+		tasks[i].Rank = ranked
+		if err := t.UpdateTask(tasks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
@@ -17,7 +18,7 @@ func GetProjectHandler(t store.TodoStore, c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, project)
+	sendCacheableJSON(c, project, project.UpdatedAt)
 }
 
 // Handler for POST /projects/
@@ -41,7 +42,7 @@ func PostProjectHandler(t store.TodoStore, c *gin.Context) {
 	// Create project
 	err := t.PostProject(projectName)
 	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{
@@ -52,7 +53,10 @@ func PostProjectHandler(t store.TodoStore, c *gin.Context) {
 // Handler for GET /projects/
 func GetAllProjectsHandler(t store.TodoStore, c *gin.Context) {
 	projects := t.GetAllProjects()
-	c.JSON(http.StatusOK, projects)
+
+	// Deleted projects leave no trace in the newest UpdatedAt,
+	// lists are only revalidated with their ETag
+	sendCacheableJSON(c, projects, time.Time{})
 }
 
 // Handler for PUT /projects/:projectName
@@ -74,7 +78,7 @@ func PutProjectHandler(t store.TodoStore, c *gin.Context) {
 	project.Name = newProjectName
 	err := t.UpdateProject(project)
 	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -93,7 +97,7 @@ func DeleteProjectHandler(t store.TodoStore, c *gin.Context) {
 		sendJSONResponse(c, http.StatusNotFound, "project not found")
 		return
 	} else if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -126,7 +130,7 @@ func ArchiveProjectHandler(t store.TodoStore, c *gin.Context) {
 	err := t.UpdateProject(project)
 
 	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
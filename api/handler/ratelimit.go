@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ratelimit"
+)
+
+// Middleware that limits the requests of every client per route group.
+// Every matching group counts the request, the headers show the group
+// with the fewest remaining requests. Clients are told apart by key
+func RateLimit(groups *ratelimit.Groups, key func(r *http.Request) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, ok := groups.Allow(c.Request.Method, c.Request.URL.Path, key(c.Request), time.Now())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"message": "rate limit exceeded",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Rounds up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Upper limit of results returned by one search
+const maxSearchResults = 100
+
+// Handler for GET /search
+func SearchHandler(t store.TodoStore, c *gin.Context) {
+	query := store.SearchQuery{
+		Text:    c.Query("q"),
+		Project: c.Query("project"),
+		Type:    c.Query("type"),
+	}
+
+	if query.Text == "" {
+		sendJSONResponse(c, http.StatusBadRequest, "query parameter q is required")
+		return
+	}
+
+	if query.Type != "" && query.Type != "project" && query.Type != "task" {
+		sendJSONResponse(c, http.StatusBadRequest, "type must be project or task")
+		return
+	}
+
+	if value := c.Query("done"); value != "" {
+		done, err := strconv.ParseBool(value)
+		if err != nil {
+			sendJSONResponse(c, http.StatusBadRequest, "done must be true or false")
+			return
+		}
+		query.Done = &done
+	}
+
+	var err error
+	if query.DueBefore, err = parseQueryTime(c, "due_before"); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.DueAfter, err = parseQueryTime(c, "due_after"); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxSearchResults {
+			sendJSONResponse(c, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		query.Limit = limit
+	}
+
+	results, err := t.Search(query)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+
+	sendCacheableJSON(c, results, time.Time{})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/stats"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Handler for GET /projects/:projectName/stats
+func GetProjectStatsHandler(t store.TodoStore, c *gin.Context) {
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+
+	from, err := parseQueryTime(c, "from")
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseQueryTime(c, "to")
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := stats.Compute(project, t.GetAllProjectTasks(project), time.Now(), from, to)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Overdue tasks change without updates, so only the ETag is used
+	sendCacheableJSON(c, result, time.Time{})
+}
@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
 )
 
 // Handler for POST /projects/:projectName/tasks
@@ -28,23 +30,16 @@ func PostTaskHandler(t store.TodoStore, c *gin.Context) {
 
 	// Create Task
 	task := model.Task{}
-	task.Name = json.Name
-	task.Priority = json.Priority
-
-	// Parse the deadline string as a time.Time{}
-	deadline, errTime := time.Parse("2006-01-02 15:04:05 +0000 UTC", json.Deadline)
-	if errTime != nil {
-		sendJSONResponse(c, http.StatusBadRequest, errTime.Error())
+	if err := json.applyTo(&task); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	task.Deadline = &deadline
 	task.ProjectID = project.ID
 
 	err := t.PostTask(task)
 
 	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
 		return
 	}
 	sendJSONResponse(c, http.StatusCreated, "task created")
@@ -66,7 +61,12 @@ func GetTaskHandler(t store.TodoStore, c *gin.Context) {
 	if task.Name == "" {
 		return
 	}
-	c.JSON(http.StatusOK, task)
+
+	// Removed dependencies change the blocked status without
+	// touching any task, so only the ETag can tell about changes
+	tasks := []model.Task{task}
+	dependency.MarkBlocked(t, tasks)
+	sendCacheableJSON(c, tasks[0], time.Time{})
 }
 
 // Handler for Route GET /projects/:projectName/tasks
@@ -81,7 +81,11 @@ func GetAllTasksHandler(t store.TodoStore, c *gin.Context) {
 
 	// Get all Tasks of the project
 	tasks := t.GetAllProjectTasks(project)
-	c.JSON(http.StatusOK, tasks)
+	dependency.MarkBlocked(t, tasks)
+
+	// Deleted tasks leave no trace in the newest UpdatedAt,
+	// lists are only revalidated with their ETag
+	sendCacheableJSON(c, tasks, time.Time{})
 }
 
 // Handler for Route PUT /projects/:projectName/tasks/:taskName
@@ -109,20 +113,15 @@ func PutTaskHandler(t store.TodoStore, c *gin.Context) {
 	}
 
 	// Update task
-	oldTask.Name = jsonTask.Name
-	oldTask.Priority = jsonTask.Priority
-	// Parse the deadline string as a time.Time{}
-	deadline, errTime := time.Parse("2006-01-02 15:04:05 +0000 UTC", jsonTask.Deadline)
-	if errTime != nil {
-		sendJSONResponse(c, http.StatusBadRequest, errTime.Error())
+	if err := jsonTask.applyTo(&oldTask); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	oldTask.Deadline = &deadline
 
 	err := t.UpdateTask(oldTask)
 
 	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
 		return
 	}
 
@@ -142,7 +141,8 @@ func DeleteTaskHandler(t store.TodoStore, c *gin.Context) {
 
 	err := t.DeleteTask(task)
 	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+		sendInternalError(c, err)
+		return
 	}
 
 	sendJSONResponse(c, http.StatusOK, "task deleted")
@@ -159,23 +159,31 @@ func CompleteTaskHandler(t store.TodoStore, c *gin.Context) {
 		return
 	}
 
-	// Update task struct
+	// force completes tasks with open blockers
+	force, err := parseQueryBool(c, "force")
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var message string
+	var done bool
 	switch httpMethod := c.Request.Method; httpMethod {
 	case "PUT":
-		task.CompleteTask()
+		done = true
 		message = "task completed"
 	case "DELETE":
-		task.ReopenTask()
+		done = false
 		message = "task undone"
 	default:
 		sendJSONResponse(c, http.StatusInternalServerError, "wrong http method")
+		return
 	}
 
-	// update task in db
-	err := t.UpdateTask(task)
-	if err != nil {
-		sendJSONResponse(c, http.StatusInternalServerError, err.Error())
+	// Move the task into the first closed or open state of the workflow
+	_, err = workflow.Complete(t, t.GetProject(projectName), task, done, force)
+	if sendWorkflowError(c, err) {
+		return
 	}
 
 	sendJSONResponse(c, http.StatusOK, message)
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/timetrack"
+)
+
+// For json validation of POST /projects/:projectName/tasks/:taskName/timer
+type Timer struct {
+	Notes string `json:"notes"`
+}
+
+// For json validation of POST /projects/:projectName/tasks/:taskName/time
+type TimeEntry struct {
+	Start string `json:"start" binding:"required"`
+	End   string `json:"end" binding:"required"`
+	Notes string `json:"notes"`
+}
+
+// Handler for POST /projects/:projectName/tasks/:taskName/timer
+func StartTimerHandler(t store.TodoStore, c *gin.Context) {
+	var json Timer
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&json); err != nil {
+			sendJSONResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	entry, err := timetrack.Start(t, task, requestUser(c), json.Notes, time.Now())
+	if errors.Is(err, timetrack.ErrTimerRunning) {
+		sendJSONResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// Handler for DELETE /projects/:projectName/tasks/:taskName/timer
+func StopTimerHandler(t store.TodoStore, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	entry, err := timetrack.Stop(t, task, requestUser(c), time.Now())
+	if errors.Is(err, timetrack.ErrNoTimer) {
+		sendJSONResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// Handler for GET /timer
+func GetTimerHandler(t store.TodoStore, c *gin.Context) {
+	entry := t.GetRunningTimeEntry(requestUser(c))
+	if entry.ID == 0 {
+		sendJSONResponse(c, http.StatusNotFound, timetrack.ErrNoTimer.Error())
+		return
+	}
+
+	report := timetrack.NewReport(t, timetrack.ReportQuery{
+		Projects: t.GetAllProjects(),
+		User:     entry.User,
+		From:     &entry.Start,
+	}, time.Now())
+
+	for _, reported := range report.Entries {
+		if reported.ID == entry.ID {
+			sendCacheableJSON(c, reported, time.Time{})
+			return
+		}
+	}
+	sendJSONResponse(c, http.StatusNotFound, timetrack.ErrNoTimer.Error())
+}
+
+// Handler for POST /projects/:projectName/tasks/:taskName/time
+func PostTimeEntryHandler(t store.TodoStore, c *gin.Context) {
+	var json TimeEntry
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, json.Start)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, "start must be a RFC 3339 timestamp")
+		return
+	}
+	end, err := time.Parse(time.RFC3339, json.End)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, "end must be a RFC 3339 timestamp")
+		return
+	}
+
+	entry, err := timetrack.Add(t, task, requestUser(c), start, end, json.Notes)
+	if errors.Is(err, timetrack.ErrInvalidRange) {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// Handler for DELETE /projects/:projectName/tasks/:taskName/time/:id
+func DeleteTimeEntryHandler(t store.TodoStore, c *gin.Context) {
+	task := checkIfTaskExistsOr404(t, c, c.Param("projectName"), c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	entry := t.GetTimeEntry(uint(id))
+	if entry.ID == 0 || entry.TaskID != task.ID {
+		sendJSONResponse(c, http.StatusNotFound, "time entry not found")
+		return
+	}
+
+	if err := t.DeleteTimeEntry(entry); err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "time entry deleted")
+}
+
+// Handler for GET /projects/:projectName/tasks/:taskName/time
+func GetTaskTimeHandler(t store.TodoStore, c *gin.Context) {
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+	task := checkIfTaskExistsOr404(t, c, project.Name, c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	sendTimeReport(c, t, timetrack.ReportQuery{Projects: []model.Project{project}, Task: task.Name})
+}
+
+// Handler for GET /projects/:projectName/time
+func GetProjectTimeHandler(t store.TodoStore, c *gin.Context) {
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+
+	sendTimeReport(c, t, timetrack.ReportQuery{Projects: []model.Project{project}})
+}
+
+// Handler for GET /reports/time
+func GetTimeReportHandler(t store.TodoStore, c *gin.Context) {
+	query := timetrack.ReportQuery{Projects: t.GetAllProjects()}
+
+	if name := c.Query("project"); name != "" {
+		project := checkIfProjectExistsOr404(t, c, name)
+		if project.Name == "" {
+			return
+		}
+		query.Projects = []model.Project{project}
+	}
+
+	sendTimeReport(c, t, query)
+}
+
+// Adds the filters user, from and to of the request to the query and sends the report
+func sendTimeReport(c *gin.Context, t store.TodoStore, query timetrack.ReportQuery) {
+	query.User = c.Query("user")
+
+	var err error
+	if query.From, err = parseQueryTime(c, "from"); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.To, err = parseQueryTime(c, "to"); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		sendJSONResponse(c, http.StatusBadRequest, "to must be after from")
+		return
+	}
+
+	sendCacheableJSON(c, timetrack.NewReport(t, query, time.Now()), time.Time{})
+}
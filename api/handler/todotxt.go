@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/todotxt"
+)
+
+// Handler for GET /todo.txt and GET /projects/:projectName/todo.txt
+func ExportTodoTxtHandler(t store.TodoStore, c *gin.Context) {
+	projects := t.GetAllProjects()
+
+	if projectName := c.Param("projectName"); projectName != "" {
+		// Check if project exists
+		project := checkIfProjectExistsOr404(t, c, projectName)
+		if project.Name == "" {
+			return
+		}
+		projects = []model.Project{project}
+	}
+
+	var body strings.Builder
+	for _, project := range projects {
+		for _, task := range t.GetAllProjectTasks(project) {
+			body.WriteString(todotxt.Format(project, task) + "\n")
+		}
+	}
+
+	// Deleted tasks leave no trace in the newest UpdatedAt,
+	// exports are only revalidated with their ETag
+	sendCacheable(c, "text/plain; charset=utf-8", []byte(body.String()), time.Time{})
+}
+
+// Handler for POST /todo.txt and POST /projects/:projectName/todo.txt
+func ImportTodoTxtHandler(t store.TodoStore, c *gin.Context) {
+	// Lines without +project tag go into this project
+	defaultProject := c.Query("project")
+
+	if projectName := c.Param("projectName"); projectName != "" {
+		// Check if project exists
+		project := checkIfProjectExistsOr404(t, c, projectName)
+		if project.Name == "" {
+			return
+		}
+		defaultProject = project.Name
+	}
+
+	upload, err := openUpload(c, maxUploadSize)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer upload.Close()
+
+	report, err := todotxt.Import(t, defaultProject, upload)
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/transfer"
+)
+
+// For json validation of POST /projects/:projectName/tasks/:taskName/move and /copy
+type TaskTransfer struct {
+	// Target project
+	Project string `json:"project" binding:"required"`
+	// New name of the task, optional
+	Name       string `json:"name"`
+	OnConflict string `json:"on_conflict"`
+}
+
+// For json validation of POST /tasks/move and /tasks/copy
+type TasksTransfer struct {
+	From       string         `json:"from" binding:"required"`
+	To         string         `json:"to" binding:"required"`
+	Filter     TransferFilter `json:"filter"`
+	OnConflict string         `json:"on_conflict"`
+}
+
+// Selects the tasks of a transfer. An empty filter selects all tasks
+type TransferFilter struct {
+	Done      *bool    `json:"done"`
+	Tag       string   `json:"tag"`
+	DueBefore string   `json:"due_before"`
+	DueAfter  string   `json:"due_after"`
+	Names     []string `json:"names"`
+}
+
+// Handler for POST /projects/:projectName/tasks/:taskName/move and /copy
+func TransferTaskHandler(t store.TodoStore, action string, c *gin.Context) {
+	var json TaskTransfer
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if c.IsAborted() {
+		return
+	}
+	task := checkIfTaskExistsOr404(t, c, from.Name, c.Param("taskName"))
+	if c.IsAborted() {
+		return
+	}
+	to := checkIfProjectExistsOr404(t, c, json.Project)
+	if c.IsAborted() {
+		return
+	}
+
+	options := transfer.Options{Action: action, OnConflict: json.OnConflict, Name: json.Name}
+	runTransfer(t, c, from, to, []model.Task{task}, options)
+}
+
+// Handler for POST /tasks/move and /tasks/copy
+func TransferTasksHandler(t store.TodoStore, action string, c *gin.Context) {
+	var json TasksTransfer
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from := checkIfProjectExistsOr404(t, c, json.From)
+	if c.IsAborted() {
+		return
+	}
+	to := checkIfProjectExistsOr404(t, c, json.To)
+	if c.IsAborted() {
+		return
+	}
+
+	tasks, err := filterTasks(t.GetAllProjectTasks(from), json.Filter)
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	options := transfer.Options{Action: action, OnConflict: json.OnConflict}
+	runTransfer(t, c, from, to, tasks, options)
+}
+
+// Runs a transfer and sends the report
+func runTransfer(t store.TodoStore, c *gin.Context, from, to model.Project, tasks []model.Task, options transfer.Options) {
+	if err := options.Validate(); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := transfer.Transfer(t, from, to, tasks, options)
+	switch {
+	case errors.Is(err, transfer.ErrArchived):
+		sendJSONResponse(c, http.StatusForbidden, "archived projects can not be changed")
+	case errors.Is(err, transfer.ErrConflict):
+		sendJSONResponse(c, http.StatusConflict, err.Error())
+	case err != nil:
+		sendInternalError(c, err)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// Returns the tasks matching all conditions of the filter
+func filterTasks(tasks []model.Task, filter TransferFilter) ([]model.Task, error) {
+	dueBefore, err := parseTime("due_before", filter.DueBefore)
+	if err != nil {
+		return nil, err
+	}
+	dueAfter, err := parseTime("due_after", filter.DueAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, name := range filter.Names {
+		names[name] = true
+	}
+
+	filtered := []model.Task{}
+	for _, task := range tasks {
+		switch {
+		case filter.Done != nil && task.Done != *filter.Done:
+			continue
+		case filter.Tag != "" && !hasTag(task, filter.Tag):
+			continue
+		case len(names) > 0 && !names[task.Name]:
+			continue
+		case dueBefore != nil && (task.Deadline == nil || !task.Deadline.Before(*dueBefore)):
+			continue
+		case dueAfter != nil && (task.Deadline == nil || !task.Deadline.After(*dueAfter)):
+			continue
+		}
+		filtered = append(filtered, task)
+	}
+	return filtered, nil
+}
+
+func hasTag(task model.Task, tag string) bool {
+	for _, t := range task.TagList() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
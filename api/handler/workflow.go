@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+)
+
+// For json validation of PUT /projects/:projectName/workflow
+type Workflow struct {
+	States []WorkflowState `json:"states" binding:"required"`
+}
+
+type WorkflowState struct {
+	Name     string `json:"name" binding:"required"`
+	Category string `json:"category" binding:"required"`
+	WIPLimit int    `json:"wip_limit"`
+}
+
+// For json validation of PUT /projects/:projectName/tasks/:taskName/state
+type TaskState struct {
+	State string `json:"state" binding:"required"`
+}
+
+// Handler for GET /projects/:projectName/workflow
+func GetWorkflowHandler(t store.TodoStore, c *gin.Context) {
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+
+	sendCacheableJSON(c, gin.H{"states": workflow.States(t, project)}, project.UpdatedAt)
+}
+
+// Handler for PUT /projects/:projectName/workflow
+func PutWorkflowHandler(t store.TodoStore, c *gin.Context) {
+	var json Workflow
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+
+	states := []model.WorkflowState{}
+	for _, state := range json.States {
+		states = append(states, model.WorkflowState{Name: state.Name, Category: state.Category, WIPLimit: state.WIPLimit})
+	}
+	if err := workflow.Validate(states); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Touch the project, the workflow is cached with its Last-Modified
+	err := t.Transaction(func(tx store.TodoStore) error {
+		if err := tx.SetWorkflowStates(project.ID, states); err != nil {
+			return err
+		}
+		return tx.UpdateProject(project)
+	})
+	if err != nil {
+		sendInternalError(c, err)
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "workflow updated")
+}
+
+// Handler for PUT /projects/:projectName/tasks/:taskName/state
+func PutTaskStateHandler(t store.TodoStore, c *gin.Context) {
+	var json TaskState
+	if err := c.ShouldBindJSON(&json); err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+	task := checkIfTaskExistsOr404(t, c, project.Name, c.Param("taskName"))
+	if task.Name == "" {
+		return
+	}
+
+	// force moves tasks with open blockers into closed states
+	force, err := parseQueryBool(c, "force")
+	if err != nil {
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = workflow.Transition(t, project, task, json.State, force)
+	if sendWorkflowError(c, err) {
+		return
+	}
+	sendJSONResponse(c, http.StatusOK, "task moved to "+json.State)
+}
+
+// Handler for GET /projects/:projectName/board
+func GetBoardHandler(t store.TodoStore, c *gin.Context) {
+	project := checkIfProjectExistsOr404(t, c, c.Param("projectName"))
+	if project.Name == "" {
+		return
+	}
+
+	// Deleted tasks leave no trace in the newest UpdatedAt,
+	// so the board is only revalidated with its ETag
+	sendCacheableJSON(c, workflow.NewBoard(t, project), time.Time{})
+}
+
+// Sends the response for errors of state transitions.
+// Returns false if there was no error
+func sendWorkflowError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, workflow.ErrUnknownState):
+		sendJSONResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, workflow.ErrWIPLimit), errors.Is(err, dependency.ErrBlocked):
+		sendJSONResponse(c, http.StatusConflict, err.Error())
+	default:
+		sendInternalError(c, err)
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A VTODO component read from an iCalendar document
+type Todo struct {
+	UID         string
+	Summary     string
+	Description string
+	Due         *time.Time
+	Priority    int
+	Status      string
+	RRule       string
+	Categories  []string
+
+	// Line of BEGIN:VTODO, used to report invalid entries
+	Line int
+	// Set if a property of the component could not be parsed
+	Err error
+}
+
+// A content line split into its parts
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+	line   int
+}
+
+// Reads all VTODO components of an iCalendar document.
+// Errors in single components are stored in Todo.Err,
+// only documents that can not be read at all return an error
+func Decode(r io.Reader) ([]Todo, error) {
+	properties, err := readProperties(r)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := []Todo{}
+	var current *Todo
+	// Nested components like VALARM are skipped
+	depth := 0
+
+	for _, prop := range properties {
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VTODO") && current == nil:
+			current = &Todo{Line: prop.line}
+		case prop.name == "BEGIN" && current != nil:
+			depth++
+		case prop.name == "END" && current != nil && depth > 0:
+			depth--
+		case prop.name == "END" && strings.EqualFold(prop.value, "VTODO") && current != nil:
+			todos = append(todos, *current)
+			current = nil
+		case current != nil && depth == 0:
+			if err := current.set(prop); err != nil && current.Err == nil {
+				current.Err = err
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("line %d: VTODO is not terminated", current.Line)
+	}
+	return todos, nil
+}
+
+// Sets a property of the todo
+func (t *Todo) set(prop property) error {
+	switch prop.name {
+	case "UID":
+		t.UID = prop.value
+	case "SUMMARY":
+		t.Summary = unescapeText(prop.value)
+	case "DESCRIPTION":
+		t.Description = unescapeText(prop.value)
+	case "DUE":
+		due, err := parseTime(prop)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid DUE: %v", prop.line, err)
+		}
+		t.Due = &due
+	case "PRIORITY":
+		priority, err := strconv.Atoi(prop.value)
+		if err != nil || priority < 0 || priority > 9 {
+			return fmt.Errorf("line %d: PRIORITY must be between 0 and 9", prop.line)
+		}
+		t.Priority = priority
+	case "STATUS":
+		t.Status = strings.ToUpper(prop.value)
+	case "RRULE":
+		t.RRule = prop.value
+	case "CATEGORIES":
+		for _, category := range splitList(prop.value) {
+			if category = strings.TrimSpace(unescapeText(category)); category != "" {
+				t.Categories = append(t.Categories, category)
+			}
+		}
+	}
+	return nil
+}
+
+// Reads and unfolds the content lines of a document
+func readProperties(r io.Reader) ([]property, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	properties := []property{}
+	var unfolded strings.Builder
+	start, lineNumber := 0, 0
+
+	flush := func() error {
+		if unfolded.Len() == 0 {
+			return nil
+		}
+		prop, err := parseProperty(unfolded.String(), start)
+		if err != nil {
+			return err
+		}
+		properties = append(properties, prop)
+		unfolded.Reset()
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// Folded lines continue with a space or tab
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			unfolded.WriteString(line[1:])
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if line != "" {
+			unfolded.WriteString(line)
+			start = lineNumber
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(properties) == 0 || properties[0].name != "BEGIN" || !strings.EqualFold(properties[0].value, "VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar document")
+	}
+	return properties, nil
+}
+
+// Splits a content line into name, parameters and value
+func parseProperty(line string, lineNumber int) (property, error) {
+	// The value starts after the first colon outside of quotes
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("line %d: missing colon", lineNumber)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  line[colon+1:],
+		line:   lineNumber,
+	}
+	for _, param := range parts[1:] {
+		pair := strings.SplitN(param, "=", 2)
+		if len(pair) == 2 {
+			prop.params[strings.ToUpper(pair[0])] = strings.Trim(pair[1], "\"")
+		}
+	}
+	return prop, nil
+}
+
+// Parses DATE and DATE-TIME values including TZID parameters
+func parseTime(prop property) (time.Time, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len("20060102") {
+		return time.Parse("20060102", prop.value)
+	}
+	if strings.HasSuffix(prop.value, "Z") {
+		return time.Parse(dateTimeLayout, prop.value)
+	}
+
+	// Floating times are interpreted as UTC
+	location := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		loaded, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %s", tzid)
+		}
+		location = loaded
+	}
+
+	parsed, err := time.ParseInLocation("20060102T150405", prop.value, location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.UTC(), nil
+}
+
+// Splits a comma separated list, respecting escaped commas
+func splitList(value string) []string {
+	items := []string{}
+	var item strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			item.WriteByte(value[i])
+			item.WriteByte(value[i+1])
+			i++
+		case value[i] == ',':
+			items = append(items, item.String())
+			item.Reset()
+		default:
+			item.WriteByte(value[i])
+		}
+	}
+	return append(items, item.String())
+}
+
+// Reverses escapeText
+func unescapeText(text string) string {
+	replacer := strings.NewReplacer(
+		"\\\\", "\\",
+		"\\;", ";",
+		"\\,", ",",
+		"\\n", "\n",
+		"\\N", "\n",
+	)
+	return replacer.Replace(text)
+}
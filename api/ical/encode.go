@@ -0,0 +1,169 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Components a task can be exported as
+const (
+	ComponentTodo  = "VTODO"
+	ComponentEvent = "VEVENT"
+)
+
+// Product identifier and domain of the exported UIDs
+const (
+	productID = "-//mpfen//Go-Todo-REST-API-V2//EN"
+	uidDomain = "go-todo-rest-api"
+)
+
+// Layout of UTC date-time values
+const dateTimeLayout = "20060102T150405Z"
+
+// Lines are folded after 75 octets as required by RFC 5545
+const maxLineLength = 75
+
+// Writes the tasks with a deadline as iCalendar document.
+// component is either ComponentTodo or ComponentEvent
+func Encode(w io.Writer, calendarName, component string, tasks []model.Task) error {
+	if component != ComponentTodo && component != ComponentEvent {
+		return fmt.Errorf("unknown component %s", component)
+	}
+
+	writer := &lineWriter{w: bufio.NewWriter(w)}
+	writer.line("BEGIN", "VCALENDAR")
+	writer.line("VERSION", "2.0")
+	writer.line("PRODID", productID)
+	writer.line("CALSCALE", "GREGORIAN")
+	writer.line("X-WR-CALNAME", escapeText(calendarName))
+
+	for _, task := range tasks {
+		if task.Deadline == nil {
+			continue
+		}
+		encodeTask(writer, component, task)
+	}
+
+	writer.line("END", "VCALENDAR")
+	return writer.flush()
+}
+
+// Returns the stable UID of a task.
+// Imported tasks keep their original UID
+func TaskUID(task model.Task) string {
+	if task.ExternalUID != "" {
+		return task.ExternalUID
+	}
+	return fmt.Sprintf("task-%d@%s", task.ID, uidDomain)
+}
+
+// Writes a single task as VTODO or VEVENT
+func encodeTask(writer *lineWriter, component string, task model.Task) {
+	writer.line("BEGIN", component)
+	writer.line("UID", TaskUID(task))
+	writer.line("DTSTAMP", formatTime(task.UpdatedAt))
+	if !task.CreatedAt.IsZero() {
+		writer.line("CREATED", formatTime(task.CreatedAt))
+		writer.line("LAST-MODIFIED", formatTime(task.UpdatedAt))
+	}
+
+	summary := task.Name
+	if component == ComponentEvent && task.Done {
+		// Events have no completion status
+		summary = "[done] " + summary
+	}
+	writer.line("SUMMARY", escapeText(summary))
+
+	if task.Notes != "" {
+		writer.line("DESCRIPTION", escapeText(task.Notes))
+	}
+	if tags := task.TagList(); len(tags) > 0 {
+		for i, tag := range tags {
+			tags[i] = escapeText(tag)
+		}
+		writer.line("CATEGORIES", strings.Join(tags, ","))
+	}
+	if level := task.PriorityLevel(); level > 0 {
+		writer.line("PRIORITY", strconv.Itoa(level))
+	}
+
+	if component == ComponentTodo {
+		writer.line("DUE", formatTime(*task.Deadline))
+		if task.Done {
+			writer.line("STATUS", "COMPLETED")
+			writer.line("PERCENT-COMPLETE", "100")
+		} else {
+			writer.line("STATUS", "NEEDS-ACTION")
+		}
+	} else {
+		writer.line("DTSTART", formatTime(*task.Deadline))
+		writer.line("DURATION", "PT0S")
+		writer.line("TRANSP", "TRANSPARENT")
+	}
+
+	if task.Recurrence != "" {
+		writer.line("RRULE", task.Recurrence)
+	}
+	writer.line("END", component)
+}
+
+// Formats a time as UTC date-time value
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+// Escapes a TEXT value as described in RFC 5545 section 3.3.11
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	)
+	return replacer.Replace(text)
+}
+
+// Writes folded content lines terminated by CRLF
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (l *lineWriter) line(name, value string) {
+	if l.err != nil {
+		return
+	}
+
+	content := name + ":" + value
+	// Continuation lines start with a space
+	limit := maxLineLength
+	for len(content) > limit {
+		// Never split a multi-byte rune
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		_, l.err = l.w.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		limit = maxLineLength - 1
+	}
+	_, err := l.w.WriteString(content + "\r\n")
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+func (l *lineWriter) flush() error {
+	if l.err != nil {
+		return l.err
+	}
+	return l.w.Flush()
+}
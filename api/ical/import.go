@@ -0,0 +1,156 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// The document can not be read at all
+var ErrInvalidDocument = errors.New("invalid iCalendar document")
+
+// Result of an import
+type ImportReport struct {
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Skipped []SkippedEntry `json:"skipped"`
+}
+
+// A VTODO that was not imported
+type SkippedEntry struct {
+	Line    int    `json:"line"`
+	UID     string `json:"uid"`
+	Summary string `json:"summary"`
+	Reason  string `json:"reason"`
+}
+
+// Imports all VTODO components of a document into a project.
+// Tasks remember the UID they were imported from, so importing
+// the same document again updates them instead of creating duplicates.
+// All tasks are imported in one transaction. Completed todos of
+// tasks with open blockers are skipped.
+// Documents that can not be read fail with ErrInvalidDocument
+func Import(t store.TodoStore, project model.Project, r io.Reader) (ImportReport, error) {
+	report := ImportReport{Skipped: []SkippedEntry{}}
+
+	todos, err := Decode(r)
+	if err != nil {
+		return report, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	err = t.Transaction(func(tx store.TodoStore) error {
+		tasksByUID := map[string]model.Task{}
+		taskNames := map[string]bool{}
+		for _, task := range tx.GetAllProjectTasks(project) {
+			if task.ExternalUID != "" {
+				tasksByUID[task.ExternalUID] = task
+			}
+			taskNames[task.Name] = true
+		}
+
+		seen := map[string]bool{}
+		for _, todo := range todos {
+			skip := func(reason string) {
+				report.Skipped = append(report.Skipped, SkippedEntry{
+					Line:    todo.Line,
+					UID:     todo.UID,
+					Summary: todo.Summary,
+					Reason:  reason,
+				})
+			}
+
+			if reason := validateTodo(todo); reason != "" {
+				skip(reason)
+				continue
+			}
+			if seen[todo.UID] {
+				skip("duplicate UID")
+				continue
+			}
+			seen[todo.UID] = true
+
+			task, exists := tasksByUID[todo.UID]
+			if !exists && taskNames[todo.Summary] || exists && task.Name != todo.Summary && taskNames[todo.Summary] {
+				skip("task name already exists")
+				continue
+			}
+
+			// Only new tasks are free of blockers
+			if exists && !task.Done && todo.Status == "COMPLETED" {
+				if err := dependency.CheckDone(tx, task); err != nil {
+					skip(err.Error())
+					continue
+				}
+			}
+
+			oldName := task.Name
+			applyTodo(&task, todo)
+
+			if exists {
+				if err := tx.UpdateTask(task); err != nil {
+					return err
+				}
+				report.Updated++
+				delete(taskNames, oldName)
+			} else {
+				task.ProjectID = project.ID
+				if err := tx.PostTask(task); err != nil {
+					return err
+				}
+				report.Created++
+			}
+			taskNames[task.Name] = true
+		}
+		return nil
+	})
+
+	if err != nil {
+		report = ImportReport{Skipped: []SkippedEntry{}}
+	}
+	return report, err
+}
+
+// Returns why a todo can not be imported or an empty string
+func validateTodo(todo Todo) string {
+	switch {
+	case todo.Err != nil:
+		return todo.Err.Error()
+	case todo.UID == "":
+		return "missing UID"
+	case todo.Summary == "":
+		return "missing SUMMARY"
+	case todo.Status == "CANCELLED":
+		return "task was cancelled"
+	}
+
+	if err := ValidateRecurrence(todo.RRule); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// Copies the properties of a todo into a task
+func applyTodo(task *model.Task, todo Todo) {
+	task.Name = todo.Summary
+	task.Notes = todo.Description
+	task.Deadline = todo.Due
+	task.Recurrence = todo.RRule
+	task.ExternalUID = todo.UID
+	task.SetTags(todo.Categories)
+
+	task.Priority = ""
+	if todo.Priority > 0 {
+		task.Priority = strconv.Itoa(todo.Priority)
+	}
+
+	if todo.Status == "COMPLETED" {
+		task.CompleteTask()
+	} else {
+		task.ReopenTask()
+	}
+}
@@ -0,0 +1,123 @@
+package ical
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Valid values of the FREQ rule part
+var frequencies = map[string]bool{
+	"SECONDLY": true,
+	"MINUTELY": true,
+	"HOURLY":   true,
+	"DAILY":    true,
+	"WEEKLY":   true,
+	"MONTHLY":  true,
+	"YEARLY":   true,
+}
+
+// Valid values of the WKST rule part
+var weekdays = map[string]bool{
+	"MO": true,
+	"TU": true,
+	"WE": true,
+	"TH": true,
+	"FR": true,
+	"SA": true,
+	"SU": true,
+}
+
+// Values of the BY rule parts, lists of numbers or weekdays like "1,-1" or "MO,2TU"
+var byValue = regexp.MustCompile(`^[+-]?[0-9]*[A-Za-z]{0,2}(,[+-]?[0-9]*[A-Za-z]{0,2})*$`)
+
+// Rule parts of a RRULE as defined in RFC 5545 section 3.3.10
+var ruleParts = map[string]bool{
+	"FREQ":       true,
+	"UNTIL":      true,
+	"COUNT":      true,
+	"INTERVAL":   true,
+	"BYSECOND":   true,
+	"BYMINUTE":   true,
+	"BYHOUR":     true,
+	"BYDAY":      true,
+	"BYMONTHDAY": true,
+	"BYYEARDAY":  true,
+	"BYWEEKNO":   true,
+	"BYMONTH":    true,
+	"BYSETPOS":   true,
+	"WKST":       true,
+}
+
+// Checks if rule is a valid RRULE value like "FREQ=WEEKLY;INTERVAL=2".
+// An empty rule means the task does not recur
+func ValidateRecurrence(rule string) error {
+	if rule == "" {
+		return nil
+	}
+	// Line breaks would end the RRULE line of exported calendars
+	if strings.IndexFunc(rule, unicode.IsControl) >= 0 {
+		return fmt.Errorf("recurrence rule must not contain control characters")
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rule, ";") {
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 || pair[1] == "" {
+			return fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		name, value := strings.ToUpper(pair[0]), pair[1]
+		if !ruleParts[name] {
+			return fmt.Errorf("unknown recurrence rule part %s", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("recurrence rule part %s is set twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if !frequencies[strings.ToUpper(value)] {
+				return fmt.Errorf("invalid recurrence frequency %s", value)
+			}
+		case "COUNT", "INTERVAL":
+			if number, err := strconv.Atoi(value); err != nil || number < 1 {
+				return fmt.Errorf("recurrence rule part %s must be a positive number", name)
+			}
+		case "UNTIL":
+			if !isDateOrDateTime(value) {
+				return fmt.Errorf("recurrence rule part UNTIL must be a date or date-time")
+			}
+		case "WKST":
+			if !weekdays[strings.ToUpper(value)] {
+				return fmt.Errorf("recurrence rule part WKST must be a weekday")
+			}
+		default:
+			if !byValue.MatchString(value) {
+				return fmt.Errorf("invalid value %q of recurrence rule part %s", value, name)
+			}
+		}
+	}
+
+	if !seen["FREQ"] {
+		return fmt.Errorf("recurrence rule needs a FREQ")
+	}
+	if seen["COUNT"] && seen["UNTIL"] {
+		return fmt.Errorf("recurrence rule must not contain COUNT and UNTIL")
+	}
+	return nil
+}
+
+// Checks for the DATE or DATE-TIME value formats
+func isDateOrDateTime(value string) bool {
+	for _, layout := range []string{"20060102", "20060102T150405", dateTimeLayout} {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
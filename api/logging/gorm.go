@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Adapts the logger of the context to gorm. Failed queries are logged as
+// errors, slow queries as warnings and all other queries at level debug.
+// Open the database with PlaceholderDialector to keep parameter values
+// out of the logged SQL
+type GormLogger struct {
+	// Queries taking longer are slow, zero disables the warnings
+	SlowThreshold time.Duration
+
+	silent bool
+}
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold}
+}
+
+func (g *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	adapted := *g
+	adapted.silent = level == logger.Silent
+	return &adapted
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	g.log(ctx, LevelInfo, fmt.Sprintf(msg, args...))
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	g.log(ctx, LevelWarn, fmt.Sprintf(msg, args...))
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	g.log(ctx, LevelError, fmt.Sprintf(msg, args...))
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	level, msg := LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = LevelError, "query failed"
+	case g.SlowThreshold > 0 && elapsed > g.SlowThreshold:
+		level, msg = LevelWarn, "slow query"
+	}
+
+	log := FromContext(ctx)
+	if !log.Enabled(level) {
+		return
+	}
+
+	sql, rows := fc()
+	keyvals := []interface{}{"sql", sql, "rows", rows, "duration_ms", float64(elapsed.Microseconds()) / 1000}
+	if level == LevelError {
+		keyvals = append(keyvals, "error", err)
+	}
+	log.Log(level, msg, keyvals...)
+}
+
+// Wraps a dialector so gorm passes SQL with ? placeholders to its logger.
+// Parameter values like notes, comments or feed tokens are not logged.
+// gorm only uses the Explain of the wrapper for logging, migrations
+// use the dialector itself. The dialector has to support savepoints,
+// gorm needs them for nested transactions
+func PlaceholderDialector(dialector SavePointDialector) gorm.Dialector {
+	return placeholderDialector{dialector}
+}
+
+// A dialector supporting savepoints, like the one of SQLite
+type SavePointDialector interface {
+	gorm.Dialector
+	gorm.SavePointerDialectorInterface
+}
+
+type placeholderDialector struct {
+	SavePointDialector
+}
+
+func (placeholderDialector) Explain(sql string, vars ...interface{}) string {
+	return sql
+}
+
+func (g *GormLogger) log(ctx context.Context, level Level, msg string) {
+	if !g.silent {
+		FromContext(ctx).Log(level, msg)
+	}
+}
@@ -0,0 +1,181 @@
+// Package logging writes structured logs as JSON lines.
+//
+// Every line has the fields time, level and msg followed by the fields of
+// the logger and the call. Loggers carrying the request ID are passed
+// along with the context of a request, so everything logged while
+// handling the request can be correlated with it.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Parses the names debug, info, warn and error
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(level), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// Writes lines of at least its level. Safe for concurrent use
+type Logger struct {
+	out   *output
+	level Level
+	// Alternating keys and values added to every line
+	fields []interface{}
+}
+
+// Shared by a logger and the loggers derived with With
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{out: &output{w: w}, level: level}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = New(os.Stderr, LevelInfo)
+)
+
+// Returns the logger used outside of requests, which logs to stderr
+// at level info unless it is replaced with SetDefault
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+func SetDefault(logger *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = logger
+}
+
+// Returns a logger adding the alternating keys and values to every line
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	return &Logger{out: l.out, level: l.level, fields: fields}
+}
+
+// Reports whether lines of the level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.Log(LevelDebug, msg, keyvals...)
+}
+
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.Log(LevelInfo, msg, keyvals...)
+}
+
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.Log(LevelWarn, msg, keyvals...)
+}
+
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.Log(LevelError, msg, keyvals...)
+}
+
+// Writes a line with the alternating keys and values.
+// Errors are written as their message
+func (l *Logger) Log(level Level, msg string, keyvals ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	line := &bytes.Buffer{}
+	line.WriteString(`{"time":`)
+	writeValue(line, time.Now().UTC().Format(time.RFC3339Nano))
+	line.WriteString(`,"level":`)
+	writeValue(line, level.String())
+	line.WriteString(`,"msg":`)
+	writeValue(line, msg)
+	writeFields(line, l.fields)
+	writeFields(line, keyvals)
+	line.WriteString("}\n")
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w.Write(line.Bytes())
+}
+
+func writeFields(line *bytes.Buffer, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		line.WriteByte(',')
+		writeValue(line, fmt.Sprint(keyvals[i]))
+		line.WriteByte(':')
+		if i+1 < len(keyvals) {
+			writeValue(line, keyvals[i+1])
+		} else {
+			line.WriteString("null")
+		}
+	}
+}
+
+func writeValue(line *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	line.Write(encoded)
+}
+
+type contextKey struct{}
+
+// Returns a context carrying the logger
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// Returns the logger of the context, the default logger if it has none
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return logger
+		}
+	}
+	return Default()
+}
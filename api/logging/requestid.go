@@ -0,0 +1,21 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// Request IDs sent by clients are kept if they match
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Returns the request ID sent by a client if it is valid, otherwise a new random ID
+func RequestID(sent string) string {
+	if validRequestID.MatchString(sent) {
+		return sent
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
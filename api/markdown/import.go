@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"fmt"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Result of an import
+type ImportReport struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// Imports checklist items into a project.
+// Existing tasks are matched by name and get the done state of their item,
+// nested items become subtasks. All items are imported in one transaction,
+// checked items of tasks with open blockers fail with dependency.ErrBlocked
+func Import(t store.TodoStore, project model.Project, items []Item) (ImportReport, error) {
+	report := ImportReport{}
+
+	err := t.Transaction(func(tx store.TodoStore) error {
+		// IDs of the last task on every nesting level
+		parents := []uint{}
+
+		for _, item := range items {
+			task := tx.GetTask(project.Name, item.Name)
+			exists := task.ID != 0
+
+			task.Name = item.Name
+			task.ProjectID = project.ID
+			if item.Priority != "" {
+				task.Priority = item.Priority
+			}
+			if item.Due != nil {
+				task.Deadline = item.Due
+			}
+			if item.Done && exists && !task.Done {
+				if err := dependency.CheckDone(tx, task); err != nil {
+					return fmt.Errorf("line %d: %w", item.Line, err)
+				}
+			}
+			if item.Done {
+				task.CompleteTask()
+			} else {
+				task.ReopenTask()
+			}
+
+			parents = parents[:item.Level]
+			if item.Level > 0 {
+				parent := parents[item.Level-1]
+				task.ParentID = &parent
+			} else {
+				task.ParentID = nil
+			}
+
+			var err error
+			if exists {
+				err = tx.UpdateTask(task)
+				report.Updated++
+			} else {
+				err = tx.PostTask(task)
+				report.Created++
+			}
+			if err != nil {
+				return err
+			}
+
+			// The ID of new tasks is needed for their subtasks
+			parents = append(parents, tx.GetTask(project.Name, item.Name).ID)
+		}
+		return nil
+	})
+
+	if err != nil {
+		report = ImportReport{}
+	}
+	return report, err
+}
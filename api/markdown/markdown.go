@@ -0,0 +1,181 @@
+// Package markdown converts the tasks of a project from and to
+// Markdown checklists:
+//
+//	# homework
+//
+//	- [ ] math (priority: 1, due: 2021-06-10)
+//	  - [x] exercise 3
+//	- [x] biology
+//
+// Subtasks are indented below their parent task.
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Layout of deadlines
+const dateLayout = "2006-01-02"
+
+// Indentation of one nesting level
+const indent = "  "
+
+// Matches checklist items like "- [x] name"
+var itemPattern = regexp.MustCompile(`^(\s*)[-*+] \[([ xX])\] (.+)$`)
+
+// Matches the metadata like "(priority: 1, due: 2021-06-10)" at the end of an item
+var metadataPattern = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)$`)
+
+// A parsed checklist item
+type Item struct {
+	Name     string
+	Done     bool
+	Priority string
+	Due      *time.Time
+	// Nesting level, 0 for top level items
+	Level int
+	// Line of the item, used to report errors
+	Line int
+}
+
+// Writes the tasks of a project as Markdown document
+func Render(w io.Writer, project model.Project, tasks []model.Task) error {
+	// Group the tasks by parent. Tasks with unknown parents are top level tasks
+	ids := map[uint]bool{}
+	for _, task := range tasks {
+		ids[task.ID] = true
+	}
+	children := map[uint][]model.Task{}
+	for _, task := range tasks {
+		parent := uint(0)
+		if task.ParentID != nil && ids[*task.ParentID] && *task.ParentID != task.ID {
+			parent = *task.ParentID
+		}
+		children[parent] = append(children[parent], task)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("# " + project.Name + "\n\n")
+
+	// Subtasks are rendered below their parent, visited guards against cycles
+	visited := map[uint]bool{}
+	var render func(parent uint, level int)
+	render = func(parent uint, level int) {
+		for _, task := range children[parent] {
+			if visited[task.ID] {
+				continue
+			}
+			visited[task.ID] = true
+			builder.WriteString(strings.Repeat(indent, level) + formatItem(task) + "\n")
+			render(task.ID, level+1)
+		}
+	}
+	render(0, 0)
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// Formats a task as checklist item
+func formatItem(task model.Task) string {
+	check := " "
+	if task.Done {
+		check = "x"
+	}
+
+	metadata := []string{}
+	if task.Priority != "" {
+		metadata = append(metadata, "priority: "+task.Priority)
+	}
+	if task.Deadline != nil {
+		metadata = append(metadata, "due: "+task.Deadline.Format(dateLayout))
+	}
+
+	item := fmt.Sprintf("- [%s] %s", check, task.Name)
+	if len(metadata) > 0 {
+		item += " (" + strings.Join(metadata, ", ") + ")"
+	}
+	return item
+}
+
+// Reads the checklist items of a document. Other lines are ignored.
+// Names have to be unique, an item listed twice could become its own parent
+func Parse(r io.Reader) ([]Item, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	// Indentation widths of the currently open nesting levels
+	levels := []int{}
+	// Lines of the items by name
+	seen := map[string]int{}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		match := itemPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		width := len(strings.ReplaceAll(match[1], "\t", "    "))
+		for len(levels) > 0 && levels[len(levels)-1] >= width {
+			levels = levels[:len(levels)-1]
+		}
+		levels = append(levels, width)
+
+		item, err := parseItem(match[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if line, ok := seen[item.Name]; ok {
+			return nil, fmt.Errorf("line %d: task %s is already listed in line %d", i+1, item.Name, line)
+		}
+		seen[item.Name] = i + 1
+		item.Done = match[2] != " "
+		item.Level = len(levels) - 1
+		item.Line = i + 1
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Splits the text of an item into name and metadata
+func parseItem(text string) (Item, error) {
+	item := Item{Name: strings.TrimSpace(text)}
+
+	match := metadataPattern.FindStringSubmatch(item.Name)
+	if match == nil {
+		return item, nil
+	}
+
+	// Parentheses are only metadata if they contain known keys
+	metadata := Item{Name: match[1]}
+	for _, part := range strings.Split(match[2], ",") {
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) != 2 {
+			return item, nil
+		}
+
+		value := strings.TrimSpace(pair[1])
+		switch strings.ToLower(strings.TrimSpace(pair[0])) {
+		case "priority":
+			metadata.Priority = value
+		case "due":
+			due, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return item, fmt.Errorf("invalid due date %s", value)
+			}
+			metadata.Due = &due
+		default:
+			return item, nil
+		}
+	}
+	return metadata, nil
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,7 +16,7 @@ type Project struct {
 }
 
 func DbMigrate(db *gorm.DB) *gorm.DB {
-	db.AutoMigrate(&Project{}, &Task{})
+	db.AutoMigrate(&Project{}, &Task{}, &FeedToken{}, &WorkflowState{}, &TimeEntry{}, &TaskDependency{}, &Comment{}, &Attachment{})
 	return db
 }
 
@@ -28,17 +30,182 @@ func (p *Project) UnArchiveProject() {
 
 type Task struct {
 	gorm.Model
-	Name      string     `json:"name"`
-	Priority  string     `json:"priority"`
-	Deadline  *time.Time `gorm:"default:null" json:"deadline"`
-	Done      bool       `json:"done"`
-	ProjectID uint       `json:"project_id"`
+	Name        string     `json:"name"`
+	Priority    string     `json:"priority"`
+	Deadline    *time.Time `gorm:"default:null" json:"deadline"`
+	Done        bool       `json:"done"`
+	CompletedAt *time.Time `gorm:"default:null" json:"completed_at"`
+	Notes       string     `json:"notes"`
+	Recurrence  string     `json:"recurrence"`
+	Tags        string     `json:"tags"`
+	ExternalUID string     `json:"external_uid"`
+	ParentID    *uint      `gorm:"default:null" json:"parent_id"`
+	Rank        string     `gorm:"index" json:"rank"`
+	State       string     `json:"state"`
+	ProjectID   uint       `json:"project_id"`
+	// true while a task blocking this task is open. Computed from the
+	// dependencies of the task, not stored
+	Blocked bool `gorm:"-" json:"blocked"`
 }
 
+// Completes the task and records when it was completed.
+// Completing a done task keeps the original completion time.
+// Completing an open task resets its workflow state
 func (t *Task) CompleteTask() {
+	if !t.Done {
+		t.State = ""
+	}
+	if !t.Done || t.CompletedAt == nil {
+		now := time.Now()
+		t.CompletedAt = &now
+	}
 	t.Done = true
 }
 
+// Reopens the task. Reopening a done task resets its workflow state
 func (t *Task) ReopenTask() {
+	if t.Done {
+		t.State = ""
+	}
 	t.Done = false
+	t.CompletedAt = nil
+}
+
+// Returns the comma separated tags of a task as list
+func (t *Task) TagList() []string {
+	tags := []string{}
+	for _, tag := range strings.Split(t.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Stores a list of tags. Commas inside tags are not allowed
+func (t *Task) SetTags(tags []string) {
+	cleaned := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", " "))
+		if tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	t.Tags = strings.Join(cleaned, ",")
+}
+
+// Maps the priority of a task to a level between 1 (highest)
+// and 9 (lowest) as used by iCalendar. 0 means undefined
+func (t *Task) PriorityLevel() int {
+	switch strings.ToLower(strings.TrimSpace(t.Priority)) {
+	case "high", "urgent":
+		return 1
+	case "medium", "normal":
+		return 5
+	case "low":
+		return 9
+	}
+
+	level, err := strconv.Atoi(strings.TrimSpace(t.Priority))
+	if err != nil || level < 0 {
+		return 0
+	}
+	if level > 9 {
+		return 9
+	}
+	return level
+}
+
+// Categories of workflow states
+const (
+	StateOpen   = "open"
+	StateActive = "active"
+	// Tasks in closed states are done
+	StateClosed = "closed"
+)
+
+// A state of the workflow of a project, e.g. "in review".
+// WIPLimit is the maximum number of tasks in the state, 0 for no limit
+type WorkflowState struct {
+	gorm.Model `json:"-"`
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	WIPLimit   int    `json:"wip_limit"`
+	Position   int    `json:"-"`
+	ProjectID  uint   `json:"-"`
+}
+
+// The task with TaskID can't start until the task with BlockedByID is done.
+// Both tasks may belong to different projects, tasks depend on each other once
+type TaskDependency struct {
+	gorm.Model
+	TaskID      uint `gorm:"uniqueIndex:idx_task_dependencies_task_blocked_by" json:"task_id"`
+	BlockedByID uint `gorm:"index;uniqueIndex:idx_task_dependencies_task_blocked_by" json:"blocked_by_id"`
+}
+
+// Time spent by a user on a task. End is nil while the timer is running
+type TimeEntry struct {
+	gorm.Model
+	TaskID uint       `gorm:"index" json:"task_id"`
+	User   string     `gorm:"index" json:"user"`
+	Start  time.Time  `gorm:"column:started_at" json:"start"`
+	End    *time.Time `gorm:"column:ended_at;default:null" json:"end"`
+	Notes  string     `json:"notes"`
+}
+
+// Returns the duration of the entry. Running entries last until now
+func (e *TimeEntry) Duration(now time.Time) time.Duration {
+	if e.End != nil {
+		now = *e.End
+	}
+	return now.Sub(e.Start)
+}
+
+// A comment on a task. The body is Markdown, BodyHTML is rendered
+// from it when the comment is sent. EditedAt is nil until the body changes
+type Comment struct {
+	gorm.Model
+	TaskID   uint       `gorm:"index" json:"task_id"`
+	Author   string     `json:"author"`
+	Body     string     `json:"body"`
+	BodyHTML string     `gorm:"-" json:"body_html"`
+	EditedAt *time.Time `gorm:"default:null" json:"edited_at"`
+}
+
+// A file attached to a task. The content is kept in a blob store
+// under BlobKey, SHA256 is the hex encoded checksum of the content
+type Attachment struct {
+	gorm.Model
+	TaskID      uint   `gorm:"index" json:"task_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `gorm:"column:sha256" json:"sha256"`
+	BlobKey     string `gorm:"unique" json:"-"`
+}
+
+// Grants read access to the calendar feed of a project.
+// Tokens without a project grant access to all projects
+type FeedToken struct {
+	gorm.Model
+	Token     string `json:"token" gorm:"unique"`
+	ProjectID *uint  `json:"project_id"`
+}
+
+// Checks if the token grants access to the project
+func (f *FeedToken) AllowsProject(project Project) bool {
+	return f.ProjectID == nil || *f.ProjectID == project.ID
+}
+
+// A project or task found by a full-text search
+type SearchResult struct {
+	Type      string     `json:"type"`
+	ID        uint       `json:"id"`
+	Project   string     `json:"project"`
+	Name      string     `json:"name"`
+	Highlight string     `json:"highlight"`
+	Snippet   string     `json:"snippet"`
+	Score     float64    `json:"score"`
+	Done      *bool      `json:"done,omitempty"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
 }
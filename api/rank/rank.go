@@ -0,0 +1,107 @@
+// Package rank computes lexicographic ranks for manual ordering.
+//
+// Ranks are strings of the digits 0-9 and a-z that sort in byte order.
+// A rank between any two different ranks can always be found, so moving
+// an item only changes the rank of that item.
+package rank
+
+import "strings"
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// Returns a rank that sorts after rank. An empty rank is the start of the list.
+// The last digit is incremented, so appending many items keeps ranks short.
+// Only ranks ending with the highest digit get a rank in between the end
+func After(rank string) string {
+	if last := len(rank) - 1; last >= 0 {
+		if d := digit(rank, last, 0); d >= 0 && d < base-1 {
+			return rank[:last] + digits[d+1:d+2]
+		}
+	}
+	return Between(rank, "")
+}
+
+// Returns a rank that sorts before rank. An empty rank is the end of the list
+func Before(rank string) string {
+	return Between("", rank)
+}
+
+// Returns a rank that sorts between before and after, which must be ordered.
+// An empty before is the start of the list, an empty after the end of it.
+// Returns "" if there is no rank in between, e.g. for equal ranks
+func Between(before, after string) string {
+	if after != "" && before >= after {
+		return ""
+	}
+
+	var result strings.Builder
+	upperBound := after != ""
+	for i := 0; ; i++ {
+		low := digit(before, i, 0)
+		high := base
+		if upperBound {
+			if i >= len(after) {
+				// before is a prefix of after padded with zeros
+				return ""
+			}
+			high = digit(after, i, 0)
+		}
+
+		if low == high {
+			result.WriteByte(digits[low])
+			continue
+		}
+
+		if middle := (low + high) / 2; middle > low {
+			result.WriteByte(digits[middle])
+			return result.String()
+		}
+
+		// No digit in between, the remaining digits only
+		// have to sort after the remaining digits of before
+		result.WriteByte(digits[low])
+		upperBound = false
+	}
+}
+
+// Returns n ascending ranks with even gaps, to rank a whole list at once
+func Sequence(n int) []string {
+	ranks := make([]string, n)
+
+	// Enough digits for n ranks
+	width := 1
+	for capacity := base - 1; capacity < n; capacity *= base {
+		width++
+	}
+
+	step := 1
+	for i := 0; i < width; i++ {
+		step *= base
+	}
+	step /= n + 1
+
+	for i := range ranks {
+		ranks[i] = format((i+1)*step, width)
+	}
+	return ranks
+}
+
+// Value of the digit at position i, or fallback past the end of rank
+func digit(rank string, i, fallback int) int {
+	if i >= len(rank) {
+		return fallback
+	}
+	return strings.IndexByte(digits, rank[i])
+}
+
+// Formats a number with width digits, without trailing zeros
+func format(number, width int) string {
+	formatted := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		formatted[i] = digits[number%base]
+		number /= base
+	}
+	return strings.TrimRight(string(formatted), "0")
+}
@@ -0,0 +1,206 @@
+// Package ratelimit limits requests with token buckets.
+//
+// Every client key has its own bucket, which holds up to Limit.Requests
+// tokens and is refilled with Limit.Requests tokens per Limit.Per.
+// Each request takes one token, requests finding an empty bucket are
+// rejected. Buckets that are full again are forgotten.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Requests allowed per duration. Up to Requests requests may be sent at once
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Parses limits like "60/1m" or "10/s"
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("rate limit %q is not of the form requests/duration", value)
+	}
+
+	limit := Limit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(parts[0]); err != nil || limit.Requests <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q needs a positive number of requests", value)
+	}
+	per := parts[1]
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+	if limit.Per, err = time.ParseDuration(per); err != nil || limit.Per <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q needs a positive duration", value)
+	}
+	return limit, nil
+}
+
+// Parses a comma separated list of IP addresses and CIDR ranges
+// like "10.0.0.1, 192.168.0.0/16" as sent by trusted proxies
+func ParseProxies(value string) ([]*net.IPNet, error) {
+	proxies := []*net.IPNet{}
+	for _, proxy := range strings.Split(value, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("proxy %q is no IP address", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %q is no CIDR range", proxy)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Outcome of a request
+type Result struct {
+	Allowed bool
+	// Requests of the limit
+	Limit int
+	// Requests that may be sent right now
+	Remaining int
+	// Time until the bucket is full again
+	Reset time.Duration
+	// Time until the next request is allowed, zero if the request was allowed
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Keeps the buckets of all keys for one limit. Safe for concurrent use
+type Limiter struct {
+	limit Limit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{limit: limit, buckets: map[string]*bucket{}}
+}
+
+// Takes a token from the bucket of key if there is one
+func (l *Limiter) Allow(key string, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := float64(l.limit.Requests)
+	perToken := l.limit.Per / time.Duration(l.limit.Requests)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(perToken))
+		b.last = now
+	}
+
+	result := Result{Limit: l.limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+
+	l.sweep(now)
+	return result
+}
+
+// Limiters of route groups, shared by all APIs of a server
+type Groups struct {
+	groups []group
+}
+
+type group struct {
+	method  string
+	prefix  string
+	limiter *Limiter
+}
+
+// Creates the limiters of route groups. limits maps path prefixes,
+// optionally preceded by a method as in "POST /projects/", to their limits
+func NewGroups(limits map[string]Limit) *Groups {
+	routes := make([]string, 0, len(limits))
+	for route := range limits {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	g := &Groups{}
+	for _, route := range routes {
+		group := group{prefix: route, limiter: NewLimiter(limits[route])}
+		if fields := strings.Fields(route); len(fields) == 2 {
+			group.method, group.prefix = strings.ToUpper(fields[0]), fields[1]
+		}
+		g.groups = append(g.groups, group)
+	}
+	return g
+}
+
+// Takes a token of key from every group matching the request. Returns the
+// result of the group with the fewest remaining requests, preferring
+// rejections, and false if no group matches
+func (g *Groups) Allow(method, path, key string, now time.Time) (Result, bool) {
+	var lowest *Result
+	for _, group := range g.groups {
+		if group.method != "" && group.method != method || !strings.HasPrefix(path, group.prefix) {
+			continue
+		}
+
+		result := group.limiter.Allow(key, now)
+		if lowest == nil || !result.Allowed && lowest.Allowed ||
+			result.Allowed == lowest.Allowed && result.Remaining < lowest.Remaining {
+			lowest = &result
+		}
+	}
+	if lowest == nil {
+		return Result{}, false
+	}
+	return *lowest, true
+}
+
+// Forgets full buckets once per period, they behave like new buckets
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Per {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -1,89 +1,581 @@
 package api
 
 import (
+	"net"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/agenda"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/attachment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/backup"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/graphqlapi"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/grpcapi"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/handler"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ratelimit"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/transfer"
+	"google.golang.org/grpc"
 )
 
 type TodoServer struct {
 	Router *gin.Engine
 	Store  store.TodoStore
+
+	cachePolicies map[string]handler.CachePolicy
+	adminToken    string
+	restoreLimit  int64
+	docsUI        bool
+	graphQLLimits graphqlapi.Limits
+	rateLimits    map[string]ratelimit.Limit
+	rateLimitKey  func(r *http.Request) string
+	rateLimiter   *ratelimit.Groups
+	proxies       []*net.IPNet
+	logger        *logging.Logger
+
+	blobs            blob.Store
+	attachmentLimits attachment.Limits
+}
+
+// Option configures optional behaviour of a TodoServer
+type Option func(*TodoServer)
+
+// Overrides the cache policy of a GET route.
+// route is the path as registered, e.g. "/projects/:projectName"
+func WithCachePolicy(route string, policy handler.CachePolicy) Option {
+	return func(t *TodoServer) {
+		t.cachePolicies[route] = policy
+	}
+}
+
+// Enables the admin routes, which require the token as bearer token.
+// Without token the admin routes are not available
+func WithAdminToken(token string) Option {
+	return func(t *TodoServer) {
+		t.adminToken = token
+	}
+}
+
+// Overrides the maximum size in bytes of backups sent to
+// POST /admin/restore, zero disables the limit
+func WithRestoreLimit(maxSize int64) Option {
+	return func(t *TodoServer) {
+		t.restoreLimit = maxSize
+	}
+}
+
+// Serves a Redoc page of the OpenAPI document at /docs.
+// The page loads a pinned Redoc release from a CDN
+func WithDocsUI() Option {
+	return func(t *TodoServer) {
+		t.docsUI = true
+	}
+}
+
+// Overrides the depth and complexity limits of GraphQL queries
+func WithGraphQLLimits(limits graphqlapi.Limits) Option {
+	return func(t *TodoServer) {
+		t.graphQLLimits = limits
+	}
+}
+
+// Limits the requests of every client to a route group. group is a
+// path prefix, optionally preceded by a method, e.g. "/" for all routes
+// or "POST /projects/". Clients are told apart by their IP address,
+// see WithRateLimitKey. Requests have to be within the limits of all
+// matching groups. The limits apply to the gRPC API as well, see GRPCOptions
+func WithRateLimit(group string, limit ratelimit.Limit) Option {
+	return func(t *TodoServer) {
+		t.rateLimits[group] = limit
+	}
+}
+
+// Tells clients apart by the key of their requests instead of their IP
+// address, e.g. by a user set by an authenticating proxy. Requests
+// with an empty key are told apart by their IP address
+func WithRateLimitKey(key func(r *http.Request) string) Option {
+	return func(t *TodoServer) {
+		t.rateLimitKey = key
+	}
+}
+
+// Trusts the X-Forwarded-For header of requests sent by the proxies
+// when telling clients apart. Without proxies the header is ignored
+func WithTrustedProxies(proxies ...*net.IPNet) Option {
+	return func(t *TodoServer) {
+		t.proxies = proxies
+	}
+}
+
+// Logs requests and errors with the logger instead of the default logger
+func WithLogger(logger *logging.Logger) Option {
+	return func(t *TodoServer) {
+		t.logger = logger
+	}
+}
+
+// Enables the attachment routes, which keep the contents of attachments
+// in blobs. Wrap the store with attachment.NewStore to delete the blobs
+// of deleted tasks
+func WithBlobStore(blobs blob.Store) Option {
+	return func(t *TodoServer) {
+		t.blobs = blobs
+	}
+}
+
+// Overrides the size and content type limits of attachments
+func WithAttachmentLimits(limits attachment.Limits) Option {
+	return func(t *TodoServer) {
+		t.attachmentLimits = limits
+	}
 }
 
 // Initialize TodoServer and create a gin router
-func NewTodoServer(store store.TodoStore) *TodoServer {
+func NewTodoServer(store store.TodoStore, options ...Option) *TodoServer {
 	t := new(TodoServer)
 	t.Store = store
-	t.Router = gin.Default()
+	t.Router = gin.New()
+	// Clients are identified with handler.ClientIP, which only
+	// trusts the proxies passed to WithTrustedProxies
+	t.Router.ForwardedByClientIP = false
+	t.Router.TrustedProxies = nil
+	t.cachePolicies = map[string]handler.CachePolicy{}
+	t.rateLimits = map[string]ratelimit.Limit{}
+	t.restoreLimit = backup.DefaultMaxSize
+	t.graphQLLimits = graphqlapi.DefaultLimits
+	t.attachmentLimits = attachment.DefaultLimits
+	t.logger = logging.Default()
+
+	for _, option := range options {
+		option(t)
+	}
+
+	// Requests rejected by the rate limit are logged as well
+	t.Router.Use(handler.RequestID(t.logger), handler.AccessLog(t.proxies), handler.Recovery())
+	t.rateLimiter = ratelimit.NewGroups(t.rateLimits)
+	if len(t.rateLimits) > 0 {
+		t.Router.Use(handler.RateLimit(t.rateLimiter, t.clientKey))
+	}
 
 	// Project routes
-	t.Router.GET("/projects/:projectName", t.GetProject)
+	t.cachedGET("/projects/:projectName", t.GetProject)
 	t.Router.POST("/projects/", t.PostProject)
-	t.Router.GET("/projects/", t.GetAllProjects)
+	t.cachedGET("/projects/", t.GetAllProjects)
 	t.Router.PUT("/projects/:projectName", t.PutProject)
 	t.Router.DELETE("/projects/:projectName", t.DeleteProject)
 	t.Router.DELETE("/projects/:projectName/archive", t.ArchiveProject)
 	t.Router.PUT("/projects/:projectName/archive", t.ArchiveProject)
+	t.cachedGET("/projects/:projectName/stats", t.GetProjectStats)
+
+	// Workflow routes
+	t.cachedGET("/projects/:projectName/workflow", t.GetWorkflow)
+	t.Router.PUT("/projects/:projectName/workflow", t.PutWorkflow)
+	t.cachedGET("/projects/:projectName/board", t.GetBoard)
+	t.Router.PUT("/projects/:projectName/tasks/:taskName/state", t.PutTaskState)
 
 	// Task routes
 	t.Router.POST("projects/:projectName/tasks", t.PostTask)
-	t.Router.GET("projects/:projectName/tasks/:taskName", t.GetTask)
-	t.Router.GET("projects/:projectName/tasks", t.GetAllTasks)
+	t.cachedGET("/projects/:projectName/tasks/:taskName", t.GetTask)
+	t.cachedGET("/projects/:projectName/tasks", t.GetAllTasks)
 	t.Router.PUT("projects/:projectName/tasks/:taskName", t.PutTask)
 	t.Router.DELETE("projects/:projectName/tasks/:taskName", t.DeleteTask)
 	t.Router.PUT("/projects/:projectName/tasks/:taskName/complete", t.CompleteTask)
 	t.Router.DELETE("/projects/:projectName/tasks/:taskName/complete", t.CompleteTask)
+	t.Router.PUT("/projects/:projectName/tasks/:taskName/position", t.PutTaskPosition)
+
+	// Dependency routes
+	t.cachedGET("/projects/:projectName/tasks/:taskName/dependencies", t.GetDependencies)
+	t.Router.POST("/projects/:projectName/tasks/:taskName/dependencies", t.PostDependency)
+	t.Router.DELETE("/projects/:projectName/tasks/:taskName/dependencies/:id", t.DeleteDependency)
+
+	// Comment routes
+	t.cachedGET("/projects/:projectName/tasks/:taskName/comments", t.GetComments)
+	t.Router.POST("/projects/:projectName/tasks/:taskName/comments", t.PostComment)
+	t.cachedGET("/projects/:projectName/tasks/:taskName/comments/:id", t.GetComment)
+	t.Router.PUT("/projects/:projectName/tasks/:taskName/comments/:id", t.PutComment)
+	t.Router.DELETE("/projects/:projectName/tasks/:taskName/comments/:id", t.DeleteComment)
+
+	// Attachment routes
+	if t.blobs != nil {
+		t.cachedGET("/projects/:projectName/tasks/:taskName/attachments", t.GetAttachments)
+		t.Router.POST("/projects/:projectName/tasks/:taskName/attachments", t.PostAttachment)
+		t.cachedGET("/projects/:projectName/tasks/:taskName/attachments/:id", t.GetAttachment)
+		t.Router.DELETE("/projects/:projectName/tasks/:taskName/attachments/:id", t.DeleteAttachment)
+	}
+
+	// Time tracking routes
+	t.Router.POST("/projects/:projectName/tasks/:taskName/timer", t.StartTimer)
+	t.Router.DELETE("/projects/:projectName/tasks/:taskName/timer", t.StopTimer)
+	t.cachedGET("/timer", t.GetTimer)
+	t.cachedGET("/projects/:projectName/tasks/:taskName/time", t.GetTaskTime)
+	t.Router.POST("/projects/:projectName/tasks/:taskName/time", t.PostTimeEntry)
+	t.Router.DELETE("/projects/:projectName/tasks/:taskName/time/:id", t.DeleteTimeEntry)
+	t.cachedGET("/projects/:projectName/time", t.GetProjectTime)
+	t.cachedGET("/reports/time", t.GetTimeReport)
+
+	// Transfer routes
+	t.Router.POST("/projects/:projectName/tasks/:taskName/move", t.MoveTask)
+	t.Router.POST("/projects/:projectName/tasks/:taskName/copy", t.CopyTask)
+	t.Router.POST("/tasks/move", t.MoveTasks)
+	t.Router.POST("/tasks/copy", t.CopyTasks)
+
+	// Batch routes
+	t.Router.POST("/batch", t.Batch)
+
+	// CSV routes
+	t.cachedGET("/tasks.csv", t.ExportCSV)
+	t.cachedGET("/projects/:projectName/tasks.csv", t.ExportCSV)
+	t.Router.POST("/tasks.csv", t.ImportCSV)
+	t.Router.POST("/projects/:projectName/tasks.csv", t.ImportCSV)
+
+	// todo.txt routes
+	t.cachedGET("/todo.txt", t.ExportTodoTxt)
+	t.cachedGET("/projects/:projectName/todo.txt", t.ExportTodoTxt)
+	t.Router.POST("/todo.txt", t.ImportTodoTxt)
+	t.Router.POST("/projects/:projectName/todo.txt", t.ImportTodoTxt)
+
+	// Markdown routes
+	t.cachedGET("/projects/:projectName/tasks.md", t.ExportMarkdown)
+	t.Router.POST("/projects/:projectName/tasks.md", t.ImportMarkdown)
+
+	// Agenda routes
+	t.cachedGET("/agenda/today", t.AgendaToday)
+	t.cachedGET("/agenda/overdue", t.AgendaOverdue)
+	t.cachedGET("/agenda/upcoming", t.AgendaUpcoming)
+
+	// Search routes
+	t.cachedGET("/search", t.Search)
+
+	// GraphQL routes
+	t.cachedGET("/graphql", t.GraphQL)
+	t.Router.POST("/graphql", t.GraphQL)
+
+	// Calendar routes
+	t.cachedGET("/calendar.ics", t.GetCalendar)
+	t.cachedGET("/projects/:projectName/calendar.ics", t.GetCalendar)
+	t.Router.POST("/calendar/tokens", t.PostFeedToken)
+	t.Router.POST("/projects/:projectName/calendar/tokens", t.PostFeedToken)
+	t.Router.DELETE("/calendar/tokens/:token", t.DeleteFeedToken)
+	t.Router.POST("/projects/:projectName/import/ics", t.ImportCalendar)
+
+	// Documentation routes
+	t.cachedGET("/openapi.json", t.OpenAPI)
+	if t.docsUI {
+		t.cachedGET("/docs", t.Docs)
+	}
+
+	// Admin routes
+	if t.adminToken != "" {
+		admin := t.Router.Group("/admin", handler.RequireToken(t.adminToken))
+		admin.GET("/backup", t.Backup)
+		admin.POST("/restore", t.Restore)
+	}
 
 	return t
 }
 
+// Registers a GET route together with its cache policy
+func (t *TodoServer) cachedGET(route string, handlerFunc gin.HandlerFunc) {
+	policy, ok := t.cachePolicies[route]
+	if !ok {
+		policy = handler.DefaultCachePolicy
+	}
+
+	t.Router.GET(route, handler.CacheControl(policy), handlerFunc)
+}
+
+// Returns the options for a gRPC server serving the same clients,
+// which log calls with request IDs to the logger of the server
+// and apply its rate limits to gRPC calls
+func (t *TodoServer) GRPCOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			grpcapi.RequestIDUnary(t.logger),
+			grpcapi.RateLimitUnary(t.rateLimiter, t.clientKey)),
+		grpc.ChainStreamInterceptor(
+			grpcapi.RequestIDStream(t.logger),
+			grpcapi.RateLimitStream(t.rateLimiter, t.clientKey)),
+	}
+}
+
+// Returns the key telling apart the clients of rate limits
+func (t *TodoServer) clientKey(r *http.Request) string {
+	if t.rateLimitKey != nil {
+		if key := t.rateLimitKey(r); key != "" {
+			return key
+		}
+	}
+	return handler.ClientIP(r, t.proxies)
+}
+
+// Returns the store running its queries with the context of the request
+func (t *TodoServer) requestStore(c *gin.Context) store.TodoStore {
+	return t.Store.WithContext(c.Request.Context())
+}
+
 // Project Handlers
 func (t *TodoServer) GetProject(c *gin.Context) {
-	handler.GetProjectHandler(t.Store, c)
+	handler.GetProjectHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) PostProject(c *gin.Context) {
-	handler.PostProjectHandler(t.Store, c)
+	handler.PostProjectHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) GetAllProjects(c *gin.Context) {
-	handler.GetAllProjectsHandler(t.Store, c)
+	handler.GetAllProjectsHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) PutProject(c *gin.Context) {
-	handler.PutProjectHandler(t.Store, c)
+	handler.PutProjectHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) DeleteProject(c *gin.Context) {
-	handler.DeleteProjectHandler(t.Store, c)
+	handler.DeleteProjectHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) ArchiveProject(c *gin.Context) {
-	handler.ArchiveProjectHandler(t.Store, c)
+	handler.ArchiveProjectHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetProjectStats(c *gin.Context) {
+	handler.GetProjectStatsHandler(t.requestStore(c), c)
+}
+
+// Workflow Handlers
+func (t *TodoServer) GetWorkflow(c *gin.Context) {
+	handler.GetWorkflowHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PutWorkflow(c *gin.Context) {
+	handler.PutWorkflowHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetBoard(c *gin.Context) {
+	handler.GetBoardHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PutTaskState(c *gin.Context) {
+	handler.PutTaskStateHandler(t.requestStore(c), c)
 }
 
 // Task Handlers
 func (t *TodoServer) PostTask(c *gin.Context) {
-	handler.PostTaskHandler(t.Store, c)
+	handler.PostTaskHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) GetTask(c *gin.Context) {
-	handler.GetTaskHandler(t.Store, c)
+	handler.GetTaskHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) GetAllTasks(c *gin.Context) {
-	handler.GetAllTasksHandler(t.Store, c)
+	handler.GetAllTasksHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) PutTask(c *gin.Context) {
-	handler.PutTaskHandler(t.Store, c)
+	handler.PutTaskHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) DeleteTask(c *gin.Context) {
-	handler.DeleteTaskHandler(t.Store, c)
+	handler.DeleteTaskHandler(t.requestStore(c), c)
 }
 
 func (t *TodoServer) CompleteTask(c *gin.Context) {
-	handler.CompleteTaskHandler(t.Store, c)
+	handler.CompleteTaskHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PutTaskPosition(c *gin.Context) {
+	handler.PutTaskPositionHandler(t.requestStore(c), c)
+}
+
+// Dependency Handlers
+func (t *TodoServer) GetDependencies(c *gin.Context) {
+	handler.GetDependenciesHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PostDependency(c *gin.Context) {
+	handler.PostDependencyHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) DeleteDependency(c *gin.Context) {
+	handler.DeleteDependencyHandler(t.requestStore(c), c)
+}
+
+// Comment Handlers
+func (t *TodoServer) GetComments(c *gin.Context) {
+	handler.GetCommentsHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PostComment(c *gin.Context) {
+	handler.PostCommentHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetComment(c *gin.Context) {
+	handler.GetCommentHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PutComment(c *gin.Context) {
+	handler.PutCommentHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) DeleteComment(c *gin.Context) {
+	handler.DeleteCommentHandler(t.requestStore(c), c)
+}
+
+// Attachment Handlers
+func (t *TodoServer) GetAttachments(c *gin.Context) {
+	handler.GetAttachmentsHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PostAttachment(c *gin.Context) {
+	handler.PostAttachmentHandler(t.requestStore(c), t.blobs, t.attachmentLimits, c)
+}
+
+func (t *TodoServer) GetAttachment(c *gin.Context) {
+	handler.GetAttachmentHandler(t.requestStore(c), t.blobs, c)
+}
+
+func (t *TodoServer) DeleteAttachment(c *gin.Context) {
+	handler.DeleteAttachmentHandler(t.requestStore(c), t.blobs, c)
+}
+
+// Time Tracking Handlers
+func (t *TodoServer) StartTimer(c *gin.Context) {
+	handler.StartTimerHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) StopTimer(c *gin.Context) {
+	handler.StopTimerHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetTimer(c *gin.Context) {
+	handler.GetTimerHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetTaskTime(c *gin.Context) {
+	handler.GetTaskTimeHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PostTimeEntry(c *gin.Context) {
+	handler.PostTimeEntryHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) DeleteTimeEntry(c *gin.Context) {
+	handler.DeleteTimeEntryHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetProjectTime(c *gin.Context) {
+	handler.GetProjectTimeHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) GetTimeReport(c *gin.Context) {
+	handler.GetTimeReportHandler(t.requestStore(c), c)
+}
+
+// Transfer Handlers
+func (t *TodoServer) MoveTask(c *gin.Context) {
+	handler.TransferTaskHandler(t.requestStore(c), transfer.Move, c)
+}
+
+func (t *TodoServer) CopyTask(c *gin.Context) {
+	handler.TransferTaskHandler(t.requestStore(c), transfer.Copy, c)
+}
+
+func (t *TodoServer) MoveTasks(c *gin.Context) {
+	handler.TransferTasksHandler(t.requestStore(c), transfer.Move, c)
+}
+
+func (t *TodoServer) CopyTasks(c *gin.Context) {
+	handler.TransferTasksHandler(t.requestStore(c), transfer.Copy, c)
+}
+
+// Batch Handlers
+func (t *TodoServer) Batch(c *gin.Context) {
+	handler.BatchHandler(t.requestStore(c), c)
+}
+
+// Agenda Handlers
+func (t *TodoServer) AgendaToday(c *gin.Context) {
+	handler.AgendaHandler(t.requestStore(c), agenda.Today, c)
+}
+
+func (t *TodoServer) AgendaOverdue(c *gin.Context) {
+	handler.AgendaHandler(t.requestStore(c), agenda.Overdue, c)
+}
+
+func (t *TodoServer) AgendaUpcoming(c *gin.Context) {
+	handler.AgendaHandler(t.requestStore(c), agenda.Upcoming, c)
+}
+
+// Search Handlers
+func (t *TodoServer) Search(c *gin.Context) {
+	handler.SearchHandler(t.requestStore(c), c)
+}
+
+// GraphQL Handlers
+func (t *TodoServer) GraphQL(c *gin.Context) {
+	handler.GraphQLHandler(t.requestStore(c), t.graphQLLimits, c)
+}
+
+// Calendar Handlers
+func (t *TodoServer) GetCalendar(c *gin.Context) {
+	handler.GetCalendarHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) PostFeedToken(c *gin.Context) {
+	handler.PostFeedTokenHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) DeleteFeedToken(c *gin.Context) {
+	handler.DeleteFeedTokenHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) ImportCalendar(c *gin.Context) {
+	handler.ImportCalendarHandler(t.requestStore(c), c)
+}
+
+// CSV Handlers
+func (t *TodoServer) ExportCSV(c *gin.Context) {
+	handler.ExportCSVHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) ImportCSV(c *gin.Context) {
+	handler.ImportCSVHandler(t.requestStore(c), c)
+}
+
+// todo.txt Handlers
+func (t *TodoServer) ExportTodoTxt(c *gin.Context) {
+	handler.ExportTodoTxtHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) ImportTodoTxt(c *gin.Context) {
+	handler.ImportTodoTxtHandler(t.requestStore(c), c)
+}
+
+// Markdown Handlers
+func (t *TodoServer) ExportMarkdown(c *gin.Context) {
+	handler.ExportMarkdownHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) ImportMarkdown(c *gin.Context) {
+	handler.ImportMarkdownHandler(t.requestStore(c), c)
+}
+
+// Admin Handlers
+func (t *TodoServer) Backup(c *gin.Context) {
+	handler.BackupHandler(t.requestStore(c), c)
+}
+
+func (t *TodoServer) Restore(c *gin.Context) {
+	handler.RestoreHandler(t.requestStore(c), t.restoreLimit, c)
+}
+
+// Documentation Handlers
+func (t *TodoServer) OpenAPI(c *gin.Context) {
+	handler.OpenAPIHandler(OpenAPISpec, c)
+}
+
+func (t *TodoServer) Docs(c *gin.Context) {
+	handler.DocsHandler(c)
 }
@@ -0,0 +1,168 @@
+// Package stats computes the progress of projects.
+//
+// The burndown series is computed from the creation and completion
+// times of the current tasks, so deleted tasks do not appear in it.
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Layout of the days of the burndown series
+const DateLayout = "2006-01-02"
+
+// Upper limit for the days of a burndown series
+const MaxDays = 366
+
+// Progress of a project
+type Stats struct {
+	Project string `json:"project"`
+	Counts
+	// Percentage of done tasks, 0 for projects without tasks
+	CompletionPercent float64         `json:"completion_percent"`
+	ByPriority        []PriorityStats `json:"by_priority"`
+	Burndown          []Day           `json:"burndown"`
+}
+
+type Counts struct {
+	Total   int `json:"total"`
+	Open    int `json:"open"`
+	Done    int `json:"done"`
+	Overdue int `json:"overdue"`
+}
+
+// Counts of the tasks of a priority level between 1 (highest)
+// and 9 (lowest), 0 for tasks without priority
+type PriorityStats struct {
+	Priority int `json:"priority"`
+	Counts
+}
+
+// State of a project at the end of a day (UTC)
+type Day struct {
+	Date string `json:"date"`
+	// Tasks created until the end of the day
+	Scope int `json:"scope"`
+	// Tasks completed until the end of the day
+	Done int `json:"done"`
+	// Tasks still open at the end of the day
+	Remaining int `json:"remaining"`
+}
+
+// Computes the stats of the tasks of a project. The burndown series covers
+// the days from from to to, by default from the creation of the first task,
+// but at most MaxDays days, until now
+func Compute(project model.Project, tasks []model.Task, now time.Time, from, to *time.Time) (Stats, error) {
+	stats := Stats{Project: project.Name, ByPriority: []PriorityStats{}, Burndown: []Day{}}
+
+	priorities := map[int]*PriorityStats{}
+	for _, task := range tasks {
+		level := task.PriorityLevel()
+		if priorities[level] == nil {
+			priorities[level] = &PriorityStats{Priority: level}
+		}
+		stats.Counts.add(task, now)
+		priorities[level].Counts.add(task, now)
+	}
+
+	for _, priority := range priorities {
+		stats.ByPriority = append(stats.ByPriority, *priority)
+	}
+	sort.Slice(stats.ByPriority, func(i, j int) bool { return stats.ByPriority[i].Priority < stats.ByPriority[j].Priority })
+
+	if stats.Total > 0 {
+		stats.CompletionPercent = float64(stats.Done*1000/stats.Total) / 10
+	}
+
+	burndown, err := burndown(tasks, now, from, to)
+	stats.Burndown = burndown
+	return stats, err
+}
+
+// Counts a task
+func (c *Counts) add(task model.Task, now time.Time) {
+	c.Total++
+	if task.Done {
+		c.Done++
+		return
+	}
+	c.Open++
+	if task.Deadline != nil && task.Deadline.Before(now) {
+		c.Overdue++
+	}
+}
+
+// Returns the scope and the done tasks at the end of every day
+func burndown(tasks []model.Task, now time.Time, from, to *time.Time) ([]Day, error) {
+	days := []Day{}
+
+	first := day(now)
+	for _, task := range tasks {
+		if created := day(task.CreatedAt); created.Before(first) {
+			first = created
+		}
+	}
+	last := day(now)
+	if from != nil {
+		first = day(*from)
+	}
+	if to != nil {
+		last = day(*to)
+	}
+
+	// Without from the series starts at most MaxDays days before to
+	if from == nil && last.Sub(first) >= MaxDays*24*time.Hour {
+		first = last.AddDate(0, 0, 1-MaxDays)
+	}
+	if from == nil && first.After(last) {
+		first = last
+	}
+
+	if last.Before(first) {
+		return days, errors.New("to must not be before from")
+	}
+	if last.Sub(first) >= MaxDays*24*time.Hour {
+		return days, fmt.Errorf("the burndown covers at most %d days", MaxDays)
+	}
+
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		end := date.AddDate(0, 0, 1)
+		current := Day{Date: date.Format(DateLayout)}
+
+		for _, task := range tasks {
+			if !task.CreatedAt.Before(end) {
+				continue
+			}
+			current.Scope++
+			if completed := completedAt(task); completed != nil && completed.Before(end) {
+				current.Done++
+			}
+		}
+		current.Remaining = current.Scope - current.Done
+		days = append(days, current)
+	}
+	return days, nil
+}
+
+// Returns when a done task was completed. Tasks completed before
+// completion times were recorded count as completed at their last update
+func completedAt(task model.Task) *time.Time {
+	if !task.Done {
+		return nil
+	}
+	if task.CompletedAt != nil {
+		return task.CompletedAt
+	}
+	return &task.UpdatedAt
+}
+
+// Returns the start of the day in UTC
+func day(t time.Time) time.Time {
+	year, month, date := t.UTC().Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
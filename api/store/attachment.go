@@ -0,0 +1,47 @@
+package store
+
+import (
+	model "github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Gets an attachment by ID
+func (d *Database) GetAttachment(id uint) model.Attachment {
+	attachment := model.Attachment{}
+	err := d.DB.Find(&attachment, id).Error
+
+	if err != nil {
+		return model.Attachment{}
+	}
+
+	return attachment
+}
+
+// Returns the attachments of a task in the order they were added
+func (d *Database) GetAttachments(taskID uint) []model.Attachment {
+	attachments := []model.Attachment{}
+
+	d.DB.Order("ID").Find(&attachments, "Task_ID = ?", taskID)
+
+	return attachments
+}
+
+// Returns the attachments of all tasks
+func (d *Database) GetAllAttachments() []model.Attachment {
+	attachments := []model.Attachment{}
+
+	d.DB.Order("ID").Find(&attachments)
+
+	return attachments
+}
+
+// Creates an attachment
+func (d *Database) PostAttachment(attachment model.Attachment) error {
+	err := d.DB.Create(&attachment).Error
+	return err
+}
+
+// Deletes an attachment. Its blob has to be deleted separately
+func (d *Database) DeleteAttachment(attachment model.Attachment) error {
+	err := d.DB.Unscoped().Delete(&model.Attachment{}, attachment.ID).Error
+	return err
+}
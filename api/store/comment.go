@@ -0,0 +1,47 @@
+package store
+
+import (
+	model "github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Gets a comment by ID
+func (d *Database) GetComment(id uint) model.Comment {
+	comment := model.Comment{}
+	err := d.DB.Find(&comment, id).Error
+
+	if err != nil {
+		return model.Comment{}
+	}
+
+	return comment
+}
+
+// Returns a page of the comments of a task, oldest first, and the number
+// of all comments of the task. A negative limit returns all comments
+func (d *Database) GetComments(taskID uint, offset, limit int) ([]model.Comment, int) {
+	comments := []model.Comment{}
+	var total int64
+
+	d.DB.Model(&model.Comment{}).Where("Task_ID = ?", taskID).Count(&total)
+	d.DB.Order("Created_At, ID").Offset(offset).Limit(limit).Find(&comments, "Task_ID = ?", taskID)
+
+	return comments, int(total)
+}
+
+// Creates a comment
+func (d *Database) PostComment(comment model.Comment) error {
+	err := d.DB.Create(&comment).Error
+	return err
+}
+
+// Updates a comment
+func (d *Database) UpdateComment(comment model.Comment) error {
+	err := d.DB.Save(&comment).Error
+	return err
+}
+
+// Deletes a comment
+func (d *Database) DeleteComment(comment model.Comment) error {
+	err := d.DB.Unscoped().Delete(&model.Comment{}, comment.ID).Error
+	return err
+}
@@ -1,19 +1,31 @@
 package store
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
 	model "github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/rank"
 )
 
 // TodoStore interface for testing
 // Tests use own implementation with
 // StubTodoStore instead of a real database
 type TodoStore interface {
+	// Runs fn in a transaction. The changes made through the
+	// TodoStore passed to fn are rolled back if fn returns an error
+	Transaction(fn func(t TodoStore) error) error
+	// Returns a TodoStore running its queries with the context,
+	// which carries the logger of a request
+	WithContext(ctx context.Context) TodoStore
+
 	GetProject(name string) model.Project
+	GetProjectByID(id uint) model.Project
 	PostProject(name string) error
 	GetAllProjects() []model.Project
 	DeleteProject(name string) error
@@ -22,12 +34,81 @@ type TodoStore interface {
 	GetTask(projectName, taskName string) model.Task
 	PostTask(task model.Task) error
 	GetAllProjectTasks(project model.Project) []model.Task
+	// Gets the tasks of several projects in one query
+	GetTasksOfProjects(projectIDs []uint) []model.Task
+	// Gets the open tasks with a deadline of several projects in one query
+	GetOpenTasksWithDeadline(projectIDs []uint) []model.Task
 	DeleteTask(task model.Task) error
 	UpdateTask(task model.Task) error
+
+	Search(query SearchQuery) ([]model.SearchResult, error)
+
+	// Removes all data, used to replace it with a backup
+	DeleteAll() error
+
+	GetFeedToken(token string) model.FeedToken
+	GetAllFeedTokens() []model.FeedToken
+	PostFeedToken(feedToken model.FeedToken) error
+	DeleteFeedToken(feedToken model.FeedToken) error
+
+	// Gets the workflow states of a project in their order,
+	// empty if the project uses the default workflow
+	GetWorkflowStates(projectID uint) []model.WorkflowState
+	// Replaces the workflow states of a project
+	SetWorkflowStates(projectID uint, states []model.WorkflowState) error
+
+	GetTimeEntry(id uint) model.TimeEntry
+	// Gets the running time entry of a user
+	GetRunningTimeEntry(user string) model.TimeEntry
+	// Gets the time entries matching the query ordered by start
+	GetTimeEntries(query TimeEntryQuery) []model.TimeEntry
+	// Creates a time entry and returns it with its ID and timestamps
+	PostTimeEntry(entry model.TimeEntry) (model.TimeEntry, error)
+	UpdateTimeEntry(entry model.TimeEntry) error
+	DeleteTimeEntry(entry model.TimeEntry) error
+
+	GetDependency(id uint) model.TaskDependency
+	GetAllDependencies() []model.TaskDependency
+	// Gets the dependencies of the tasks with taskIDs, which they are
+	// blocked by, and the dependencies on the tasks with blockedByIDs
+	GetDependencies(taskIDs, blockedByIDs []uint) []model.TaskDependency
+	PostDependency(dependency model.TaskDependency) error
+	DeleteDependency(dependency model.TaskDependency) error
+	// Gets tasks of any project by their IDs
+	GetTasksByID(ids []uint) []model.Task
+
+	GetComment(id uint) model.Comment
+	// Gets a page of the comments of a task, oldest first, and the number
+	// of all comments of the task. A negative limit gets all comments
+	GetComments(taskID uint, offset, limit int) ([]model.Comment, int)
+	PostComment(comment model.Comment) error
+	UpdateComment(comment model.Comment) error
+	DeleteComment(comment model.Comment) error
+
+	GetAttachment(id uint) model.Attachment
+	GetAttachments(taskID uint) []model.Attachment
+	GetAllAttachments() []model.Attachment
+	PostAttachment(attachment model.Attachment) error
+	// Deletes the metadata of an attachment, but not its blob
+	DeleteAttachment(attachment model.Attachment) error
 }
 
 type Database struct {
 	DB *gorm.DB
+
+	// true if the FTS5 search index is available
+	fullText bool
+}
+
+// Runs fn in a database transaction
+func (d *Database) Transaction(fn func(t TodoStore) error) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&Database{DB: tx, fullText: d.fullText})
+	})
+}
+
+func (d *Database) WithContext(ctx context.Context) TodoStore {
+	return &Database{DB: d.DB.WithContext(ctx), fullText: d.fullText}
 }
 
 // Gets project by name
@@ -62,13 +143,36 @@ func (d *Database) GetAllProjects() []model.Project {
 	return projects
 }
 
-// Delete a project
+// Delete a project with its tasks, workflow states and feed tokens.
+// Tasks are deleted like with DeleteTask, together with their time
+// entries, comments, attachments and dependencies
 func (d *Database) DeleteProject(name string) error {
-	project := model.Project{}
-
-	// Unscoped to delete project permanently
-	err := d.DB.Unscoped().Where("Name = ?", name).Delete(&project).Error
-	return err
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		t := &Database{DB: tx, fullText: d.fullText}
+		project := t.GetProject(name)
+		if project.ID == 0 {
+			return nil
+		}
+
+		for _, task := range t.GetAllProjectTasks(project) {
+			if err := t.DeleteTask(task); err != nil {
+				return err
+			}
+		}
+
+		err := tx.Unscoped().Where("Project_ID = ?", project.ID).Delete(&model.WorkflowState{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Where("Project_ID = ?", project.ID).Delete(&model.FeedToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		// Unscoped to delete project permanently
+		return tx.Unscoped().Delete(&project).Error
+	})
 }
 
 // Update a project
@@ -108,17 +212,47 @@ func (d *Database) GetTask(projectName, taskName string) model.Task {
 	return task
 }
 
-// Create a Task
+// Create a Task. Tasks without rank are added at the end of the project
 func (d *Database) PostTask(task model.Task) error {
+	if task.Rank == "" {
+		last := model.Task{}
+		d.DB.Where("Project_ID = ?", task.ProjectID).Order("Rank DESC").Limit(1).Find(&last)
+		task.Rank = rank.After(last.Rank)
+	}
+
 	err := d.DB.Create(&task).Error
 	return err
 }
 
-// Returns an array of all tasks belonging to a project
+// Returns an array of all tasks belonging to a project in the order of their ranks
 func (d *Database) GetAllProjectTasks(project model.Project) []model.Task {
 	tasks := []model.Task{}
 
-	d.DB.Find(&tasks, "Project_ID = ?", project.ID)
+	d.DB.Order("Rank, ID").Find(&tasks, "Project_ID = ?", project.ID)
+
+	return tasks
+}
+
+// Returns the tasks of all given projects
+func (d *Database) GetTasksOfProjects(projectIDs []uint) []model.Task {
+	tasks := []model.Task{}
+	if len(projectIDs) == 0 {
+		return tasks
+	}
+
+	d.DB.Order("Rank, ID").Find(&tasks, "Project_ID IN ?", projectIDs)
+
+	return tasks
+}
+
+// Returns the open tasks with a deadline of all given projects
+func (d *Database) GetOpenTasksWithDeadline(projectIDs []uint) []model.Task {
+	tasks := []model.Task{}
+	if len(projectIDs) == 0 {
+		return tasks
+	}
+
+	d.DB.Order("Deadline, Rank, ID").Find(&tasks, "Project_ID IN ? AND Done = ? AND Deadline IS NOT NULL", projectIDs, false)
 
 	return tasks
 }
@@ -126,6 +260,40 @@ func (d *Database) GetAllProjectTasks(project model.Project) []model.Task {
 // Deletes a Task
 func (d *Database) DeleteTask(task model.Task) error {
 	err := d.DB.Unscoped().Where("Name = ? AND Project_ID = ?", task.Name, task.ProjectID).Delete(&task).Error
+	if err != nil || task.ID == 0 {
+		return err
+	}
+
+	// Subtasks of the deleted task become top level tasks
+	err = d.DB.Model(&model.Task{}).Where("Parent_ID = ?", task.ID).Update("Parent_ID", nil).Error
+	if err != nil {
+		return err
+	}
+
+	err = d.DB.Unscoped().Where("Task_ID = ?", task.ID).Delete(&model.TimeEntry{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = d.DB.Unscoped().Where("Task_ID = ?", task.ID).Delete(&model.Comment{}).Error
+	if err != nil {
+		return err
+	}
+
+	// The blobs of the attachments are deleted by attachment.Store
+	err = d.DB.Unscoped().Where("Task_ID = ?", task.ID).Delete(&model.Attachment{}).Error
+	if err != nil {
+		return err
+	}
+
+	// Touch the tasks blocked by the deleted task, their blocked status may change
+	blocked := d.DB.Model(&model.TaskDependency{}).Select("Task_ID").Where("Blocked_By_ID = ?", task.ID)
+	err = d.DB.Model(&model.Task{}).Where("ID IN (?)", blocked).Update("Updated_At", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	err = d.DB.Unscoped().Where("Task_ID = ? OR Blocked_By_ID = ?", task.ID, task.ID).Delete(&model.TaskDependency{}).Error
 	return err
 }
 
@@ -135,15 +303,111 @@ func (d *Database) UpdateTask(task model.Task) error {
 	return err
 }
 
+// Get a calendar feed token
+func (d *Database) GetFeedToken(token string) model.FeedToken {
+	feedToken := model.FeedToken{}
+	err := d.DB.Find(&feedToken, "Token = ?", token).Error
+
+	if err != nil {
+		return model.FeedToken{}
+	}
+
+	return feedToken
+}
+
+// Returns an array of all calendar feed tokens
+func (d *Database) GetAllFeedTokens() []model.FeedToken {
+	feedTokens := []model.FeedToken{}
+
+	d.DB.Find(&feedTokens)
+
+	return feedTokens
+}
+
+// Create a calendar feed token
+func (d *Database) PostFeedToken(feedToken model.FeedToken) error {
+	err := d.DB.Create(&feedToken).Error
+	return err
+}
+
+// Deletes a calendar feed token
+func (d *Database) DeleteFeedToken(feedToken model.FeedToken) error {
+	err := d.DB.Unscoped().Where("Token = ?", feedToken.Token).Delete(&feedToken).Error
+	return err
+}
+
+// Returns the workflow states of a project ordered by position
+func (d *Database) GetWorkflowStates(projectID uint) []model.WorkflowState {
+	states := []model.WorkflowState{}
+
+	d.DB.Order("Position").Find(&states, "Project_ID = ?", projectID)
+
+	return states
+}
+
+// Deletes the workflow states of a project and creates the new ones in their order
+func (d *Database) SetWorkflowStates(projectID uint, states []model.WorkflowState) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("Project_ID = ?", projectID).Delete(&model.WorkflowState{}).Error
+		if err != nil {
+			return err
+		}
+
+		for i, state := range states {
+			state.Model = gorm.Model{}
+			state.ProjectID = projectID
+			state.Position = i
+			if err := tx.Create(&state).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Deletes all records of all tables
+func (d *Database) DeleteAll() error {
+	for _, table := range []interface{}{&model.Task{}, &model.Project{}, &model.FeedToken{}, &model.WorkflowState{}, &model.TimeEntry{}, &model.TaskDependency{}, &model.Comment{}, &model.Attachment{}} {
+		err := d.DB.Unscoped().Where("1 = 1").Delete(table).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Queries taking longer are logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
 // creates database struct and runs automigrate
 func NewDatabaseConnection(name string) *Database {
-	db, err := gorm.Open(sqlite.Open(name), &gorm.Config{})
+	db, err := gorm.Open(logging.PlaceholderDialector(&sqlite.Dialector{DSN: name}), &gorm.Config{
+		Logger: logging.NewGormLogger(slowQueryThreshold),
+	})
 
 	if err != nil {
 		log.Fatalf("Can not open Database %s", err)
 	}
 
 	db = model.DbMigrate(db)
+	rankTasks(db)
+	fullText := setupSearchIndex(db)
 
-	return &Database{DB: db}
+	return &Database{DB: db, fullText: fullText}
+}
+
+// Ranks the tasks of databases created before tasks had ranks,
+// keeping the order of their IDs
+func rankTasks(db *gorm.DB) {
+	var projectIDs []uint
+	db.Model(&model.Task{}).Where("Rank = ?", "").Distinct().Pluck("Project_ID", &projectIDs)
+
+	for _, projectID := range projectIDs {
+		tasks := []model.Task{}
+		db.Order("ID").Find(&tasks, "Project_ID = ?", projectID)
+
+		for i, rank := range rank.Sequence(len(tasks)) {
+			db.Model(&tasks[i]).UpdateColumn("Rank", rank)
+		}
+	}
 }
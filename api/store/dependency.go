@@ -0,0 +1,70 @@
+package store
+
+import (
+	model "github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Gets a dependency by ID
+func (d *Database) GetDependency(id uint) model.TaskDependency {
+	dependency := model.TaskDependency{}
+	err := d.DB.Find(&dependency, id).Error
+
+	if err != nil {
+		return model.TaskDependency{}
+	}
+
+	return dependency
+}
+
+// Returns all dependencies between tasks
+func (d *Database) GetAllDependencies() []model.TaskDependency {
+	dependencies := []model.TaskDependency{}
+
+	d.DB.Order("ID").Find(&dependencies)
+
+	return dependencies
+}
+
+// Returns the dependencies of the tasks with taskIDs
+// and the dependencies on the tasks with blockedByIDs
+func (d *Database) GetDependencies(taskIDs, blockedByIDs []uint) []model.TaskDependency {
+	dependencies := []model.TaskDependency{}
+	if len(taskIDs) == 0 && len(blockedByIDs) == 0 {
+		return dependencies
+	}
+
+	// IN () with an empty list is invalid, no ID is 0
+	if len(taskIDs) == 0 {
+		taskIDs = []uint{0}
+	}
+	if len(blockedByIDs) == 0 {
+		blockedByIDs = []uint{0}
+	}
+	d.DB.Order("ID").Find(&dependencies, "Task_ID IN ? OR Blocked_By_ID IN ?", taskIDs, blockedByIDs)
+
+	return dependencies
+}
+
+// Creates a dependency
+func (d *Database) PostDependency(dependency model.TaskDependency) error {
+	err := d.DB.Create(&dependency).Error
+	return err
+}
+
+// Deletes a dependency
+func (d *Database) DeleteDependency(dependency model.TaskDependency) error {
+	err := d.DB.Unscoped().Delete(&model.TaskDependency{}, dependency.ID).Error
+	return err
+}
+
+// Returns the tasks with the given IDs, possibly of different projects
+func (d *Database) GetTasksByID(ids []uint) []model.Task {
+	tasks := []model.Task{}
+	if len(ids) == 0 {
+		return tasks
+	}
+
+	d.DB.Order("ID").Find(&tasks, "ID IN ?", ids)
+
+	return tasks
+}
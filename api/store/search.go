@@ -0,0 +1,370 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	model "github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Markers used to highlight matches in search results
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+// Filters for a full-text search
+type SearchQuery struct {
+	Text      string
+	Project   string
+	Type      string
+	Done      *bool
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Limit     int
+}
+
+// Statements creating the FTS5 index and the triggers
+// which keep it in sync with the projects and tasks tables
+var searchIndexStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+		name, notes, kind UNINDEXED, ref_id UNINDEXED, project_id UNINDEXED,
+		tokenize = 'unicode61', prefix = '2 3')`,
+
+	`CREATE TRIGGER IF NOT EXISTS search_projects_insert AFTER INSERT ON projects BEGIN
+		INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT new.name, '', 'project', new.id, new.id WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS search_projects_update AFTER UPDATE ON projects BEGIN
+		DELETE FROM search_index WHERE kind = 'project' AND ref_id = old.id;
+		INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT new.name, '', 'project', new.id, new.id WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS search_projects_delete AFTER DELETE ON projects BEGIN
+		DELETE FROM search_index WHERE kind = 'project' AND ref_id = old.id;
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS search_tasks_insert AFTER INSERT ON tasks BEGIN
+		INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT new.name, new.notes, 'task', new.id, new.project_id WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS search_tasks_update AFTER UPDATE ON tasks BEGIN
+		DELETE FROM search_index WHERE kind = 'task' AND ref_id = old.id;
+		INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT new.name, new.notes, 'task', new.id, new.project_id WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS search_tasks_delete AFTER DELETE ON tasks BEGIN
+		DELETE FROM search_index WHERE kind = 'task' AND ref_id = old.id;
+	END`,
+
+	// Rebuild the index in case rows were written without the triggers
+	`DELETE FROM search_index`,
+	`INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT name, '', 'project', id, id FROM projects WHERE deleted_at IS NULL`,
+	`INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT name, notes, 'task', id, project_id FROM tasks WHERE deleted_at IS NULL`,
+}
+
+// Triggers of searchIndexStatements. They are dropped if FTS5 is not
+// available, otherwise they break all writes to databases created by
+// a build with FTS5
+var searchIndexTriggers = []string{
+	"search_projects_insert", "search_projects_update", "search_projects_delete",
+	"search_tasks_insert", "search_tasks_update", "search_tasks_delete",
+}
+
+// Creates the full-text index.
+// FTS5 is only available if go-sqlite3 was built with the
+// sqlite_fts5 tag. Returns false if the index can not be used.
+// The index itself is kept, its virtual table can't be dropped
+// without FTS5. It is rebuilt once FTS5 is available again
+func setupSearchIndex(db *gorm.DB) bool {
+	// A missing FTS5 module is reported below, not by gorm
+	silent := db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	err := silent.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range searchIndexStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		logging.Default().Warn("full-text index not available, falling back to LIKE search", "error", err)
+		for _, trigger := range searchIndexTriggers {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + trigger).Error; err != nil {
+				logging.Default().Error("dropping trigger of full-text index failed", "trigger", trigger, "error", err)
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Search projects and tasks
+func (d *Database) Search(query SearchQuery) ([]model.SearchResult, error) {
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return []model.SearchResult{}, nil
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+
+	if d.fullText {
+		return d.fullTextSearch(query, terms)
+	}
+	return d.likeSearch(query, terms)
+}
+
+// A row of the full-text search query
+type searchRow struct {
+	Kind        string
+	RefID       uint
+	ProjectName string
+	Name        string
+	Highlight   string
+	Snippet     string
+	Score       float64
+	Done        *bool
+	Deadline    *time.Time
+}
+
+// Search using the FTS5 index ranked by bm25.
+// Matches in names weigh more than matches in notes
+func (d *Database) fullTextSearch(query SearchQuery, terms []string) ([]model.SearchResult, error) {
+	// Every term is matched as a prefix
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = fmt.Sprintf("\"%s\"*", strings.ReplaceAll(term, "\"", "\"\""))
+	}
+
+	sql := d.DB.Table("search_index").
+		Select(`search_index.kind AS kind, search_index.ref_id AS ref_id,
+			projects.name AS project_name, search_index.name AS name,
+			highlight(search_index, 0, ?, ?) AS highlight,
+			snippet(search_index, 1, ?, ?, '…', 12) AS snippet,
+			-bm25(search_index, 10.0, 1.0) AS score,
+			tasks.done AS done, tasks.deadline AS deadline`,
+			highlightStart, highlightEnd, highlightStart, highlightEnd).
+		Joins("JOIN projects ON projects.id = search_index.project_id").
+		Joins("LEFT JOIN tasks ON search_index.kind = 'task' AND tasks.id = search_index.ref_id").
+		Where("search_index MATCH ?", strings.Join(match, " "))
+
+	sql = applySearchFilters(sql, query, "search_index.kind")
+
+	rows := []searchRow{}
+	err := sql.Order("score DESC").Limit(query.Limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = model.SearchResult{
+			Type:      row.Kind,
+			ID:        row.RefID,
+			Project:   row.ProjectName,
+			Name:      row.Name,
+			Highlight: row.Highlight,
+			Snippet:   row.Snippet,
+			Score:     row.Score,
+			Done:      row.Done,
+			Deadline:  row.Deadline,
+		}
+	}
+	return results, nil
+}
+
+// Search with LIKE if FTS5 is not available.
+// Ranking, highlights and snippets are computed in Go
+func (d *Database) likeSearch(query SearchQuery, terms []string) ([]model.SearchResult, error) {
+	results := []model.SearchResult{}
+
+	if query.Type == "" || query.Type == "project" {
+		projects := []model.Project{}
+		sql := d.DB.Model(&model.Project{})
+		for _, term := range terms {
+			sql = sql.Where("projects.name LIKE ?", "%"+term+"%")
+		}
+		if query.Project != "" {
+			sql = sql.Where("projects.name = ?", query.Project)
+		}
+		// Projects have neither a done state nor a deadline
+		if query.Done == nil && query.DueBefore == nil && query.DueAfter == nil {
+			if err := sql.Find(&projects).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		for _, project := range projects {
+			results = append(results, model.SearchResult{
+				Type:      "project",
+				ID:        project.ID,
+				Project:   project.Name,
+				Name:      project.Name,
+				Highlight: highlightTerms(project.Name, terms),
+				Score:     scoreMatch(project.Name, "", terms),
+			})
+		}
+	}
+
+	if query.Type == "" || query.Type == "task" {
+		rows := []searchRow{}
+		sql := d.DB.Table("tasks").
+			Select(`'task' AS kind, tasks.id AS ref_id, projects.name AS project_name,
+				tasks.name AS name, tasks.notes AS snippet, tasks.done AS done, tasks.deadline AS deadline`).
+			Joins("JOIN projects ON projects.id = tasks.project_id").
+			Where("tasks.deleted_at IS NULL")
+		for _, term := range terms {
+			sql = sql.Where("(tasks.name LIKE ? OR tasks.notes LIKE ?)", "%"+term+"%", "%"+term+"%")
+		}
+		sql = applySearchFilters(sql, query, "'task'")
+
+		if err := sql.Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			results = append(results, model.SearchResult{
+				Type:      "task",
+				ID:        row.RefID,
+				Project:   row.ProjectName,
+				Name:      row.Name,
+				Highlight: highlightTerms(row.Name, terms),
+				Snippet:   makeSnippet(row.Snippet, terms),
+				Score:     scoreMatch(row.Name, row.Snippet, terms),
+				Done:      row.Done,
+				Deadline:  row.Deadline,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// Adds the project, type, done and deadline filters to a search query.
+// kind is the SQL expression holding the type of a row
+func applySearchFilters(sql *gorm.DB, query SearchQuery, kind string) *gorm.DB {
+	if query.Project != "" {
+		sql = sql.Where("projects.name = ?", query.Project)
+	}
+	if query.Type != "" {
+		sql = sql.Where(kind+" = ?", query.Type)
+	}
+
+	// The remaining filters only apply to tasks
+	if query.Done != nil {
+		sql = sql.Where(kind+" = 'task' AND tasks.done = ?", *query.Done)
+	}
+	if query.DueBefore != nil {
+		sql = sql.Where(kind+" = 'task' AND tasks.deadline < ?", query.DueBefore.UTC())
+	}
+	if query.DueAfter != nil {
+		sql = sql.Where(kind+" = 'task' AND tasks.deadline > ?", query.DueAfter.UTC())
+	}
+	return sql
+}
+
+// Splits the search text into lower case words
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Counts the occurrences of all terms. Matches in names weigh more
+func scoreMatch(name, notes string, terms []string) float64 {
+	name = strings.ToLower(name)
+	notes = strings.ToLower(notes)
+
+	score := 0.0
+	for _, term := range terms {
+		score += 10*float64(strings.Count(name, term)) + float64(strings.Count(notes, term))
+	}
+	return score
+}
+
+// Wraps every occurrence of the terms in highlight markers
+func highlightTerms(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	// Lower casing may change the length of some runes
+	if len(lower) != len(text) {
+		return text
+	}
+	marked := make([]bool, len(text))
+
+	for _, term := range terms {
+		for start := 0; ; {
+			index := strings.Index(lower[start:], term)
+			if index < 0 {
+				break
+			}
+			for i := start + index; i < start+index+len(term); i++ {
+				marked[i] = true
+			}
+			start += index + len(term)
+		}
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			builder.WriteString(highlightStart)
+		}
+		builder.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			builder.WriteString(highlightEnd)
+		}
+	}
+	return builder.String()
+}
+
+// Returns a highlighted excerpt of the text around the first match
+func makeSnippet(text string, terms []string) string {
+	const contextWords = 6
+
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		for _, term := range terms {
+			if strings.Contains(strings.ToLower(word), term) {
+				first = i
+				break
+			}
+		}
+		if first >= 0 {
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start, end := first-contextWords, first+contextWords+1
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(words) {
+		end, suffix = len(words), ""
+	}
+
+	return prefix + highlightTerms(strings.Join(words[start:end], " "), terms) + suffix
+}
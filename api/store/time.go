@@ -0,0 +1,106 @@
+package store
+
+import (
+	"time"
+
+	model "github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Filters for time entries. Empty fields match all entries
+type TimeEntryQuery struct {
+	TaskIDs []uint
+	User    string
+	// Entries overlapping the range from From to To
+	From *time.Time
+	To   *time.Time
+}
+
+// Checks if the entry matches the query
+func (q TimeEntryQuery) Matches(entry model.TimeEntry) bool {
+	if q.TaskIDs != nil {
+		found := false
+		for _, id := range q.TaskIDs {
+			found = found || id == entry.TaskID
+		}
+		if !found {
+			return false
+		}
+	}
+
+	switch {
+	case q.User != "" && entry.User != q.User:
+		return false
+	case q.To != nil && !entry.Start.Before(*q.To):
+		return false
+	case q.From != nil && entry.End != nil && !entry.End.After(*q.From):
+		return false
+	}
+	return true
+}
+
+// Gets a time entry by ID
+func (d *Database) GetTimeEntry(id uint) model.TimeEntry {
+	entry := model.TimeEntry{}
+	err := d.DB.Find(&entry, id).Error
+
+	if err != nil {
+		return model.TimeEntry{}
+	}
+
+	return entry
+}
+
+// Gets the running time entry of a user
+func (d *Database) GetRunningTimeEntry(user string) model.TimeEntry {
+	entry := model.TimeEntry{}
+	err := d.DB.Find(&entry, "User = ? AND Ended_At IS NULL", user).Error
+
+	if err != nil {
+		return model.TimeEntry{}
+	}
+
+	return entry
+}
+
+// Returns the time entries matching the query ordered by start
+func (d *Database) GetTimeEntries(query TimeEntryQuery) []model.TimeEntry {
+	entries := []model.TimeEntry{}
+
+	db := d.DB.Order("Started_At, ID")
+	if query.TaskIDs != nil {
+		if len(query.TaskIDs) == 0 {
+			return entries
+		}
+		db = db.Where("Task_ID IN ?", query.TaskIDs)
+	}
+	if query.User != "" {
+		db = db.Where("User = ?", query.User)
+	}
+	if query.To != nil {
+		db = db.Where("Started_At < ?", *query.To)
+	}
+	if query.From != nil {
+		db = db.Where("(Ended_At IS NULL OR Ended_At > ?)", *query.From)
+	}
+	db.Find(&entries)
+
+	return entries
+}
+
+// Creates a time entry and returns it with its ID and timestamps
+func (d *Database) PostTimeEntry(entry model.TimeEntry) (model.TimeEntry, error) {
+	err := d.DB.Create(&entry).Error
+	return entry, err
+}
+
+// Updates a time entry
+func (d *Database) UpdateTimeEntry(entry model.TimeEntry) error {
+	err := d.DB.Save(&entry).Error
+	return err
+}
+
+// Deletes a time entry
+func (d *Database) DeleteTimeEntry(entry model.TimeEntry) error {
+	err := d.DB.Unscoped().Delete(&entry).Error
+	return err
+}
@@ -0,0 +1,123 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/agenda"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseAgenda(t *testing.T, body []byte) agenda.Agenda {
+	t.Helper()
+	result := agenda.Agenda{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("Error parsing agenda: %s", err)
+	}
+	return result
+}
+
+// Returns the names of the tasks of an agenda by day and project
+func agendaNames(result agenda.Agenda) map[string]map[string][]string {
+	names := map[string]map[string][]string{}
+	for _, day := range result.Days {
+		names[day.Date] = map[string][]string{}
+		for _, project := range day.Projects {
+			for _, task := range project.Tasks {
+				names[day.Date][project.Project] = append(names[day.Date][project.Project], task.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Tests routes GET /agenda/today, /agenda/overdue and /agenda/upcoming
+func TestAgenda(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	for _, name := range []string{"homework", "cleaning", "old"} {
+		db.PostProject(name)
+	}
+	old := db.GetProject("old")
+	old.ArchiveProject()
+	db.UpdateProject(old)
+
+	// Noon in UTC keeps the tasks on the same day in the tested time zones
+	now := time.Now().UTC()
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC)
+	date := func(days int) string { return noon.AddDate(0, 0, days).Format(agenda.DateLayout) }
+
+	tasks := []struct {
+		project, name string
+		days          int
+		done          bool
+	}{
+		{"homework", "math", -3, false},
+		{"homework", "biology", -3, true},
+		{"cleaning", "kitchen", 0, false},
+		{"homework", "history", 0, false},
+		{"homework", "art", 1, false},
+		{"cleaning", "windows", 6, false},
+		{"cleaning", "garage", 9, false},
+		{"old", "essay", 0, false},
+	}
+	for _, task := range tasks {
+		deadline := noon.AddDate(0, 0, task.days)
+		db.PostTask(model.Task{Name: task.name, Priority: "1", Deadline: &deadline, Done: task.done, ProjectID: db.GetProject(task.project).ID})
+	}
+
+	t.Run("Today", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/agenda/today", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		result := parseAgenda(t, w.Body.Bytes())
+		assert.Equal(t, 2, result.Count)
+		assert.Equal(t, "UTC", result.Timezone)
+		assert.Equal(t, map[string]map[string][]string{
+			date(0): {"cleaning": {"kitchen"}, "homework": {"history"}},
+		}, agendaNames(result))
+		assert.Equal(t, "cleaning", result.Days[0].Projects[0].Project)
+	})
+
+	t.Run("Overdue", func(t *testing.T) {
+		result := parseAgenda(t, doJSONRequest(t, server, "GET", "/agenda/overdue", nil).Body.Bytes())
+		assert.Equal(t, []string{"math"}, agendaNames(result)[date(-3)]["homework"])
+		assert.Nil(t, result.From)
+	})
+
+	t.Run("Upcoming", func(t *testing.T) {
+		result := parseAgenda(t, doJSONRequest(t, server, "GET", "/agenda/upcoming", nil).Body.Bytes())
+		assert.Equal(t, map[string]map[string][]string{
+			date(1): {"homework": {"art"}},
+			date(6): {"cleaning": {"windows"}},
+		}, agendaNames(result))
+
+		result = parseAgenda(t, doJSONRequest(t, server, "GET", "/agenda/upcoming?days=10", nil).Body.Bytes())
+		assert.Equal(t, 3, result.Count)
+		assert.Equal(t, date(1), result.Days[0].Date)
+	})
+
+	t.Run("Days start in the time zone of the caller", func(t *testing.T) {
+		result := parseAgenda(t, doJSONRequest(t, server, "GET", "/agenda/upcoming?days=1&tz=Pacific/Kiritimati", nil).Body.Bytes())
+		assert.Equal(t, "Pacific/Kiritimati", result.Timezone)
+
+		// At UTC+14 the deadline at noon UTC is at 2 am the next day
+		location, _ := time.LoadLocation("Pacific/Kiritimati")
+		for _, day := range result.Days {
+			for _, project := range day.Projects {
+				for _, task := range project.Tasks {
+					assert.Equal(t, day.Date, task.Deadline.In(location).Format(agenda.DateLayout))
+				}
+			}
+		}
+	})
+
+	t.Run("Invalid parameters return http.StatusBadRequest", func(t *testing.T) {
+		for _, url := range []string{"/agenda/today?tz=Mars/Olympus", "/agenda/upcoming?days=0", "/agenda/upcoming?days=week"} {
+			w := doJSONRequest(t, server, "GET", url, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		}
+	})
+}
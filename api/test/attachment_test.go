@@ -0,0 +1,279 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/attachment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var pngContent = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+
+// Creates a TodoServer with attachments kept in a temporary directory
+func setupAttachmentServer(t *testing.T, options ...api.Option) (*api.TodoServer, *store.Database, blob.Store) {
+	t.Helper()
+	db := store.NewDatabaseConnection(filepath.Join(t.TempDir(), testdbfile))
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating blob store: %s", err)
+	}
+
+	options = append([]api.Option{api.WithBlobStore(blobs)}, options...)
+	return api.NewTodoServer(attachment.NewStore(db, blobs), options...), db, blobs
+}
+
+// Uploads a file with an optional checksum as multipart form
+func doUploadRequest(t *testing.T, server *api.TodoServer, url, name string, content []byte, checksum string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	file, _ := form.CreateFormFile("file", name)
+	file.Write(content)
+	if checksum != "" {
+		form.WriteField("sha256", checksum)
+	}
+	form.Close()
+
+	req, _ := http.NewRequest("POST", url, body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func parseAttachment(t *testing.T, body []byte) model.Attachment {
+	t.Helper()
+	attachment := model.Attachment{}
+	if err := json.Unmarshal(body, &attachment); err != nil {
+		t.Fatalf("Error parsing attachment: %s", err)
+	}
+	return attachment
+}
+
+// Tests routes /projects/:projectName/tasks/:taskName/attachments
+func TestAttachments(t *testing.T) {
+	server, db, blobs := setupAttachmentServer(t)
+	db.PostProject("homework")
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: db.GetProject("homework").ID})
+
+	const url = "/projects/homework/tasks/math/attachments"
+	sum := sha256.Sum256(pngContent)
+	checksum := hex.EncodeToString(sum[:])
+
+	var uploaded model.Attachment
+	t.Run("Upload attachments", func(t *testing.T) {
+		w := doUploadRequest(t, server, url, "screenshot.png", pngContent, checksum)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		uploaded = parseAttachment(t, w.Body.Bytes())
+		assert.NotZero(t, uploaded.ID)
+		assert.Equal(t, "screenshot.png", uploaded.Name)
+		assert.Equal(t, "image/png", uploaded.ContentType)
+		assert.Equal(t, int64(len(pngContent)), uploaded.Size)
+		assert.Equal(t, checksum, uploaded.SHA256)
+
+		w = doUploadRequest(t, server, url, "../notes.txt", []byte("page 42"), "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "notes.txt", parseAttachment(t, w.Body.Bytes()).Name)
+
+		attachments := []model.Attachment{}
+		json.Unmarshal(doJSONRequest(t, server, "GET", url, nil).Body.Bytes(), &attachments)
+		assert.Len(t, attachments, 2)
+	})
+
+	t.Run("Download an attachment", func(t *testing.T) {
+		attachmentURL := fmt.Sprintf("%s/%d", url, uploaded.ID)
+		w := doJSONRequest(t, server, "GET", attachmentURL, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, pngContent, w.Body.Bytes())
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=screenshot.png", w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "\""+checksum+"\"", w.Header().Get("ETag"))
+		assert.Contains(t, w.Header().Get("Digest"), "sha-256=")
+
+		req, _ := http.NewRequest("GET", attachmentURL, nil)
+		req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		w = httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("Wrong checksums return http.StatusBadRequest", func(t *testing.T) {
+		for _, wrong := range []string{hex.EncodeToString(make([]byte, 32)), "abc", "no checksum"} {
+			w := doUploadRequest(t, server, url, "screenshot.png", pngContent, wrong)
+			assert.Equal(t, http.StatusBadRequest, w.Code, wrong)
+		}
+	})
+
+	t.Run("Disallowed content types return http.StatusUnsupportedMediaType", func(t *testing.T) {
+		w := doUploadRequest(t, server, url, "page.html", []byte("<html><script>alert(1)</script></html>"), "")
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("Attachments of other tasks are not found", func(t *testing.T) {
+		db.PostTask(model.Task{Name: "biology", Priority: "1", ProjectID: db.GetProject("homework").ID})
+		w := doJSONRequest(t, server, "GET", fmt.Sprintf("/projects/homework/tasks/biology/attachments/%d", uploaded.ID), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Delete an attachment and its content", func(t *testing.T) {
+		stored := db.GetAttachment(uploaded.ID)
+		w := doJSONRequest(t, server, "DELETE", fmt.Sprintf("%s/%d", url, uploaded.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSONRequest(t, server, "GET", fmt.Sprintf("%s/%d", url, uploaded.ID), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		_, err := blobs.Get(stored.BlobKey)
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	})
+
+	t.Run("Deleting a task deletes its attachments", func(t *testing.T) {
+		task := db.GetTask("homework", "math")
+		stored := db.GetAttachments(task.ID)
+		assert.Len(t, stored, 1)
+
+		w := doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/math", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Empty(t, db.GetAttachments(task.ID))
+		_, err := blobs.Get(stored[0].BlobKey)
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	})
+
+	t.Run("Deleting a project deletes its tasks and attachments", func(t *testing.T) {
+		db.PostTask(model.Task{Name: "physics", Priority: "1", ProjectID: db.GetProject("homework").ID})
+		db.SetWorkflowStates(db.GetProject("homework").ID, []model.WorkflowState{{Name: "todo", Category: "todo"}, {Name: "done", Category: "done"}})
+		w := doUploadRequest(t, server, "/projects/homework/tasks/physics/attachments", "screenshot.png", pngContent, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		stored := db.GetAttachment(parseAttachment(t, w.Body.Bytes()).ID)
+		projectID := db.GetProject("homework").ID
+
+		w = doJSONRequest(t, server, "DELETE", "/projects/homework", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Empty(t, db.GetTasksOfProjects([]uint{projectID}))
+		assert.Empty(t, db.GetAllAttachments())
+		assert.Empty(t, db.GetWorkflowStates(projectID))
+		_, err := blobs.Get(stored.BlobKey)
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	})
+}
+
+// Tests that backups keep the attachments of restored tasks
+func TestAttachmentBackup(t *testing.T) {
+	server, db, blobs := setupAttachmentServer(t, api.WithAdminToken(adminToken))
+	db.PostProject("homework")
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: db.GetProject("homework").ID})
+
+	const url = "/projects/homework/tasks/math/attachments"
+	doUploadRequest(t, server, url, "screenshot.png", pngContent, "")
+	dump := doAdminRequest(t, server, "GET", "/admin/backup", "").Body.String()
+	kept := db.GetAttachments(db.GetTask("homework", "math").ID)[0]
+
+	doUploadRequest(t, server, url, "notes.txt", []byte("page 42"), "")
+	added := db.GetAttachments(db.GetTask("homework", "math").ID)[1]
+
+	t.Run("Merge keeps attachments once", func(t *testing.T) {
+		w := doAdminRequest(t, server, "POST", "/admin/restore?mode=merge", dump)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"attachments":0`)
+		assert.Len(t, db.GetAllAttachments(), 2)
+	})
+
+	t.Run("Replace keeps the contents of restored attachments", func(t *testing.T) {
+		w := doAdminRequest(t, server, "POST", "/admin/restore?mode=replace", dump)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"attachments":1`)
+
+		attachments := db.GetAttachments(db.GetTask("homework", "math").ID)
+		if assert.Len(t, attachments, 1) {
+			assert.Equal(t, "screenshot.png", attachments[0].Name)
+			assert.Equal(t, kept.BlobKey, attachments[0].BlobKey)
+
+			w = doJSONRequest(t, server, "GET", fmt.Sprintf("%s/%d", url, attachments[0].ID), nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, pngContent, w.Body.Bytes())
+		}
+
+		// Attachments added after the backup are gone with their contents
+		_, err := blobs.Get(added.BlobKey)
+		assert.ErrorIs(t, err, blob.ErrNotFound)
+	})
+}
+
+// Tests the size limit of attachments
+func TestAttachmentLimits(t *testing.T) {
+	server, db, _ := setupAttachmentServer(t, api.WithAttachmentLimits(attachment.Limits{
+		MaxSize:      64,
+		ContentTypes: []string{"text/plain"},
+	}))
+	db.PostProject("homework")
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: db.GetProject("homework").ID})
+
+	const url = "/projects/homework/tasks/math/attachments"
+	w := doUploadRequest(t, server, url, "notes.txt", bytes.Repeat([]byte("a"), 64), "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doUploadRequest(t, server, url, "notes.txt", bytes.Repeat([]byte("a"), 65), "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	t.Run("Content is limited without a multipart header size", func(t *testing.T) {
+		blobs, _ := blob.NewFileStore(t.TempDir())
+		task := db.GetTask("homework", "math")
+		limits := attachment.Limits{MaxSize: 64, ContentTypes: []string{"text/plain"}}
+
+		_, err := attachment.Add(db, blobs, limits, task, attachment.Upload{Name: "notes.txt", Content: bytes.NewReader(bytes.Repeat([]byte("a"), 65))})
+		assert.ErrorIs(t, err, attachment.ErrTooLarge)
+		assert.Len(t, db.GetAttachments(task.ID), 1)
+	})
+
+	t.Run("Routes are disabled without a blob store", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t)
+		w := doJSONRequest(t, server, "GET", url, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// Tests that a FileStore replaces content and rejects keys leaving its directory
+func TestFileStore(t *testing.T) {
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating blob store: %s", err)
+	}
+
+	blobs.Put("key", bytes.NewReader([]byte("old")))
+	written, err := blobs.Put("key", bytes.NewReader([]byte("new")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), written)
+
+	content, err := blobs.Get("key")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(content)
+	content.Close()
+	assert.Equal(t, "new", string(data))
+
+	assert.NoError(t, blobs.Delete("key"))
+	assert.NoError(t, blobs.Delete("key"))
+	_, err = blobs.Get("key")
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	_, err = blobs.Put("../key", bytes.NewReader([]byte("escaped")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/backup"
+	"github.com/stretchr/testify/assert"
+)
+
+const adminToken = "secret"
+
+// Sends a request with the admin token
+func doAdminRequest(t *testing.T, server *api.TodoServer, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+// Tests for routes GET /admin/backup and POST /admin/restore
+func TestBackupAndRestore(t *testing.T) {
+	source, sourceDB := setupDatabaseServer(t, api.WithAdminToken(adminToken))
+
+	doJSONRequest(t, source, "POST", "/projects/", map[string]string{"name": "homework"})
+	doJSONRequest(t, source, "POST", "/projects/", map[string]string{"name": "cleaning"})
+	doJSONRequest(t, source, "PUT", "/projects/cleaning/archive", nil)
+	req, _ := http.NewRequest("POST", "/projects/homework/tasks.md",
+		strings.NewReader("- [x] math (priority: 1, due: 2021-06-10)\n  - [ ] exercise 1\n"))
+	source.Router.ServeHTTP(httptest.NewRecorder(), req)
+	doJSONRequest(t, source, "POST", "/projects/homework/calendar/tokens", nil)
+	doJSONRequest(t, source, "POST", "/projects/homework/tasks/math/dependencies", map[string]string{"name": "exercise 1"})
+	doJSONRequest(t, source, "POST", "/projects/homework/tasks/math/comments", map[string]string{"body": "chapter 4"})
+
+	w := doAdminRequest(t, source, "GET", "/admin/backup", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	dump := w.Body.String()
+
+	t.Run("Backup contains all data", func(t *testing.T) {
+		parsed := backup.Dump{}
+		json.Unmarshal([]byte(dump), &parsed)
+
+		assert.Equal(t, backup.Format, parsed.Format)
+		assert.Equal(t, backup.SchemaVersion, parsed.SchemaVersion)
+		assert.Len(t, parsed.Projects, 2)
+		assert.Len(t, parsed.Tasks, 2)
+		assert.Len(t, parsed.FeedTokens, 1)
+		assert.Len(t, parsed.Dependencies, 1)
+		assert.Len(t, parsed.Comments, 1)
+	})
+
+	t.Run("Restore into an empty database remaps IDs", func(t *testing.T) {
+		target, targetDB := setupDatabaseServer(t, api.WithAdminToken(adminToken))
+		// Occupy the IDs of the source database
+		targetDB.PostProject("placeholder")
+		targetDB.DeleteProject("placeholder")
+		targetDB.PostProject("garden")
+
+		w := doAdminRequest(t, target, "POST", "/admin/restore?mode=merge", dump)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := backup.RestoreReport{}
+		json.Unmarshal(w.Body.Bytes(), &report)
+		assert.Equal(t, backup.RestoreReport{Mode: "merge", Projects: 2, Tasks: 2, FeedTokens: 1, Dependencies: 1, Comments: 1}, report)
+
+		homework := targetDB.GetProject("homework")
+		math := targetDB.GetTask("homework", "math")
+		exercise := targetDB.GetTask("homework", "exercise 1")
+		assert.NotEqual(t, sourceDB.GetProject("homework").ID, homework.ID)
+		assert.Equal(t, homework.ID, math.ProjectID)
+		assert.True(t, math.Done)
+		assert.Equal(t, "2021-06-10", math.Deadline.Format("2006-01-02"))
+		assert.Equal(t, math.ID, *exercise.ParentID)
+		assert.True(t, targetDB.GetProject("cleaning").Archived)
+		assert.Equal(t, "garden", targetDB.GetProject("garden").Name)
+
+		comments, _ := targetDB.GetComments(math.ID, 0, -1)
+		assert.Equal(t, "chapter 4", comments[0].Body)
+
+		dependencies := targetDB.GetAllDependencies()
+		assert.Len(t, dependencies, 1)
+		assert.Equal(t, math.ID, dependencies[0].TaskID)
+		assert.Equal(t, exercise.ID, dependencies[0].BlockedByID)
+
+		token := targetDB.GetAllFeedTokens()[0]
+		assert.Equal(t, homework.ID, *token.ProjectID)
+	})
+
+	t.Run("Merge updates existing tasks", func(t *testing.T) {
+		doJSONRequest(t, source, "DELETE", "/projects/homework/tasks/math/complete", nil)
+
+		w := doAdminRequest(t, source, "POST", "/admin/restore", dump)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, sourceDB.GetTask("homework", "math").Done)
+		assert.Len(t, sourceDB.GetAllProjects(), 2)
+		assert.Len(t, sourceDB.GetAllDependencies(), 1)
+		_, comments := sourceDB.GetComments(sourceDB.GetTask("homework", "math").ID, 0, -1)
+		assert.Equal(t, 1, comments)
+	})
+
+	t.Run("Replace deletes existing data", func(t *testing.T) {
+		doJSONRequest(t, source, "POST", "/projects/", map[string]string{"name": "garden"})
+
+		w := doAdminRequest(t, source, "POST", "/admin/restore?mode=replace", dump)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, sourceDB.GetAllProjects(), 2)
+		assert.Equal(t, "", sourceDB.GetProject("garden").Name)
+	})
+
+	t.Run("Newer schema versions return http.StatusBadRequest", func(t *testing.T) {
+		newer := backup.SchemaVersion + 1
+		invalid := strings.Replace(dump, fmt.Sprintf(`"schema_version":%d`, backup.SchemaVersion), fmt.Sprintf(`"schema_version":%d`, newer), 1)
+
+		w := doAdminRequest(t, source, "POST", "/admin/restore", invalid)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("unsupported schema version %d", newer))
+	})
+
+	t.Run("Older schema versions are restored", func(t *testing.T) {
+		older := strings.Replace(dump, fmt.Sprintf(`"schema_version":%d`, backup.SchemaVersion), `"schema_version":1`, 1)
+
+		w := doAdminRequest(t, source, "POST", "/admin/restore", older)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Restores are not limited to the size of imports", func(t *testing.T) {
+		padded := strings.Repeat(" ", 11<<20) + dump
+
+		w := doAdminRequest(t, source, "POST", "/admin/restore", padded)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Backups over the restore limit return http.StatusBadRequest", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithAdminToken(adminToken), api.WithRestoreLimit(int64(len(dump)-1)))
+
+		w := doAdminRequest(t, server, "POST", "/admin/restore", dump)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "too large")
+	})
+
+	t.Run("Invalid mode returns http.StatusBadRequest", func(t *testing.T) {
+		w := doAdminRequest(t, source, "POST", "/admin/restore?mode=append", dump)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Wrong admin token returns http.StatusUnauthorized", func(t *testing.T) {
+		w := doJSONRequest(t, source, "GET", "/admin/backup", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Admin routes are disabled without admin token", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t)
+
+		w := doJSONRequest(t, server, "GET", "/admin/backup", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
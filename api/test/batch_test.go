@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+var batchTask = map[string]string{
+	"name":     "math",
+	"priority": "1",
+	"deadline": "2021-06-10 12:00:00 +0000 UTC",
+}
+
+func parseBatchResponse(t *testing.T, body []byte) handler.BatchResponse {
+	t.Helper()
+	response := handler.BatchResponse{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Error parsing batch response: %s", err)
+	}
+	return response
+}
+
+// Tests route POST /batch
+func TestBatch(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+
+	t.Run("Atomic batch", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{
+			"operations": []map[string]interface{}{
+				{"op": "create_project", "name": "homework"},
+				{"op": "create_task", "project": "homework", "task": batchTask},
+				{"op": "complete_task", "project": "homework", "name": "math"},
+				{"op": "create_project", "name": "cleaning"},
+				{"op": "archive_project", "name": "cleaning"},
+			},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		response := parseBatchResponse(t, w.Body.Bytes())
+		assert.True(t, response.Committed)
+		assert.Equal(t, handler.BatchModeAtomic, response.Mode)
+		assert.Len(t, response.Results, 5)
+		assert.Equal(t, handler.BatchResult{Index: 1, Op: "create_task", Status: http.StatusCreated, Message: "task created"}, response.Results[1])
+
+		assert.True(t, db.GetTask("homework", "math").Done)
+		assert.True(t, db.GetProject("cleaning").Archived)
+	})
+
+	t.Run("Failed atomic batch is rolled back", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{
+			"operations": []map[string]interface{}{
+				{"op": "reopen_task", "project": "homework", "name": "math"},
+				{"op": "create_project", "name": "garden"},
+				{"op": "delete_task", "project": "homework", "name": "biology"},
+				{"op": "delete_task", "project": "homework", "name": "math"},
+			},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		response := parseBatchResponse(t, w.Body.Bytes())
+		assert.False(t, response.Committed)
+		assert.Equal(t, []int{http.StatusOK, http.StatusCreated, http.StatusNotFound, http.StatusFailedDependency},
+			[]int{response.Results[0].Status, response.Results[1].Status, response.Results[2].Status, response.Results[3].Status})
+
+		assert.True(t, db.GetTask("homework", "math").Done)
+		assert.Empty(t, db.GetProject("garden").Name)
+	})
+
+	t.Run("Best effort batch", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{
+			"mode": "best_effort",
+			"operations": []map[string]interface{}{
+				{"op": "create_task", "project": "homework", "task": map[string]string{"name": "biology"}},
+				{"op": "update_task", "project": "homework", "name": "math", "task": map[string]string{
+					"name": "algebra", "priority": "2", "deadline": "2021-06-11 12:00:00 +0000 UTC", "recurrence": "FREQ=WEEKLY",
+				}},
+				{"op": "rename_project", "name": "homework"},
+			},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		response := parseBatchResponse(t, w.Body.Bytes())
+		assert.True(t, response.Committed)
+		assert.Equal(t, http.StatusBadRequest, response.Results[0].Status)
+		assert.Equal(t, http.StatusOK, response.Results[1].Status)
+		assert.Equal(t, "unknown operation rename_project", response.Results[2].Message)
+
+		assert.Equal(t, "FREQ=WEEKLY", db.GetTask("homework", "algebra").Recurrence)
+	})
+
+	t.Run("Invalid batches", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{"mode": "sometimes", "operations": []string{}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		operations := make([]map[string]string, 101)
+		w = doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{"operations": operations})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,182 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for ETag and Last-Modified handling of GET routes
+func TestConditionalGet(t *testing.T) {
+	server, store := setupProjectTests()
+
+	updatedAt := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	store.Projects[0].UpdatedAt = updatedAt
+
+	t.Run("GET sends ETag, Last-Modified and Cache-Control", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/homework", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.Equal(t, updatedAt.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+		assert.Equal(t, handler.DefaultCachePolicy.HeaderValue(), w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("If-None-Match with current ETag returns 304", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/homework", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		etag := w.Header().Get("ETag")
+
+		req, _ = http.NewRequest("GET", "/projects/homework", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("If-None-Match with stale ETag returns 200", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		etag := w.Header().Get("ETag")
+
+		store.Projects[2].Name = "university"
+
+		req, _ = http.NewRequest("GET", "/projects/", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("If-Modified-Since returns 304 when not modified", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/homework", nil)
+		req.Header.Set("If-Modified-Since", updatedAt.Add(time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("If-Modified-Since returns 200 when modified", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/homework", nil)
+		req.Header.Set("If-Modified-Since", updatedAt.Add(-time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Task lists are cacheable", func(t *testing.T) {
+		server, _ := setupTaskTests()
+
+		req, _ := http.NewRequest("GET", "/projects/homework/tasks", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		etag := w.Header().Get("ETag")
+
+		req, _ = http.NewRequest("GET", "/projects/homework/tasks", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Lists are only revalidated with their ETag", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t)
+		doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+		doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "garden"})
+
+		w := doJSONRequest(t, server, "GET", "/projects/", nil)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+
+		// Deleting the newest project does not make the list older
+		doJSONRequest(t, server, "DELETE", "/projects/garden", nil)
+		req, _ := http.NewRequest("GET", "/projects/", nil)
+		req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).Format(http.TimeFormat))
+		w = httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "garden")
+	})
+
+	t.Run("Reports, exports and documents are cacheable", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t)
+		doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+
+		routes := []string{
+			"/reports/time",
+			"/projects/homework/time",
+			"/tasks.csv",
+			"/projects/homework/tasks.csv",
+			"/graphql?query=" + url.QueryEscape("{ projects { name } }"),
+			"/openapi.json",
+		}
+		for _, route := range routes {
+			w := doJSONRequest(t, server, "GET", route, nil)
+			etag := w.Header().Get("ETag")
+			assert.NotEmpty(t, etag, route)
+
+			req, _ := http.NewRequest("GET", route, nil)
+			req.Header.Set("If-None-Match", etag)
+			w = httptest.NewRecorder()
+			server.Router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotModified, w.Code, route)
+		}
+	})
+}
+
+// Tests for route specific cache policies
+func TestCachePolicy(t *testing.T) {
+	_, store := setupProjectTests()
+	policy := handler.CachePolicy{MaxAge: time.Minute, Private: true, WeakETag: true}
+	server := api.NewTodoServer(store, api.WithCachePolicy("/projects/", policy))
+
+	t.Run("Route with own policy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+		assert.True(t, strings.HasPrefix(w.Header().Get("ETag"), "W/\""))
+	})
+
+	t.Run("Weak ETags match strong comparison values", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		etag := strings.TrimPrefix(w.Header().Get("ETag"), "W/")
+
+		req, _ = http.NewRequest("GET", "/projects/", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Other routes keep the default policy", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/projects/homework", nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, "no-cache, public, max-age=0", w.Header().Get("Cache-Control"))
+		assert.False(t, strings.HasPrefix(w.Header().Get("ETag"), "W/"))
+	})
+}
@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for the iCalendar feed routes
+func TestCalendarFeed(t *testing.T) {
+	server, _ := setupDatabaseServer(t)
+
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "cleaning"})
+	doJSONRequest(t, server, "POST", "/projects/homework/tasks", map[string]string{
+		"name":       "math, chapter 3",
+		"priority":   "high",
+		"deadline":   "2021-06-10 12:00:00 +0000 UTC",
+		"recurrence": "FREQ=WEEKLY;INTERVAL=1",
+	})
+	doJSONRequest(t, server, "POST", "/projects/cleaning/tasks", map[string]string{
+		"name":     "kitchen",
+		"priority": "9",
+		"deadline": "2021-06-12 08:30:00 +0000 UTC",
+	})
+	doJSONRequest(t, server, "PUT", "/projects/cleaning/tasks/kitchen/complete", nil)
+
+	postToken := func(t *testing.T, url string) (string, string) {
+		t.Helper()
+		w := doJSONRequest(t, server, "POST", url, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		response := map[string]string{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["token"], response["url"]
+	}
+
+	projectToken, projectURL := postToken(t, "/projects/homework/calendar/tokens")
+	_, globalURL := postToken(t, "/calendar/tokens")
+
+	t.Run("Project feed exports tasks as VTODO", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", projectURL, nil)
+		body := w.Body.String()
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"))
+		assert.Contains(t, body, "BEGIN:VTODO\r\n")
+		assert.Contains(t, body, "UID:task-1@go-todo-rest-api\r\n")
+		assert.Contains(t, body, "SUMMARY:math\\, chapter 3\r\n")
+		assert.Contains(t, body, "DUE:20210610T120000Z\r\n")
+		assert.Contains(t, body, "PRIORITY:1\r\n")
+		assert.Contains(t, body, "STATUS:NEEDS-ACTION\r\n")
+		assert.Contains(t, body, "RRULE:FREQ=WEEKLY;INTERVAL=1\r\n")
+		assert.NotContains(t, body, "kitchen")
+	})
+
+	t.Run("Global feed contains all projects", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", globalURL+"&component=vevent", nil)
+		body := w.Body.String()
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, body, "BEGIN:VEVENT\r\n")
+		assert.Contains(t, body, "DTSTART:20210612T083000Z\r\n")
+		assert.Contains(t, body, "SUMMARY:[done] kitchen\r\n")
+		assert.Contains(t, body, "PRIORITY:9\r\n")
+	})
+
+	t.Run("Project token does not grant access to other projects", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/cleaning/calendar.ics?token="+projectToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Feed URLs escape the project name", func(t *testing.T) {
+		doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "home office"})
+		_, url := postToken(t, "/projects/home office/calendar/tokens")
+		assert.True(t, strings.HasPrefix(url, "/projects/home%20office/calendar.ics?token="), url)
+
+		w := doJSONRequest(t, server, "GET", url, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Missing or wrong token returns http.StatusForbidden", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/calendar.ics", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doJSONRequest(t, server, "GET", "/calendar.ics?token=guessed", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Revoked token returns http.StatusForbidden", func(t *testing.T) {
+		w := doJSONRequest(t, server, "DELETE", "/calendar/tokens/"+projectToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSONRequest(t, server, "GET", projectURL, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Invalid recurrence rule returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/homework/tasks", map[string]string{
+			"name":       "biology",
+			"priority":   "1",
+			"deadline":   "2021-06-10 12:00:00 +0000 UTC",
+			"recurrence": "FREQ=SOMETIMES",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSONRequest(t, server, "POST", "/projects/homework/tasks", map[string]string{
+			"name":       "biology",
+			"priority":   "1",
+			"recurrence": "FREQ=DAILY;BYDAY=MO\r\nBEGIN:VALARM",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// Tests the validation of RRULE values
+func TestValidateRecurrence(t *testing.T) {
+	for _, rule := range []string{"", "FREQ=WEEKLY", "FREQ=MONTHLY;BYDAY=MO,-1FR;INTERVAL=2", "FREQ=YEARLY;BYMONTH=1,6;BYMONTHDAY=-1;WKST=SU", "freq=daily;until=20210610"} {
+		assert.NoError(t, ical.ValidateRecurrence(rule), rule)
+	}
+
+	for _, rule := range []string{
+		"FREQ=SOMETIMES",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;X-NAME=1",
+		"FREQ=DAILY;BYDAY=MO\r\nATTENDEE:mailto:eve@example.com",
+		"FREQ=DAILY\r\nBEGIN:VALARM",
+		"FREQ=DAILY;BYDAY=MO:TU",
+		"FREQ=DAILY;WKST=XX",
+	} {
+		assert.Error(t, ical.ValidateRecurrence(rule), rule)
+	}
+}
@@ -0,0 +1,170 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// Starts a TodoServer backed by a new database and returns a client of it
+func setupClient(t *testing.T, options ...client.Option) *client.Client {
+	t.Helper()
+	server, _ := setupDatabaseServer(t)
+	httpServer := httptest.NewServer(server.Router)
+	t.Cleanup(httpServer.Close)
+
+	return client.New(httpServer.URL, options...)
+}
+
+// Tests the project operations of the client
+func TestClientProjects(t *testing.T) {
+	ctx := context.Background()
+	c := setupClient(t)
+
+	assert.NoError(t, c.CreateProject(ctx, "homework"))
+	assert.NoError(t, c.CreateProject(ctx, "house work"))
+
+	project, err := c.GetProject(ctx, "house work")
+	assert.NoError(t, err)
+	assert.Equal(t, "house work", project.Name)
+
+	assert.NoError(t, c.ArchiveProject(ctx, "homework"))
+	project, _ = c.GetProject(ctx, "homework")
+	assert.True(t, project.Archived)
+	assert.NoError(t, c.UnarchiveProject(ctx, "homework"))
+	project, _ = c.GetProject(ctx, "homework")
+	assert.False(t, project.Archived)
+
+	assert.NoError(t, c.RenameProject(ctx, "house work", "cleaning"))
+	projects, err := c.GetAllProjects(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 2)
+	assert.Equal(t, "cleaning", projects[1].Name)
+
+	assert.NoError(t, c.DeleteProject(ctx, "cleaning"))
+	_, err = c.GetProject(ctx, "cleaning")
+	assert.True(t, client.IsNotFound(err))
+
+	t.Run("Errors contain the message of the server", func(t *testing.T) {
+		err := c.CreateProject(ctx, "homework")
+
+		apiError := &client.Error{}
+		assert.True(t, errors.As(err, &apiError))
+		assert.Equal(t, http.StatusBadRequest, apiError.StatusCode)
+		assert.Equal(t, "project already existing", apiError.Message)
+	})
+}
+
+// Tests the task operations of the client
+func TestClientTasks(t *testing.T) {
+	ctx := context.Background()
+	c := setupClient(t)
+	deadline := time.Date(2021, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	c.CreateProject(ctx, "homework")
+	err := c.CreateTask(ctx, "homework", client.TaskInput{Name: "math", Priority: "1", Deadline: &deadline, Recurrence: "FREQ=WEEKLY"})
+	assert.NoError(t, err)
+
+	task, err := c.GetTask(ctx, "homework", "math")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", task.Priority)
+	assert.True(t, deadline.Equal(*task.Deadline))
+	assert.Equal(t, "FREQ=WEEKLY", task.Recurrence)
+
+	err = c.UpdateTask(ctx, "homework", "math", client.TaskInput{Name: "algebra", Priority: "2", Notes: "page 42"})
+	assert.NoError(t, err)
+	assert.NoError(t, c.CompleteTask(ctx, "homework", "algebra"))
+
+	tasks, err := c.GetAllTasks(ctx, "homework")
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "page 42", tasks[0].Notes)
+	assert.Nil(t, tasks[0].Deadline)
+	assert.True(t, tasks[0].Done)
+
+	assert.NoError(t, c.ReopenTask(ctx, "homework", "algebra"))
+	task, _ = c.GetTask(ctx, "homework", "algebra")
+	assert.False(t, task.Done)
+
+	results, err := c.Search(ctx, client.SearchQuery{Text: "alg", Type: "task"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	assert.NoError(t, c.DeleteTask(ctx, "homework", "algebra"))
+	_, err = c.GetTask(ctx, "homework", "algebra")
+	assert.True(t, client.IsNotFound(err))
+
+	t.Run("Invalid recurrence", func(t *testing.T) {
+		err := c.CreateTask(ctx, "homework", client.TaskInput{Name: "biology", Priority: "1", Deadline: &deadline, Recurrence: "FREQ=SOMETIMES"})
+		apiError := &client.Error{}
+		assert.True(t, errors.As(err, &apiError))
+		assert.Equal(t, http.StatusBadRequest, apiError.StatusCode)
+	})
+}
+
+// Tests retries of idempotent requests
+func TestClientRetries(t *testing.T) {
+	server, _ := setupDatabaseServer(t)
+	server.Store.PostProject("homework")
+
+	// Fails the first two requests
+	var requests int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.Router.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+
+	t.Run("GET is retried", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		c := client.New(httpServer.URL, client.WithRetries(2, time.Millisecond))
+
+		project, err := c.GetProject(ctx, "homework")
+		assert.NoError(t, err)
+		assert.Equal(t, "homework", project.Name)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Retries are limited", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		c := client.New(httpServer.URL, client.WithRetries(1, time.Millisecond))
+
+		_, err := c.GetProject(ctx, "homework")
+		apiError := &client.Error{}
+		assert.True(t, errors.As(err, &apiError))
+		assert.Equal(t, http.StatusServiceUnavailable, apiError.StatusCode)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("POST is not retried", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		c := client.New(httpServer.URL, client.WithRetries(2, time.Millisecond))
+
+		err := c.CreateProject(ctx, "cleaning")
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Canceled context stops retries", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		c := client.New(httpServer.URL, client.WithRetries(2, time.Hour))
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := c.GetProject(ctx, "homework")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+}
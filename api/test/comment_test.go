@@ -0,0 +1,132 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/comment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseCommentPage(t *testing.T, body []byte) comment.Page {
+	t.Helper()
+	page := comment.Page{}
+	if err := json.Unmarshal(body, &page); err != nil {
+		t.Fatalf("Error parsing comments: %s", err)
+	}
+	return page
+}
+
+// Tests routes /projects/:projectName/tasks/:taskName/comments
+func TestComments(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: db.GetProject("homework").ID})
+
+	const url = "/projects/homework/tasks/math/comments"
+
+	t.Run("Create comments", func(t *testing.T) {
+		w := doUserRequest(t, server, "alice", "POST", url, map[string]string{"body": "Exercise **3** is [hard](https://example.com)"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		for i := 1; i <= 4; i++ {
+			w = doUserRequest(t, server, "bob", "POST", url, map[string]string{"body": fmt.Sprintf("reply %d", i)})
+			assert.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		page := parseCommentPage(t, doJSONRequest(t, server, "GET", url, nil).Body.Bytes())
+		assert.Equal(t, 5, page.Total)
+		assert.Equal(t, comment.DefaultLimit, page.Limit)
+		assert.Equal(t, "alice", page.Comments[0].Author)
+		assert.Equal(t, "<p>Exercise <strong>3</strong> is <a href=\"https://example.com\" rel=\"nofollow noreferrer noopener\">hard</a></p>\n", page.Comments[0].BodyHTML)
+		assert.Nil(t, page.Comments[0].EditedAt)
+	})
+
+	t.Run("Comments without author are anonymous", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", url, map[string]string{"body": "me too"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		page := parseCommentPage(t, doJSONRequest(t, server, "GET", url+"?offset=5", nil).Body.Bytes())
+		assert.Equal(t, "anonymous", page.Comments[0].Author)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		page := parseCommentPage(t, doJSONRequest(t, server, "GET", url+"?offset=1&limit=2", nil).Body.Bytes())
+		assert.Equal(t, 6, page.Total)
+		assert.Len(t, page.Comments, 2)
+		assert.Equal(t, []string{"reply 1", "reply 2"}, []string{page.Comments[0].Body, page.Comments[1].Body})
+
+		page = parseCommentPage(t, doJSONRequest(t, server, "GET", url+"?offset=10", nil).Body.Bytes())
+		assert.Empty(t, page.Comments)
+
+		for _, query := range []string{"?offset=-1", "?limit=0", "?limit=101", "?limit=all"} {
+			w := doJSONRequest(t, server, "GET", url+query, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("Markdown is rendered without raw HTML and unsafe links", func(t *testing.T) {
+		html := comment.Render("<script>alert(1)</script>\n\n[click](javascript:alert(1))")
+		assert.NotContains(t, html, "<script>")
+		assert.NotContains(t, html, "href=\"javascript:")
+	})
+
+	t.Run("Images are not rendered", func(t *testing.T) {
+		for _, url := range []string{"javascript:alert(1)", "data:image/svg+xml;base64,PHN2Zz4=", "https://tracker.example/pixel.gif"} {
+			html := comment.Render("![pixel](" + url + ")")
+			assert.NotContains(t, html, "<img", url)
+			assert.NotContains(t, html, url)
+		}
+	})
+
+	t.Run("Only the author can edit and delete a comment", func(t *testing.T) {
+		page := parseCommentPage(t, doJSONRequest(t, server, "GET", url+"?limit=1", nil).Body.Bytes())
+		commentURL := fmt.Sprintf("%s/%d", url, page.Comments[0].ID)
+
+		w := doUserRequest(t, server, "bob", "PUT", commentURL, map[string]string{"body": "changed"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doUserRequest(t, server, "bob", "DELETE", commentURL, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doUserRequest(t, server, "alice", "PUT", commentURL, map[string]string{"body": "Exercise 3 is easy"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		edited := model.Comment{}
+		json.Unmarshal(doJSONRequest(t, server, "GET", commentURL, nil).Body.Bytes(), &edited)
+		assert.Equal(t, "Exercise 3 is easy", edited.Body)
+		assert.NotNil(t, edited.EditedAt)
+
+		w = doUserRequest(t, server, "alice", "DELETE", commentURL, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doJSONRequest(t, server, "GET", commentURL, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid comments return http.StatusBadRequest", func(t *testing.T) {
+		for _, body := range []string{"", "   ", strings.Repeat("a", comment.MaxBodyLength+1)} {
+			w := doJSONRequest(t, server, "POST", url, map[string]string{"body": body})
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Comments of other tasks are not found", func(t *testing.T) {
+		db.PostTask(model.Task{Name: "biology", Priority: "1", ProjectID: db.GetProject("homework").ID})
+		page := parseCommentPage(t, doJSONRequest(t, server, "GET", url, nil).Body.Bytes())
+
+		w := doJSONRequest(t, server, "GET", fmt.Sprintf("/projects/homework/tasks/biology/comments/%d", page.Comments[0].ID), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Deleting a task deletes its comments", func(t *testing.T) {
+		task := db.GetTask("homework", "math")
+		w := doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/math", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		comments, total := db.GetComments(task.ID, 0, -1)
+		assert.Empty(t, comments)
+		assert.Zero(t, total)
+	})
+}
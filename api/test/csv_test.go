@@ -0,0 +1,176 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/csvio"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Uploads a CSV file as multipart form
+func uploadCSV(t *testing.T, server *api.TodoServer, target, content string, fields map[string]string) (int, csvio.ImportReport) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	part, _ := writer.CreateFormFile("file", "tasks.csv")
+	part.Write([]byte(content))
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", target, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+
+	report := csvio.ImportReport{}
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report
+}
+
+// Tests for the CSV export and import routes
+func TestCSV(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "cleaning"})
+	doJSONRequest(t, server, "POST", "/projects/homework/tasks", map[string]string{
+		"name":     "math",
+		"priority": "1",
+		"deadline": "2021-06-10 12:00:00 +0000 UTC",
+		"notes":    "chapter 3, exercises",
+	})
+	doJSONRequest(t, server, "POST", "/projects/cleaning/tasks", map[string]string{
+		"name":     "kitchen",
+		"priority": "2",
+		"deadline": "2021-06-12 08:30:00 +0000 UTC",
+	})
+
+	t.Run("Export tasks of a project", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/tasks.csv", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			csvio.Columns,
+			{"homework", "math", "1", "2021-06-10T12:00:00Z", "false", "chapter 3, exercises", "", ""},
+		}, records)
+	})
+
+	t.Run("Export tasks of all projects", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/tasks.csv", nil)
+
+		records, _ := csv.NewReader(w.Body).ReadAll()
+		assert.Len(t, records, 3)
+		assert.Equal(t, "kitchen", records[2][1])
+	})
+
+	t.Run("Dry run validates without changes", func(t *testing.T) {
+		content := "Task,Due,Completed\nmath,2021-07-01,yes\nphysics,,no\n"
+		code, report := uploadCSV(t, server, "/projects/homework/tasks.csv", content, map[string]string{"dry_run": "true"})
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.False(t, db.GetTask("homework", "math").Done)
+		assert.Equal(t, "", db.GetTask("homework", "physics").Name)
+	})
+
+	t.Run("Import updates present columns and creates tasks", func(t *testing.T) {
+		content := "Task,Due,Completed\nmath,2021-07-01,yes\nphysics,,no\n"
+		code, report := uploadCSV(t, server, "/projects/homework/tasks.csv", content, nil)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+
+		math := db.GetTask("homework", "math")
+		assert.True(t, math.Done)
+		assert.Equal(t, "chapter 3, exercises", math.Notes)
+		assert.Equal(t, "2021-07-01", math.Deadline.UTC().Format("2006-01-02"))
+		assert.Equal(t, "physics", db.GetTask("homework", "physics").Name)
+	})
+
+	t.Run("Header mapping", func(t *testing.T) {
+		content := "Aufgabe,Wichtigkeit\nmath,3\n"
+		mapping := url.QueryEscape(`{"Aufgabe":"name","Wichtigkeit":"priority"}`)
+		code, report := uploadCSV(t, server, "/projects/homework/tasks.csv?mapping="+mapping, content, nil)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, "3", db.GetTask("homework", "math").Priority)
+	})
+
+	t.Run("Invalid rows are reported and nothing is imported", func(t *testing.T) {
+		content := "project,name,deadline,done\n" +
+			"homework,biology,2021-07-01,false\n" +
+			"homework,chemistry,next week,false\n" +
+			"cleaning,,2021-07-01,maybe\n"
+		code, report := uploadCSV(t, server, "/tasks.csv", content, nil)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, code)
+		assert.Equal(t, []csvio.RowError{
+			{Row: 3, Column: "deadline", Message: "invalid deadline \"next week\""},
+			{Row: 4, Column: "name", Message: "name is required"},
+			{Row: 4, Column: "done", Message: "invalid done value \"maybe\""},
+		}, report.Errors)
+		assert.Equal(t, "", db.GetTask("homework", "biology").Name)
+	})
+
+	t.Run("Import of all projects creates missing projects", func(t *testing.T) {
+		content := "project,name\nhomework,biology\ngarden,lawn\n"
+		code, report := uploadCSV(t, server, "/tasks.csv", content, nil)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, "lawn", db.GetTask("garden", "lawn").Name)
+	})
+
+	t.Run("Formulas are escaped on export and unescaped on import", func(t *testing.T) {
+		db.PostProject("formulas")
+		formulas := db.GetProject("formulas")
+		for _, name := range []string{"=HYPERLINK(\"http://evil.example\")", "+1", "-1", "@SUM(A1)", "'=quoted", "'plain"} {
+			db.PostTask(model.Task{Name: name, Priority: "1", ProjectID: formulas.ID})
+		}
+
+		w := doJSONRequest(t, server, "GET", "/projects/formulas/tasks.csv", nil)
+		content := w.Body.String()
+		records, _ := csv.NewReader(strings.NewReader(content)).ReadAll()
+		names := []string{}
+		for _, record := range records[1:] {
+			names = append(names, record[1])
+		}
+		assert.Equal(t, []string{"'=HYPERLINK(\"http://evil.example\")", "'+1", "'-1", "'@SUM(A1)", "''=quoted", "'plain"}, names)
+
+		db.DeleteProject("formulas")
+		db.PostProject("formulas")
+		code, report := uploadCSV(t, server, "/projects/formulas/tasks.csv", content, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 6, report.Created)
+		assert.Equal(t, "=HYPERLINK(\"http://evil.example\")", db.GetTask("formulas", "=HYPERLINK(\"http://evil.example\")").Name)
+		assert.Equal(t, "'=quoted", db.GetTask("formulas", "'=quoted").Name)
+		assert.Equal(t, "'plain", db.GetTask("formulas", "'plain").Name)
+	})
+
+	t.Run("Missing project column returns errors", func(t *testing.T) {
+		code, report := uploadCSV(t, server, "/tasks.csv", "name\nbiology\n", nil)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, code)
+		assert.Equal(t, "column project is required", report.Errors[0].Message)
+	})
+}
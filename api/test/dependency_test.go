@@ -0,0 +1,244 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/csvio"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseDependencies(t *testing.T, body []byte) dependency.Dependencies {
+	t.Helper()
+	result := dependency.Dependencies{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("Error parsing dependencies: %s", err)
+	}
+	return result
+}
+
+// Tests routes GET, POST and DELETE /projects/:projectName/tasks/:taskName/dependencies
+func TestDependencies(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	db.PostProject("shopping")
+	homework := db.GetProject("homework")
+	for _, name := range []string{"math", "biology", "history"} {
+		db.PostTask(model.Task{Name: name, Priority: "1", ProjectID: homework.ID})
+	}
+	db.PostTask(model.Task{Name: "notebook", Priority: "1", ProjectID: db.GetProject("shopping").ID})
+
+	const url = "/projects/homework/tasks/math/dependencies"
+
+	t.Run("Add blockers of the same and other projects", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", url, map[string]string{"name": "biology"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = doJSONRequest(t, server, "POST", url, map[string]string{"project": "shopping", "name": "notebook"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = doJSONRequest(t, server, "POST", "/projects/homework/tasks/biology/dependencies", map[string]string{"name": "history"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		result := parseDependencies(t, doJSONRequest(t, server, "GET", url, nil).Body.Bytes())
+		assert.True(t, result.Blocked)
+		assert.Len(t, result.BlockedBy, 2)
+		assert.Equal(t, "shopping", result.BlockedBy[1].Project)
+		assert.Equal(t, "notebook", result.BlockedBy[1].Task.Name)
+		assert.Empty(t, result.Blocks)
+
+		result = parseDependencies(t, doJSONRequest(t, server, "GET", "/projects/shopping/tasks/notebook/dependencies", nil).Body.Bytes())
+		assert.False(t, result.Blocked)
+		assert.Equal(t, "math", result.Blocks[0].Task.Name)
+	})
+
+	t.Run("Tasks show their blocked status", func(t *testing.T) {
+		task := model.Task{}
+		json.Unmarshal(doJSONRequest(t, server, "GET", "/projects/homework/tasks/math", nil).Body.Bytes(), &task)
+		assert.True(t, task.Blocked)
+
+		tasks := []model.Task{}
+		json.Unmarshal(doJSONRequest(t, server, "GET", "/projects/homework/tasks", nil).Body.Bytes(), &tasks)
+		assert.Equal(t, []bool{true, true, false}, []bool{tasks[0].Blocked, tasks[1].Blocked, tasks[2].Blocked})
+	})
+
+	t.Run("Boards and agendas show the blocked status", func(t *testing.T) {
+		board := workflow.Board{}
+		json.Unmarshal(doJSONRequest(t, server, "GET", "/projects/homework/board", nil).Body.Bytes(), &board)
+		blocked := map[string]bool{}
+		for _, column := range board.Columns {
+			for _, task := range column.Tasks {
+				blocked[task.Name] = task.Blocked
+			}
+		}
+		assert.Equal(t, map[string]bool{"math": true, "biology": true, "history": false}, blocked)
+
+		now := time.Now().UTC()
+		noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC)
+		math := db.GetTask("homework", "math")
+		math.Deadline = &noon
+		db.UpdateTask(math)
+
+		result := parseAgenda(t, doJSONRequest(t, server, "GET", "/agenda/today", nil).Body.Bytes())
+		assert.Equal(t, 1, result.Count)
+		assert.True(t, result.Days[0].Projects[0].Tasks[0].Blocked)
+	})
+
+	t.Run("Invalid dependencies", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", url, map[string]string{"name": "math"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSONRequest(t, server, "POST", url, map[string]string{"name": "biology"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = doJSONRequest(t, server, "POST", url, map[string]string{"name": "art"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Dependencies are unique in the store", func(t *testing.T) {
+		math, biology := db.GetTask("homework", "math"), db.GetTask("homework", "biology")
+		err := db.PostDependency(model.TaskDependency{TaskID: math.ID, BlockedByID: biology.ID})
+		assert.Error(t, err)
+	})
+
+	t.Run("Dependencies are filtered by task", func(t *testing.T) {
+		math, biology := db.GetTask("homework", "math"), db.GetTask("homework", "biology")
+
+		assert.Len(t, db.GetDependencies([]uint{math.ID}, nil), 2)
+		assert.Len(t, db.GetDependencies(nil, []uint{biology.ID}), 1)
+		assert.Len(t, db.GetDependencies([]uint{biology.ID}, []uint{biology.ID}), 2)
+		assert.Empty(t, db.GetDependencies(nil, nil))
+	})
+
+	t.Run("Cycles are refused", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/homework/tasks/history/dependencies", map[string]string{"name": "math"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		response := map[string]string{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "dependency would create a cycle: history -> math -> biology -> history", response["message"])
+	})
+
+	t.Run("Imports do not complete blocked tasks", func(t *testing.T) {
+		upload := func(target, contentType, content string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("POST", target, strings.NewReader(content))
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			server.Router.ServeHTTP(w, req)
+			return w
+		}
+
+		code, report := uploadCSV(t, server, "/projects/homework/tasks.csv", "name,done\nhistory,false\nbiology,true\n", nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, code)
+		assert.Equal(t, []csvio.RowError{{Row: 3, Column: "done", Message: "task is blocked by homework/history"}}, report.Errors)
+
+		w := upload("/projects/homework/todo.txt", "text/plain", "x biology\n")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"reason":"task is blocked by homework/history"`)
+
+		w = upload("/projects/homework/tasks.md", "text/markdown", "- [ ] history\n- [x] biology\n")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "line 2: task is blocked by homework/history")
+
+		biology := db.GetTask("homework", "biology")
+		biology.ExternalUID = "biology@caldav.example"
+		db.UpdateTask(biology)
+		w = upload("/projects/homework/import/ics", "text/calendar", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"+
+			"BEGIN:VTODO\r\nUID:biology@caldav.example\r\nSUMMARY:biology\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n"+
+			"END:VCALENDAR\r\n")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"reason":"task is blocked by homework/history"`)
+
+		assert.False(t, db.GetTask("homework", "biology").Done)
+	})
+
+	t.Run("Blocked tasks can only be completed by force", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/biology/complete", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "homework/history")
+
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/biology/state", map[string]string{"state": "done"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.False(t, db.GetTask("homework", "biology").Done)
+
+		w = doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{
+			"mode":       "best_effort",
+			"operations": []map[string]interface{}{{"op": "complete_task", "project": "homework", "name": "biology"}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":409`)
+		assert.False(t, db.GetTask("homework", "biology").Done)
+
+		_, response := doGraphQLRequest(t, server, `mutation {
+			completeTask(project: "homework", name: "biology") { done }
+		}`, nil)
+		if assert.Len(t, response.Errors, 1) {
+			assert.Contains(t, response.Errors[0].Message, "task is blocked by homework/history")
+		}
+		assert.False(t, db.GetTask("homework", "biology").Done)
+
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/history/complete", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/biology/complete", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/complete?force=true", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, db.GetTask("homework", "math").Done)
+	})
+
+	t.Run("Delete dependencies", func(t *testing.T) {
+		result := parseDependencies(t, doJSONRequest(t, server, "GET", url, nil).Body.Bytes())
+		id := result.BlockedBy[1].ID
+
+		w := doJSONRequest(t, server, "DELETE", fmt.Sprintf("/projects/homework/tasks/biology/dependencies/%d", id), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doJSONRequest(t, server, "DELETE", fmt.Sprintf("/projects/shopping/tasks/notebook/dependencies/%d", id), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		result = parseDependencies(t, doJSONRequest(t, server, "GET", url, nil).Body.Bytes())
+		assert.False(t, result.Blocked)
+		assert.Len(t, result.BlockedBy, 1)
+	})
+
+	t.Run("Deleting a task deletes its dependencies", func(t *testing.T) {
+		w := doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/biology", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, db.GetAllDependencies())
+	})
+}
+
+// Concurrent requests must not create a cycle together
+func TestConcurrentDependencies(t *testing.T) {
+	_, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	homework := db.GetProject("homework")
+
+	for i := 0; i < 10; i++ {
+		first, second := fmt.Sprintf("first %d", i), fmt.Sprintf("second %d", i)
+		db.PostTask(model.Task{Name: first, Priority: "1", ProjectID: homework.ID})
+		db.PostTask(model.Task{Name: second, Priority: "1", ProjectID: homework.ID})
+		a, b := db.GetTask("homework", first), db.GetTask("homework", second)
+
+		errs := make(chan error, 2)
+		go func() {
+			_, err := dependency.Add(db, a, b)
+			errs <- err
+		}()
+		go func() {
+			_, err := dependency.Add(db, b, a)
+			errs <- err
+		}()
+		<-errs
+		<-errs
+
+		assert.Len(t, db.GetDependencies([]uint{a.ID, b.ID}, nil), 1)
+	}
+}
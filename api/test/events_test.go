@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/events"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStore(t *testing.T) {
+	_, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	project := db.GetProject("homework")
+
+	broker := events.NewBroker()
+	todoStore := events.NewStore(db, broker)
+	taskEvents, cancel := broker.Subscribe()
+	defer cancel()
+
+	t.Run("Changes are published", func(t *testing.T) {
+		todoStore.PostTask(model.Task{Name: "math", ProjectID: project.ID})
+
+		event := <-taskEvents
+		assert.Equal(t, events.TaskCreated, event.Type)
+		assert.Equal(t, "homework", event.Project)
+		assert.NotZero(t, event.Task.ID)
+	})
+
+	t.Run("Rolled back changes are not published", func(t *testing.T) {
+		err := todoStore.Transaction(func(tx store.TodoStore) error {
+			tx.PostTask(model.Task{Name: "biology", ProjectID: project.ID})
+			return errors.New("rollback")
+		})
+		assert.Error(t, err)
+		assert.Len(t, taskEvents, 0)
+	})
+
+	t.Run("Changes of transactions are published after the commit", func(t *testing.T) {
+		err := todoStore.Transaction(func(tx store.TodoStore) error {
+			tx.PostTask(model.Task{Name: "biology", ProjectID: project.ID})
+			assert.Len(t, taskEvents, 0)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		event := <-taskEvents
+		assert.Equal(t, "biology", event.Task.Name)
+	})
+
+	t.Run("Events look up only the project of their task", func(t *testing.T) {
+		counter := &countingStore{TodoStore: db}
+		counted := events.NewStore(counter, broker)
+		counted.PostTask(model.Task{Name: "physics", ProjectID: project.ID})
+
+		event := <-taskEvents
+		assert.Equal(t, "homework", event.Project)
+		assert.Zero(t, counter.projectQueries)
+	})
+}
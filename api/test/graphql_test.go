@@ -0,0 +1,204 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/graphqlapi"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// Counts the queries for tasks
+type countingStore struct {
+	store.TodoStore
+	taskQueries    int
+	projectQueries int
+}
+
+// Counts the queries of all contexts in the same counter
+func (s *countingStore) WithContext(ctx context.Context) store.TodoStore {
+	return s
+}
+
+func (s *countingStore) GetAllProjects() []model.Project {
+	s.projectQueries++
+	return s.TodoStore.GetAllProjects()
+}
+
+func (s *countingStore) GetAllProjectTasks(project model.Project) []model.Task {
+	s.taskQueries++
+	return s.TodoStore.GetAllProjectTasks(project)
+}
+
+func (s *countingStore) GetTasksOfProjects(projectIDs []uint) []model.Task {
+	s.taskQueries++
+	return s.TodoStore.GetTasksOfProjects(projectIDs)
+}
+
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Sends a GraphQL request and parses the response
+func doGraphQLRequest(t *testing.T, server *api.TodoServer, query string, variables map[string]interface{}) (int, graphQLResponse) {
+	t.Helper()
+	w := doJSONRequest(t, server, "POST", "/graphql", map[string]interface{}{"query": query, "variables": variables})
+
+	response := graphQLResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error parsing GraphQL response %s: %s", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+// Tests queries of route POST /graphql
+func TestGraphQLQueries(t *testing.T) {
+	_, db := setupDatabaseServer(t)
+	counter := &countingStore{TodoStore: db}
+	server := api.NewTodoServer(counter)
+
+	for _, name := range []string{"homework", "cleaning", "garden"} {
+		db.PostProject(name)
+		project := db.GetProject(name)
+		db.PostTask(model.Task{Name: name + " 1", ProjectID: project.ID, Tags: "weekly"})
+		db.PostTask(model.Task{Name: name + " 2", ProjectID: project.ID, Done: true})
+	}
+
+	t.Run("Nested tasks are loaded in one query", func(t *testing.T) {
+		counter.taskQueries = 0
+		code, response := doGraphQLRequest(t, server, `{
+			projects {
+				name
+				open: taskCount(done: false)
+				tasks(tag: "weekly") { name project { name } }
+			}
+		}`, nil)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, 1, counter.taskQueries)
+
+		projects := response.Data["projects"].([]interface{})
+		assert.Len(t, projects, 3)
+		homework := projects[0].(map[string]interface{})
+		assert.Equal(t, float64(1), homework["open"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"name":    "homework 1",
+			"project": map[string]interface{}{"name": "homework"},
+		}}, homework["tasks"])
+	})
+
+	t.Run("Single project and task", func(t *testing.T) {
+		_, response := doGraphQLRequest(t, server, `query($project: String!) {
+			project(name: $project) { name tasks(first: 1) { name } }
+			task(project: $project, name: "garden 2") { done completedAt }
+			missing: project(name: "school") { name }
+		}`, map[string]interface{}{"project": "garden"})
+
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, map[string]interface{}{
+			"name":  "garden",
+			"tasks": []interface{}{map[string]interface{}{"name": "garden 1"}},
+		}, response.Data["project"])
+		assert.Equal(t, true, response.Data["task"].(map[string]interface{})["done"])
+		assert.Nil(t, response.Data["missing"])
+	})
+
+	t.Run("Queries with GET", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/graphql?query="+url.QueryEscape("{ projects { name } }"), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSONRequest(t, server, "GET", "/graphql?query="+url.QueryEscape(`mutation { deleteProject(name: "garden") }`), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NotEmpty(t, db.GetProject("garden").Name)
+	})
+
+	t.Run("Invalid queries", func(t *testing.T) {
+		code, response := doGraphQLRequest(t, server, `{ projects { title } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Len(t, response.Errors, 1)
+	})
+}
+
+// Tests mutations of route POST /graphql
+func TestGraphQLMutations(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+
+	_, response := doGraphQLRequest(t, server, `mutation {
+		createProject(name: "homework") { name archived }
+		createTask(project: "homework", input: {name: "math", priority: "1", deadline: "2021-06-10T12:00:00Z", recurrence: "FREQ=WEEKLY"}) { id name deadline }
+	}`, nil)
+	assert.Empty(t, response.Errors)
+	assert.Equal(t, "2021-06-10T12:00:00Z", response.Data["createTask"].(map[string]interface{})["deadline"])
+
+	_, response = doGraphQLRequest(t, server, `mutation {
+		updateTask(project: "homework", name: "math", input: {name: "algebra", notes: "page 42"}) { name notes deadline }
+		completeTask(project: "homework", name: "algebra") { done }
+		renameProject(name: "homework", newName: "school") { name }
+		archiveProject(name: "school") { archived tasks { name done } }
+	}`, nil)
+	assert.Empty(t, response.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"archived": true,
+		"tasks":    []interface{}{map[string]interface{}{"name": "algebra", "done": true}},
+	}, response.Data["archiveProject"])
+
+	t.Run("Errors of mutations", func(t *testing.T) {
+		code, response := doGraphQLRequest(t, server, `mutation {
+			createTask(project: "school", input: {name: "biology", recurrence: "FREQ=SOMETIMES"}) { id }
+		}`, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, response.Errors, 1)
+
+		_, response = doGraphQLRequest(t, server, `mutation { deleteTask(project: "school", name: "biology") }`, nil)
+		assert.Equal(t, "task not found", response.Errors[0].Message)
+	})
+
+	_, response = doGraphQLRequest(t, server, `mutation {
+		deleteTask(project: "school", name: "algebra")
+		deleteProject(name: "school")
+	}`, nil)
+	assert.Empty(t, response.Errors)
+	assert.Empty(t, db.GetAllProjects())
+}
+
+// Tests the depth and complexity limits
+func TestGraphQLLimits(t *testing.T) {
+	server, _ := setupDatabaseServer(t, api.WithGraphQLLimits(graphqlapi.Limits{MaxDepth: 3, MaxComplexity: 50, ListSize: 10}))
+
+	t.Run("Depth", func(t *testing.T) {
+		code, response := doGraphQLRequest(t, server, `{ projects { tasks { project { name } } } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "query has depth 4, the maximum is 3", response.Errors[0].Message)
+	})
+
+	t.Run("Complexity", func(t *testing.T) {
+		code, response := doGraphQLRequest(t, server, `{ projects { name tasks { name } } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "query has complexity 121, the maximum is 50", response.Errors[0].Message)
+
+		code, _ = doGraphQLRequest(t, server, `{ projects(first: 2) { name tasks(first: 5) { name } } }`, nil)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Fragments count", func(t *testing.T) {
+		code, _ := doGraphQLRequest(t, server, `
+			{ projects { ...deep } }
+			fragment deep on Project { tasks { project { name } } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Introspection is not limited", func(t *testing.T) {
+		code, _ := doGraphQLRequest(t, server, `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil)
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
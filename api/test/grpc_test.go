@@ -0,0 +1,214 @@
+package api_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/events"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/grpcapi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Starts the gRPC and the REST API on a shared store and returns a gRPC client
+func setupGRPC(t *testing.T, options ...api.Option) (grpcapi.TodoServiceClient, *api.TodoServer) {
+	t.Helper()
+	_, db := setupDatabaseServer(t)
+	broker := events.NewBroker()
+	todoStore := events.NewStore(db, broker)
+	server := api.NewTodoServer(todoStore, options...)
+
+	grpcServer := grpc.NewServer(server.GRPCOptions()...)
+	grpcapi.RegisterTodoServiceServer(grpcServer, grpcapi.NewServer(todoStore, broker))
+	return dialGRPC(t, grpcServer), server
+}
+
+// Serves the gRPC server on an in-memory listener and returns a client
+func dialGRPC(t *testing.T, grpcServer *grpc.Server) grpcapi.TodoServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Error connecting to gRPC server: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return grpcapi.NewTodoServiceClient(conn)
+}
+
+// Tests the project operations of the gRPC API
+func TestGRPCProjects(t *testing.T) {
+	ctx := context.Background()
+	todo, _ := setupGRPC(t)
+
+	project, err := todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "homework"})
+	assert.NoError(t, err)
+	assert.Equal(t, "homework", project.Name)
+	assert.NotZero(t, project.Id)
+
+	_, err = todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "homework"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	project, err = todo.ArchiveProject(ctx, &grpcapi.ArchiveProjectRequest{Name: "homework", Archived: true})
+	assert.NoError(t, err)
+	assert.True(t, project.Archived)
+
+	project, err = todo.RenameProject(ctx, &grpcapi.RenameProjectRequest{Name: "homework", NewName: "school"})
+	assert.NoError(t, err)
+	assert.Equal(t, "school", project.Name)
+
+	projects, _ := todo.ListProjects(ctx, &grpcapi.ListProjectsRequest{})
+	assert.Len(t, projects.Projects, 1)
+
+	_, err = todo.DeleteProject(ctx, &grpcapi.DeleteProjectRequest{Name: "school"})
+	assert.NoError(t, err)
+	_, err = todo.GetProject(ctx, &grpcapi.GetProjectRequest{Name: "school"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// Tests the task operations of the gRPC API
+func TestGRPCTasks(t *testing.T) {
+	ctx := context.Background()
+	todo, server := setupGRPC(t)
+	deadline := time.Date(2021, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "homework"})
+	task, err := todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "homework", Task: &grpcapi.TaskInput{
+		Name: "math", Priority: "1", Deadline: timestamppb.New(deadline), Recurrence: "FREQ=WEEKLY",
+	}})
+	assert.NoError(t, err)
+	assert.NotZero(t, task.Id)
+	assert.True(t, deadline.Equal(task.Deadline.AsTime()))
+
+	task, err = todo.UpdateTask(ctx, &grpcapi.UpdateTaskRequest{Project: "homework", Name: "math", Task: &grpcapi.TaskInput{
+		Name: "algebra", Priority: "2", Notes: "page 42",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "algebra", task.Name)
+	assert.Nil(t, task.Deadline)
+
+	task, err = todo.CompleteTask(ctx, &grpcapi.CompleteTaskRequest{Project: "homework", Name: "algebra", Done: true})
+	assert.NoError(t, err)
+	assert.True(t, task.Done)
+	assert.NotNil(t, task.CompletedAt)
+
+	tasks, _ := todo.ListTasks(ctx, &grpcapi.ListTasksRequest{Project: "homework"})
+	assert.Len(t, tasks.Tasks, 1)
+	assert.Equal(t, "page 42", tasks.Tasks[0].Notes)
+
+	_, err = todo.DeleteTask(ctx, &grpcapi.DeleteTaskRequest{Project: "homework", Name: "algebra"})
+	assert.NoError(t, err)
+	_, err = todo.GetTask(ctx, &grpcapi.GetTaskRequest{Project: "homework", Name: "algebra"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	t.Run("Blocked tasks can only be completed by force", func(t *testing.T) {
+		for _, name := range []string{"chemistry", "physics"} {
+			todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "homework", Task: &grpcapi.TaskInput{Name: name, Priority: "1"}})
+		}
+		doJSONRequest(t, server, "POST", "/projects/homework/tasks/physics/dependencies", map[string]string{"name": "chemistry"})
+
+		_, err := todo.CompleteTask(ctx, &grpcapi.CompleteTaskRequest{Project: "homework", Name: "physics", Done: true})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		task, err := todo.CompleteTask(ctx, &grpcapi.CompleteTaskRequest{Project: "homework", Name: "physics", Done: true, Force: true})
+		assert.NoError(t, err)
+		assert.True(t, task.Done)
+	})
+
+	t.Run("Invalid tasks", func(t *testing.T) {
+		_, err := todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "homework", Task: &grpcapi.TaskInput{}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "homework", Task: &grpcapi.TaskInput{
+			Name: "biology", Recurrence: "FREQ=SOMETIMES",
+		}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "garden", Task: &grpcapi.TaskInput{Name: "roses"}})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+// Tests the stream of task changes made through the gRPC and the REST API
+func TestGRPCWatchTasks(t *testing.T) {
+	todo, server := setupGRPC(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "homework"})
+	todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "cleaning"})
+
+	stream, err := todo.WatchTasks(ctx, &grpcapi.WatchTasksRequest{Project: "homework"})
+	assert.NoError(t, err)
+	// The header is sent once the subscription is active
+	stream.Header()
+
+	todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "cleaning", Task: &grpcapi.TaskInput{Name: "kitchen"}})
+	todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "homework", Task: &grpcapi.TaskInput{Name: "math"}})
+	doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/complete", nil)
+	doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/math", nil)
+
+	want := []grpcapi.TaskEvent_Type{
+		grpcapi.TaskEvent_TYPE_CREATED,
+		grpcapi.TaskEvent_TYPE_UPDATED,
+		grpcapi.TaskEvent_TYPE_DELETED,
+	}
+	for _, eventType := range want {
+		event, err := stream.Recv()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, eventType, event.Type)
+		assert.Equal(t, "homework", event.Project)
+		assert.Equal(t, "math", event.Task.Name)
+		assert.NotZero(t, event.Task.Id)
+	}
+
+	t.Run("Unknown project", func(t *testing.T) {
+		stream, _ := todo.WatchTasks(ctx, &grpcapi.WatchTasksRequest{Project: "garden"})
+		_, err := stream.Recv()
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+// Tests gRPC and REST requests on the same listener
+func TestGRPCMultiplex(t *testing.T) {
+	_, db := setupDatabaseServer(t)
+	server := api.NewTodoServer(db)
+	grpcServer := grpc.NewServer()
+	grpcapi.RegisterTodoServiceServer(grpcServer, grpcapi.NewServer(db, nil))
+
+	httpServer := httptest.NewServer(grpcapi.Multiplex(grpcServer, server.Router))
+	defer httpServer.Close()
+
+	conn, err := grpc.Dial(strings.TrimPrefix(httpServer.URL, "http://"), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Error connecting to gRPC server: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = grpcapi.NewTodoServiceClient(conn).CreateProject(context.Background(), &grpcapi.CreateProjectRequest{Name: "homework"})
+	assert.NoError(t, err)
+
+	response, err := http.Get(httpServer.URL + "/projects/homework")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.Body.Close()
+}
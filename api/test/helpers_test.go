@@ -1,17 +1,42 @@
 package api_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
 	"gorm.io/gorm"
 )
 
 type StubTodoStore struct {
-	Projects []model.Project
-	Tasks    []model.Task
+	Projects       []model.Project
+	Tasks          []model.Task
+	FeedTokens     []model.FeedToken
+	WorkflowStates []model.WorkflowState
+	TimeEntries    []model.TimeEntry
+	Dependencies   []model.TaskDependency
+	Comments       []model.Comment
+	Attachments    []model.Attachment
+}
+
+// The stub has no rollback, fn works on the stub directly
+func (s *StubTodoStore) Transaction(fn func(t store.TodoStore) error) error {
+	return fn(s)
+}
+
+// The stub runs no queries, so it ignores the context
+func (s *StubTodoStore) WithContext(ctx context.Context) store.TodoStore {
+	return s
 }
 
 func (s *StubTodoStore) GetProject(name string) model.Project {
@@ -23,6 +48,15 @@ func (s *StubTodoStore) GetProject(name string) model.Project {
 	return model.Project{}
 }
 
+func (s *StubTodoStore) GetProjectByID(id uint) model.Project {
+	for _, p := range s.Projects {
+		if p.ID == id {
+			return p
+		}
+	}
+	return model.Project{}
+}
+
 func (s *StubTodoStore) PostProject(name string) error {
 	// Find out which ID the project gets
 	lastProjectIndex := (len(s.Projects) - 1)
@@ -77,11 +111,18 @@ func (s *StubTodoStore) PostTask(task model.Task) error {
 			UpdatedAt: time,
 			DeletedAt: deletedAT,
 		},
-		Name:      task.Name,
-		Priority:  task.Priority,
-		Deadline:  &time,
-		Done:      false,
-		ProjectID: task.ProjectID,
+		Name:        task.Name,
+		Priority:    task.Priority,
+		Deadline:    &time,
+		Done:        false,
+		Notes:       task.Notes,
+		Recurrence:  task.Recurrence,
+		Tags:        task.Tags,
+		ExternalUID: task.ExternalUID,
+		ParentID:    task.ParentID,
+		Rank:        task.Rank,
+		State:       task.State,
+		ProjectID:   task.ProjectID,
 	}
 
 	s.Tasks = append(s.Tasks, newTask)
@@ -114,6 +155,24 @@ func (s *StubTodoStore) GetAllProjectTasks(project model.Project) []model.Task {
 	return projects
 }
 
+func (s *StubTodoStore) GetTasksOfProjects(projectIDs []uint) []model.Task {
+	tasks := []model.Task{}
+	for _, id := range projectIDs {
+		tasks = append(tasks, s.GetAllProjectTasks(model.Project{Model: gorm.Model{ID: id}})...)
+	}
+	return tasks
+}
+
+func (s *StubTodoStore) GetOpenTasksWithDeadline(projectIDs []uint) []model.Task {
+	tasks := []model.Task{}
+	for _, task := range s.GetTasksOfProjects(projectIDs) {
+		if !task.Done && task.Deadline != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
 func (s *StubTodoStore) UpdateTask(task model.Task) error {
 	index := int(task.ID) - 1
 
@@ -122,6 +181,14 @@ func (s *StubTodoStore) UpdateTask(task model.Task) error {
 		s.Tasks[index].Deadline = task.Deadline
 		s.Tasks[index].Priority = task.Priority
 		s.Tasks[index].Done = task.Done
+		s.Tasks[index].CompletedAt = task.CompletedAt
+		s.Tasks[index].Notes = task.Notes
+		s.Tasks[index].Recurrence = task.Recurrence
+		s.Tasks[index].Tags = task.Tags
+		s.Tasks[index].ExternalUID = task.ExternalUID
+		s.Tasks[index].ParentID = task.ParentID
+		s.Tasks[index].Rank = task.Rank
+		s.Tasks[index].State = task.State
 	}
 	return nil
 }
@@ -133,6 +200,315 @@ func (s *StubTodoStore) DeleteTask(task model.Task) error {
 	return nil
 }
 
+func (s *StubTodoStore) Search(query store.SearchQuery) ([]model.SearchResult, error) {
+	results := []model.SearchResult{}
+
+	for _, task := range s.Tasks {
+		if strings.Contains(task.Name, query.Text) {
+			results = append(results, model.SearchResult{Type: "task", ID: task.ID, Name: task.Name})
+		}
+	}
+	return results, nil
+}
+
+func (s *StubTodoStore) GetFeedToken(token string) model.FeedToken {
+	for _, feedToken := range s.FeedTokens {
+		if feedToken.Token == token {
+			return feedToken
+		}
+	}
+	return model.FeedToken{}
+}
+
+func (s *StubTodoStore) GetAllFeedTokens() []model.FeedToken {
+	return s.FeedTokens
+}
+
+func (s *StubTodoStore) PostFeedToken(feedToken model.FeedToken) error {
+	s.FeedTokens = append(s.FeedTokens, feedToken)
+	return nil
+}
+
+func (s *StubTodoStore) DeleteFeedToken(feedToken model.FeedToken) error {
+	for i, f := range s.FeedTokens {
+		if f.Token == feedToken.Token {
+			s.FeedTokens = append(s.FeedTokens[:i], s.FeedTokens[(i+1):]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *StubTodoStore) GetWorkflowStates(projectID uint) []model.WorkflowState {
+	states := []model.WorkflowState{}
+	for _, state := range s.WorkflowStates {
+		if state.ProjectID == projectID {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+func (s *StubTodoStore) SetWorkflowStates(projectID uint, states []model.WorkflowState) error {
+	kept := []model.WorkflowState{}
+	for _, state := range s.WorkflowStates {
+		if state.ProjectID != projectID {
+			kept = append(kept, state)
+		}
+	}
+	for i, state := range states {
+		state.ProjectID = projectID
+		state.Position = i
+		kept = append(kept, state)
+	}
+	s.WorkflowStates = kept
+	return nil
+}
+
+func (s *StubTodoStore) GetTimeEntry(id uint) model.TimeEntry {
+	for _, entry := range s.TimeEntries {
+		if entry.ID == id {
+			return entry
+		}
+	}
+	return model.TimeEntry{}
+}
+
+func (s *StubTodoStore) GetRunningTimeEntry(user string) model.TimeEntry {
+	for _, entry := range s.TimeEntries {
+		if entry.User == user && entry.End == nil {
+			return entry
+		}
+	}
+	return model.TimeEntry{}
+}
+
+func (s *StubTodoStore) GetTimeEntries(query store.TimeEntryQuery) []model.TimeEntry {
+	entries := []model.TimeEntry{}
+	for _, entry := range s.TimeEntries {
+		if query.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (s *StubTodoStore) PostTimeEntry(entry model.TimeEntry) (model.TimeEntry, error) {
+	entry.ID = uint(len(s.TimeEntries) + 1)
+	s.TimeEntries = append(s.TimeEntries, entry)
+	return entry, nil
+}
+
+func (s *StubTodoStore) UpdateTimeEntry(entry model.TimeEntry) error {
+	for i := range s.TimeEntries {
+		if s.TimeEntries[i].ID == entry.ID {
+			s.TimeEntries[i] = entry
+		}
+	}
+	return nil
+}
+
+func (s *StubTodoStore) DeleteTimeEntry(entry model.TimeEntry) error {
+	for i := range s.TimeEntries {
+		if s.TimeEntries[i].ID == entry.ID {
+			s.TimeEntries = append(s.TimeEntries[:i], s.TimeEntries[(i+1):]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *StubTodoStore) GetDependency(id uint) model.TaskDependency {
+	for _, dependency := range s.Dependencies {
+		if dependency.ID == id {
+			return dependency
+		}
+	}
+	return model.TaskDependency{}
+}
+
+func (s *StubTodoStore) GetAllDependencies() []model.TaskDependency {
+	return s.Dependencies
+}
+
+func (s *StubTodoStore) GetDependencies(taskIDs, blockedByIDs []uint) []model.TaskDependency {
+	dependencies := []model.TaskDependency{}
+	for _, dependency := range s.Dependencies {
+		if containsID(taskIDs, dependency.TaskID) || containsID(blockedByIDs, dependency.BlockedByID) {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *StubTodoStore) PostDependency(dependency model.TaskDependency) error {
+	dependency.ID = uint(len(s.Dependencies) + 1)
+	s.Dependencies = append(s.Dependencies, dependency)
+	return nil
+}
+
+func (s *StubTodoStore) DeleteDependency(dependency model.TaskDependency) error {
+	for i := range s.Dependencies {
+		if s.Dependencies[i].ID == dependency.ID {
+			s.Dependencies = append(s.Dependencies[:i], s.Dependencies[(i+1):]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *StubTodoStore) GetTasksByID(ids []uint) []model.Task {
+	tasks := []model.Task{}
+	for _, task := range s.Tasks {
+		for _, id := range ids {
+			if task.ID == id {
+				tasks = append(tasks, task)
+				break
+			}
+		}
+	}
+	return tasks
+}
+
+func (s *StubTodoStore) GetComment(id uint) model.Comment {
+	for _, comment := range s.Comments {
+		if comment.ID == id {
+			return comment
+		}
+	}
+	return model.Comment{}
+}
+
+func (s *StubTodoStore) GetComments(taskID uint, offset, limit int) ([]model.Comment, int) {
+	comments := []model.Comment{}
+	for _, comment := range s.Comments {
+		if comment.TaskID == taskID {
+			comments = append(comments, comment)
+		}
+	}
+	total := len(comments)
+	if offset > total {
+		offset = total
+	}
+	if limit >= 0 && offset+limit < total {
+		total = offset + limit
+	}
+	return comments[offset:total], len(comments)
+}
+
+func (s *StubTodoStore) PostComment(comment model.Comment) error {
+	comment.ID = uint(len(s.Comments) + 1)
+	s.Comments = append(s.Comments, comment)
+	return nil
+}
+
+func (s *StubTodoStore) UpdateComment(comment model.Comment) error {
+	for i := range s.Comments {
+		if s.Comments[i].ID == comment.ID {
+			s.Comments[i] = comment
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *StubTodoStore) DeleteComment(comment model.Comment) error {
+	for i := range s.Comments {
+		if s.Comments[i].ID == comment.ID {
+			s.Comments = append(s.Comments[:i], s.Comments[(i+1):]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *StubTodoStore) GetAttachment(id uint) model.Attachment {
+	for _, attachment := range s.Attachments {
+		if attachment.ID == id {
+			return attachment
+		}
+	}
+	return model.Attachment{}
+}
+
+func (s *StubTodoStore) GetAttachments(taskID uint) []model.Attachment {
+	attachments := []model.Attachment{}
+	for _, attachment := range s.Attachments {
+		if attachment.TaskID == taskID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments
+}
+
+func (s *StubTodoStore) GetAllAttachments() []model.Attachment {
+	return s.Attachments
+}
+
+func (s *StubTodoStore) PostAttachment(attachment model.Attachment) error {
+	attachment.ID = uint(len(s.Attachments) + 1)
+	s.Attachments = append(s.Attachments, attachment)
+	return nil
+}
+
+func (s *StubTodoStore) DeleteAttachment(attachment model.Attachment) error {
+	for i := range s.Attachments {
+		if s.Attachments[i].ID == attachment.ID {
+			s.Attachments = append(s.Attachments[:i], s.Attachments[(i+1):]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (s *StubTodoStore) DeleteAll() error {
+	s.Projects = []model.Project{}
+	s.Tasks = []model.Task{}
+	s.FeedTokens = []model.FeedToken{}
+	s.WorkflowStates = []model.WorkflowState{}
+	s.TimeEntries = []model.TimeEntry{}
+	s.Dependencies = []model.TaskDependency{}
+	s.Comments = []model.Comment{}
+	s.Attachments = []model.Attachment{}
+	return nil
+}
+
+// Creates a TodoServer backed by a new database in a temporary directory
+func setupDatabaseServer(t *testing.T, options ...api.Option) (*api.TodoServer, *store.Database) {
+	t.Helper()
+	db := store.NewDatabaseConnection(filepath.Join(t.TempDir(), testdbfile))
+
+	return api.NewTodoServer(db, options...), db
+}
+
+// Sends a request with an optional JSON body to the server
+func doJSONRequest(t *testing.T, server *api.TodoServer, method, url string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		requestBody, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Error parsing request body to json: %s", err)
+		}
+		reader = bytes.NewBuffer(requestBody)
+	}
+
+	req, _ := http.NewRequest(method, url, reader)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
 // Converts a project struct to json
 func projectToJson(t *testing.T, project model.Project) string {
 	t.Helper()
@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const vtodoDocument = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//CalDAV Tool//EN\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:invoice-42@caldav.example\r\n" +
+	"SUMMARY:send invoices\\, reminders\r\n" +
+	"DESCRIPTION:all customers of\r\n" +
+	"  last month\r\n" +
+	"DUE;TZID=Europe/Berlin:20210610T140000\r\n" +
+	"PRIORITY:2\r\n" +
+	"STATUS:NEEDS-ACTION\r\n" +
+	"RRULE:FREQ=MONTHLY\r\n" +
+	"CATEGORIES:work,finance\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"END:VALARM\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:tax@caldav.example\r\n" +
+	"SUMMARY:tax return\r\n" +
+	"DUE;VALUE=DATE:20210731\r\n" +
+	"STATUS:COMPLETED\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:broken@caldav.example\r\n" +
+	"SUMMARY:broken\r\n" +
+	"DUE:tomorrow\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"SUMMARY:no uid\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+// Tests for route POST /projects/:projectName/import/ics
+func TestImportCalendar(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "office"})
+
+	importDocument := func(t *testing.T, document string) ical.ImportReport {
+		t.Helper()
+		req, _ := http.NewRequest("POST", "/projects/office/import/ics", strings.NewReader(document))
+		req.Header.Set("Content-Type", "text/calendar")
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := ical.ImportReport{}
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return report
+	}
+
+	t.Run("Import VTODO components", func(t *testing.T) {
+		report := importDocument(t, vtodoDocument)
+
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 0, report.Updated)
+		if assert.Len(t, report.Skipped, 2) {
+			assert.Equal(t, "broken@caldav.example", report.Skipped[0].UID)
+			assert.Contains(t, report.Skipped[0].Reason, "invalid DUE")
+			assert.Equal(t, "missing UID", report.Skipped[1].Reason)
+		}
+
+		task := db.GetTask("office", "send invoices, reminders")
+		assert.Equal(t, "invoice-42@caldav.example", task.ExternalUID)
+		assert.Equal(t, "all customers of last month", task.Notes)
+		assert.Equal(t, "2", task.Priority)
+		assert.Equal(t, "FREQ=MONTHLY", task.Recurrence)
+		assert.Equal(t, []string{"work", "finance"}, task.TagList())
+		assert.False(t, task.Done)
+		if assert.NotNil(t, task.Deadline) {
+			assert.Equal(t, "2021-06-10T12:00:00Z", task.Deadline.UTC().Format("2006-01-02T15:04:05Z07:00"))
+		}
+
+		assert.True(t, db.GetTask("office", "tax return").Done)
+	})
+
+	t.Run("Importing again updates instead of duplicating", func(t *testing.T) {
+		document := strings.Replace(vtodoDocument, "STATUS:NEEDS-ACTION", "STATUS:COMPLETED", 1)
+		report := importDocument(t, document)
+
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, 2, report.Updated)
+		assert.Len(t, db.GetAllProjectTasks(db.GetProject("office")), 2)
+		assert.True(t, db.GetTask("office", "send invoices, reminders").Done)
+	})
+
+	t.Run("Imported tasks keep their UID in the calendar feed", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/office/calendar/tokens", nil)
+		response := map[string]string{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		w = doJSONRequest(t, server, "GET", response["url"], nil)
+		assert.Contains(t, w.Body.String(), "UID:invoice-42@caldav.example\r\n")
+		assert.Contains(t, w.Body.String(), "CATEGORIES:work,finance\r\n")
+	})
+
+	t.Run("Import as multipart upload", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", "export.ics")
+		part.Write([]byte(strings.Replace(vtodoDocument, "tax@caldav.example", "tax-2022@caldav.example", 1)))
+		writer.Close()
+
+		req, _ := http.NewRequest("POST", "/projects/office/import/ics", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		report := ical.ImportReport{}
+		json.Unmarshal(w.Body.Bytes(), &report)
+		assert.Equal(t, 1, report.Updated)
+		// the new UID clashes with the name of the existing task
+		assert.Equal(t, "task name already exists", report.Skipped[0].Reason)
+	})
+
+	t.Run("Invalid document returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/office/import/ics", "no calendar")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Nonexistent project returns http.StatusNotFound", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/home/import/ics", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// Fails every PostTask after the first posts
+type postLimitStore struct {
+	store.TodoStore
+	posts *int
+}
+
+func (s postLimitStore) Transaction(fn func(t store.TodoStore) error) error {
+	return s.TodoStore.Transaction(func(tx store.TodoStore) error {
+		return fn(postLimitStore{tx, s.posts})
+	})
+}
+
+func (s postLimitStore) PostTask(task model.Task) error {
+	if *s.posts <= 0 {
+		return errors.New("disk full")
+	}
+	*s.posts--
+	return s.TodoStore.PostTask(task)
+}
+
+// Tests that failed imports do not leave some of the tasks behind
+func TestImportCalendarRollback(t *testing.T) {
+	_, db := setupDatabaseServer(t)
+	db.PostProject("office")
+	project := db.GetProject("office")
+
+	posts := 1
+	report, err := ical.Import(postLimitStore{db, &posts}, project, strings.NewReader(vtodoDocument))
+
+	assert.Error(t, err)
+	assert.Zero(t, report.Created)
+	assert.Empty(t, db.GetAllProjectTasks(project))
+}
@@ -0,0 +1,280 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/grpcapi"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Parses the JSON lines written by a logger
+func parseLogLines(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	lines := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("Error parsing log line %s: %s", line, err)
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+// Returns the first line with the message
+func findLogLine(lines []map[string]interface{}, msg string) map[string]interface{} {
+	for _, line := range lines {
+		if line["msg"] == msg {
+			return line
+		}
+	}
+	return nil
+}
+
+// Tests the request ID and logging middleware
+func TestRequestLogging(t *testing.T) {
+	logs := &bytes.Buffer{}
+	server, db := setupDatabaseServer(t, api.WithLogger(logging.New(logs, logging.LevelInfo)))
+
+	t.Run("Requests get an ID", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/", nil)
+		assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+
+		w = doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", map[string]string{"X-Request-ID": "abc-123"})
+		assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+
+		w = doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", map[string]string{"X-Request-ID": "no spaces allowed"})
+		assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("Requests are logged with their ID", func(t *testing.T) {
+		logs.Reset()
+		doClientRequest(t, server, "GET", "/projects/unknown", "192.0.2.1:1234", map[string]string{"X-Request-ID": "abc-123"})
+
+		line := findLogLine(parseLogLines(t, logs), "request")
+		assert.NotNil(t, line)
+		assert.Equal(t, "info", line["level"])
+		assert.Equal(t, "abc-123", line["request_id"])
+		assert.Equal(t, "GET", line["method"])
+		assert.Equal(t, "/projects/unknown", line["path"])
+		assert.Equal(t, "/projects/:projectName", line["route"])
+		assert.Equal(t, float64(http.StatusNotFound), line["status"])
+		assert.Equal(t, "192.0.2.1", line["client_ip"])
+	})
+
+	t.Run("Panics are logged and return http.StatusInternalServerError", func(t *testing.T) {
+		logs.Reset()
+		server.Router.GET("/panic", func(c *gin.Context) { panic("boom") })
+		w := doJSONRequest(t, server, "GET", "/panic", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		line := findLogLine(parseLogLines(t, logs), "panic")
+		assert.Equal(t, "boom", line["error"])
+		assert.Equal(t, w.Header().Get("X-Request-ID"), line["request_id"])
+	})
+
+	t.Run("Store errors are logged but not sent to the client", func(t *testing.T) {
+		sqlDB, _ := db.DB.DB()
+		sqlDB.Close()
+		logs.Reset()
+
+		w := doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{"message": "internal server error"}`, w.Body.String())
+
+		lines := parseLogLines(t, logs)
+		for _, msg := range []string{"query failed", "internal error", "request"} {
+			line := findLogLine(lines, msg)
+			if assert.NotNil(t, line, msg) {
+				assert.Equal(t, "error", line["level"], msg)
+				assert.Equal(t, w.Header().Get("X-Request-ID"), line["request_id"], msg)
+			}
+		}
+		// The failed lookup of the project is swallowed by the store, but logged
+		assert.Contains(t, findLogLine(lines, "query failed")["sql"], "SELECT * FROM `projects`")
+	})
+}
+
+// Fails every write of tasks and time entries
+type failingStore struct {
+	store.TodoStore
+}
+
+func (s *failingStore) WithContext(ctx context.Context) store.TodoStore {
+	return &failingStore{s.TodoStore.WithContext(ctx)}
+}
+
+func (s *failingStore) Transaction(fn func(t store.TodoStore) error) error {
+	return s.TodoStore.Transaction(func(tx store.TodoStore) error {
+		return fn(&failingStore{tx})
+	})
+}
+
+func (s *failingStore) PostTask(task model.Task) error {
+	return errors.New("disk full")
+}
+
+func (s *failingStore) PostTimeEntry(entry model.TimeEntry) (model.TimeEntry, error) {
+	return entry, errors.New("disk full")
+}
+
+func (s *failingStore) DeleteTask(task model.Task) error {
+	return errors.New("disk full")
+}
+
+// Tests that imports, time entries and deletes tell invalid requests from store errors
+func TestStoreErrorsAreInternal(t *testing.T) {
+	db := store.NewDatabaseConnection(filepath.Join(t.TempDir(), testdbfile))
+	server := api.NewTodoServer(&failingStore{db}, api.WithLogger(logging.New(&bytes.Buffer{}, logging.LevelInfo)))
+	db.PostProject("homework")
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: db.GetProject("homework").ID})
+
+	importDocument := func(document string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/projects/homework/import/ics", strings.NewReader(document))
+		req.Header.Set("Content-Type", "text/calendar")
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := importDocument(vtodoDocument)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"message": "internal server error"}`, w.Body.String())
+	w = importDocument("no calendar")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSONRequest(t, server, "POST", "/projects/homework/tasks/math/time",
+		map[string]string{"start": "2021-06-01T09:00:00Z", "end": "2021-06-01T10:00:00Z"})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"message": "internal server error"}`, w.Body.String())
+	w = doJSONRequest(t, server, "POST", "/projects/homework/tasks/math/time",
+		map[string]string{"start": "2021-06-01T10:00:00Z", "end": "2021-06-01T09:00:00Z"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/math", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"message": "internal server error"}`, w.Body.String())
+}
+
+// Tests that GraphQL and gRPC send store errors without their details
+func TestStoreErrorsAreInternalInAllAPIs(t *testing.T) {
+	db := store.NewDatabaseConnection(filepath.Join(t.TempDir(), testdbfile))
+	db.PostProject("homework")
+	var logs bytes.Buffer
+	server := api.NewTodoServer(&failingStore{db}, api.WithLogger(logging.New(&logs, logging.LevelInfo)))
+
+	t.Run("GraphQL", func(t *testing.T) {
+		logs.Reset()
+		w := doJSONRequest(t, server, "POST", "/graphql", map[string]string{
+			"query": `mutation { createTask(project: "homework", input: {name: "math"}) { name } }`,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "internal server error")
+		assert.NotContains(t, w.Body.String(), "disk full")
+		assert.Contains(t, logs.String(), "disk full")
+		assert.Contains(t, logs.String(), w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("gRPC", func(t *testing.T) {
+		logs.Reset()
+		grpcServer := grpc.NewServer(server.GRPCOptions()...)
+		grpcapi.RegisterTodoServiceServer(grpcServer, grpcapi.NewServer(&failingStore{db}, nil))
+		todo := dialGRPC(t, grpcServer)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "call-42")
+		var header metadata.MD
+		_, err := todo.CreateTask(ctx, &grpcapi.CreateTaskRequest{Project: "homework", Task: &grpcapi.TaskInput{Name: "math"}}, grpc.Header(&header))
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "internal server error", status.Convert(err).Message())
+		assert.Equal(t, []string{"call-42"}, header.Get("x-request-id"))
+		assert.Contains(t, logs.String(), `"request_id":"call-42"`)
+		assert.Contains(t, logs.String(), "disk full")
+	})
+}
+
+// Tests levels and fields of loggers
+func TestLogger(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger := logging.New(logs, logging.LevelWarn).With("component", "test")
+
+	logger.Info("hidden")
+	logger.Warn("shown", "count", 3, "error", context.Canceled)
+
+	lines := parseLogLines(t, logs)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "warn", lines[0]["level"])
+	assert.Equal(t, "shown", lines[0]["msg"])
+	assert.Equal(t, "test", lines[0]["component"])
+	assert.Equal(t, float64(3), lines[0]["count"])
+	assert.Equal(t, "context canceled", lines[0]["error"])
+	assert.Contains(t, logs.String(), `{"time":`)
+
+	level, err := logging.ParseLevel("DEBUG")
+	assert.NoError(t, err)
+	assert.Equal(t, logging.LevelDebug, level)
+	_, err = logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+// Tests that the gorm adapter logs slow queries with the logger of the context
+func TestGormLogger(t *testing.T) {
+	logs := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.New(logs, logging.LevelInfo).With("request_id", "abc-123"))
+	gormLogger := logging.NewGormLogger(100 * time.Millisecond)
+	query := func() (string, int64) { return "SELECT * FROM tasks", 2 }
+
+	gormLogger.Trace(ctx, time.Now(), query, nil)
+	assert.Empty(t, logs.String())
+
+	gormLogger.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+	line := findLogLine(parseLogLines(t, logs), "slow query")
+	if assert.NotNil(t, line) {
+		assert.Equal(t, "warn", line["level"])
+		assert.Equal(t, "abc-123", line["request_id"])
+		assert.Equal(t, "SELECT * FROM tasks", line["sql"])
+		assert.Equal(t, float64(2), line["rows"])
+	}
+}
+
+// Tests that logged SQL keeps its placeholders instead of the values
+func TestGormLoggerHidesValues(t *testing.T) {
+	_, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+
+	logs := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.New(logs, logging.LevelDebug))
+	err := db.WithContext(ctx).PostTask(model.Task{Name: "math", Priority: "1", Notes: "secret notes", ProjectID: db.GetProject("homework").ID})
+	assert.NoError(t, err)
+
+	inserts := []string{}
+	for _, line := range parseLogLines(t, logs) {
+		if sql, _ := line["sql"].(string); strings.HasPrefix(sql, "INSERT INTO `tasks`") {
+			inserts = append(inserts, sql)
+		}
+	}
+	if assert.Len(t, inserts, 1) {
+		assert.Contains(t, inserts[0], "VALUES (?,?,?")
+	}
+	assert.NotContains(t, logs.String(), "secret notes")
+}
@@ -0,0 +1,117 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/markdown"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for the Markdown export and import routes
+func TestMarkdown(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+	doJSONRequest(t, server, "POST", "/projects/homework/tasks", map[string]string{
+		"name":     "math",
+		"priority": "1",
+		"deadline": "2021-06-10 12:00:00 +0000 UTC",
+	})
+	doJSONRequest(t, server, "POST", "/projects/homework/tasks", map[string]string{
+		"name":     "biology (chapter 2)",
+		"priority": "2",
+		"deadline": "2021-06-12 12:00:00 +0000 UTC",
+	})
+
+	importChecklist := func(t *testing.T, checklist string) (int, markdown.ImportReport) {
+		t.Helper()
+		req, _ := http.NewRequest("POST", "/projects/homework/tasks.md", strings.NewReader(checklist))
+		req.Header.Set("Content-Type", "text/markdown")
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+
+		report := markdown.ImportReport{}
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return w.Code, report
+	}
+
+	t.Run("Import updates done states and creates nested tasks", func(t *testing.T) {
+		code, report := importChecklist(t, "# Homework\n\n"+
+			"Some text that is ignored\n\n"+
+			"- [x] math\n"+
+			"  - [ ] exercise 1 (priority: 3, due: 2021-06-09)\n"+
+			"    - [x] part a\n"+
+			"  - [x] exercise 2\n"+
+			"* [ ] biology (chapter 2)\n"+
+			"- [X] physics (Newton)\n")
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, report.Updated)
+		assert.Equal(t, 4, report.Created)
+
+		math := db.GetTask("homework", "math")
+		exercise := db.GetTask("homework", "exercise 1")
+		assert.True(t, math.Done)
+		assert.Equal(t, "1", math.Priority)
+		assert.Equal(t, math.ID, *exercise.ParentID)
+		assert.Equal(t, "3", exercise.Priority)
+		assert.Equal(t, "2021-06-09", exercise.Deadline.Format("2006-01-02"))
+		assert.Equal(t, exercise.ID, *db.GetTask("homework", "part a").ParentID)
+		assert.Equal(t, math.ID, *db.GetTask("homework", "exercise 2").ParentID)
+		assert.False(t, db.GetTask("homework", "biology (chapter 2)").Done)
+		assert.True(t, db.GetTask("homework", "physics (Newton)").Done)
+	})
+
+	t.Run("Export renders nested checklist", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/tasks.md", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "# homework\n\n"+
+			"- [x] math (priority: 1, due: 2021-06-10)\n"+
+			"  - [ ] exercise 1 (priority: 3, due: 2021-06-09)\n"+
+			"    - [x] part a\n"+
+			"  - [x] exercise 2\n"+
+			"- [ ] biology (chapter 2) (priority: 2, due: 2021-06-12)\n"+
+			"- [x] physics (Newton)\n", w.Body.String())
+	})
+
+	t.Run("Exported checklist can be imported again", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/tasks.md", nil)
+		code, report := importChecklist(t, w.Body.String())
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, 6, report.Updated)
+		assert.Equal(t, "biology (chapter 2)", db.GetTask("homework", "biology (chapter 2)").Name)
+	})
+
+	t.Run("Invalid due date returns http.StatusBadRequest", func(t *testing.T) {
+		code, _ := importChecklist(t, "- [ ] chemistry (due: tomorrow)\n")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Tasks listed twice return http.StatusBadRequest", func(t *testing.T) {
+		for _, checklist := range []string{
+			"- [ ] reading\n  - [ ] essay\n    - [ ] reading\n",
+			"- [ ] reading\n  - [ ] reading\n",
+			"- [ ] reading\n- [x] reading\n",
+		} {
+			req, _ := http.NewRequest("POST", "/projects/homework/tasks.md", strings.NewReader(checklist))
+			w := httptest.NewRecorder()
+			server.Router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, checklist)
+			assert.Contains(t, w.Body.String(), "already listed in line 1", checklist)
+		}
+		assert.Empty(t, db.GetTask("homework", "reading").Name)
+	})
+
+	t.Run("Deleting a task turns its subtasks into top level tasks", func(t *testing.T) {
+		doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/exercise 1", nil)
+		assert.Nil(t, db.GetTask("homework", "part a").ParentID)
+	})
+}
@@ -0,0 +1,139 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+// Parts of the OpenAPI document checked by the tests
+type openAPIDocument struct {
+	OpenAPI string                                 `json:"openapi"`
+	Paths   map[string]map[string]openAPIOperation `json:"paths"`
+}
+
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+	Parameters  []struct {
+		Ref  string `json:"$ref"`
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+}
+
+// Matches gin path parameters like :projectName
+var ginParam = regexp.MustCompile(`:([A-Za-z]+)`)
+
+// Matches OpenAPI path parameters like {projectName}
+var openAPIParam = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// Matches references to components
+var componentRef = regexp.MustCompile(`"\$ref": "#/components/([A-Za-z]+)/([A-Za-z]+)"`)
+
+func parseOpenAPISpec(t *testing.T) openAPIDocument {
+	t.Helper()
+	document := openAPIDocument{}
+	if err := json.Unmarshal(api.OpenAPISpec, &document); err != nil {
+		t.Fatalf("Error parsing openapi.json: %s", err)
+	}
+	return document
+}
+
+// Tests route GET /openapi.json
+func TestGetOpenAPISpec(t *testing.T) {
+	server, _ := setupDatabaseServer(t)
+
+	w := doJSONRequest(t, server, "GET", "/openapi.json", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(api.OpenAPISpec), w.Body.String())
+	assert.Equal(t, "3.0.3", parseOpenAPISpec(t).OpenAPI)
+
+	t.Run("Docs are disabled by default", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/docs", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Docs UI", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithDocsUI())
+		w := doJSONRequest(t, server, "GET", "/docs", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `spec-url="/openapi.json"`)
+		assert.Contains(t, w.Body.String(), "redoc@"+handler.RedocVersion+"/")
+		assert.NotContains(t, w.Body.String(), "latest")
+	})
+}
+
+// Fails if routes are added to NewTodoServer without documenting them or
+// documented routes are removed
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating blob store: %s", err)
+	}
+	server, _ := setupDatabaseServer(t, api.WithAdminToken(adminToken), api.WithDocsUI(), api.WithBlobStore(blobs))
+	document := parseOpenAPISpec(t)
+
+	routes := []string{}
+	for _, route := range server.Router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		routes = append(routes, strings.ToLower(route.Method)+" "+path)
+	}
+
+	documented := []string{}
+	for path, operations := range document.Paths {
+		for method := range operations {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented)
+}
+
+// Checks path parameters, operation IDs and references of the document
+func TestOpenAPISpecIsConsistent(t *testing.T) {
+	document := parseOpenAPISpec(t)
+
+	raw := struct {
+		Components map[string]map[string]json.RawMessage `json:"components"`
+	}{}
+	json.Unmarshal(api.OpenAPISpec, &raw)
+
+	operationIDs := map[string]bool{}
+	for path, operations := range document.Paths {
+		for method, operation := range operations {
+			assert.NotEmpty(t, operation.OperationID, "%s %s has no operationId", method, path)
+			assert.False(t, operationIDs[operation.OperationID], "operationId %s is duplicated", operation.OperationID)
+			operationIDs[operation.OperationID] = true
+
+			declared := map[string]bool{}
+			for _, parameter := range operation.Parameters {
+				if parameter.Ref != "" {
+					name := strings.TrimPrefix(parameter.Ref, "#/components/parameters/")
+					json.Unmarshal(raw.Components["parameters"][name], &parameter)
+				}
+				if parameter.In == "path" {
+					declared[parameter.Name] = true
+				}
+			}
+			for _, match := range openAPIParam.FindAllStringSubmatch(path, -1) {
+				assert.True(t, declared[match[1]], "%s %s does not declare parameter %s", method, path, match[1])
+			}
+		}
+	}
+
+	for _, match := range componentRef.FindAllStringSubmatch(string(api.OpenAPISpec), -1) {
+		_, ok := raw.Components[match[1]][match[2]]
+		assert.True(t, ok, "unknown component %s/%s", match[1], match[2])
+	}
+}
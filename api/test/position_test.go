@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/rank"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns the names of the tasks of a project in the listed order
+func getTaskNames(t *testing.T, w []byte) []string {
+	t.Helper()
+	tasks := []model.Task{}
+	if err := json.Unmarshal(w, &tasks); err != nil {
+		t.Fatalf("Error parsing tasks: %s", err)
+	}
+
+	names := []string{}
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+// Tests route PUT /projects/:projectName/tasks/:taskName/position
+func TestPutTaskPosition(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	project := db.GetProject("homework")
+	for _, name := range []string{"math", "biology", "history", "art"} {
+		db.PostTask(model.Task{Name: name, Priority: "1", ProjectID: project.ID})
+	}
+
+	listTasks := func(t *testing.T) []string {
+		t.Helper()
+		w := doJSONRequest(t, server, "GET", "/projects/homework/tasks", nil)
+		return getTaskNames(t, w.Body.Bytes())
+	}
+
+	t.Run("New tasks are added at the end", func(t *testing.T) {
+		assert.Equal(t, []string{"math", "biology", "history", "art"}, listTasks(t))
+	})
+
+	t.Run("Move before a task", func(t *testing.T) {
+		rankOfBiology := db.GetTask("homework", "biology").Rank
+
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/art/position", map[string]string{"before": "math"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"art", "math", "biology", "history"}, listTasks(t))
+		assert.Equal(t, rankOfBiology, db.GetTask("homework", "biology").Rank)
+	})
+
+	t.Run("Move after a task", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/art/position", map[string]string{"after": "history"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"math", "biology", "history", "art"}, listTasks(t))
+
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/position", map[string]string{"after": "biology"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"biology", "math", "history", "art"}, listTasks(t))
+	})
+
+	t.Run("Equal ranks are ranked again", func(t *testing.T) {
+		// Tasks with equal ranks are listed by ID
+		for _, name := range []string{"math", "history"} {
+			task := db.GetTask("homework", name)
+			task.Rank = db.GetTask("homework", "biology").Rank
+			db.UpdateTask(task)
+		}
+
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/art/position", map[string]string{"after": "math"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"math", "art", "biology", "history"}, listTasks(t))
+	})
+
+	t.Run("Missing or both neighbours return http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/art/position", map[string]string{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/art/position", map[string]string{"before": "math", "after": "math"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown neighbour returns http.StatusNotFound", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/art/position", map[string]string{"before": "music"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRank(t *testing.T) {
+	t.Run("Between", func(t *testing.T) {
+		cases := [][2]string{{"", ""}, {"", "i"}, {"i", ""}, {"a", "b"}, {"az", "b"}, {"a", "a1"}, {"zz", ""}}
+		for _, c := range cases {
+			between := rank.Between(c[0], c[1])
+			assert.Greater(t, between, c[0])
+			if c[1] != "" {
+				assert.Less(t, between, c[1])
+			}
+		}
+	})
+
+	t.Run("No rank between equal ranks", func(t *testing.T) {
+		assert.Equal(t, "", rank.Between("i", "i"))
+		assert.Equal(t, "", rank.Between("i", "i0"))
+	})
+
+	t.Run("After increments the last digit", func(t *testing.T) {
+		assert.Equal(t, "b", rank.After("a"))
+		assert.Equal(t, "a2", rank.After("a1"))
+		assert.Greater(t, rank.After("az"), "az")
+		assert.Greater(t, rank.After("zz"), "zz")
+	})
+
+	t.Run("Appended ranks stay short", func(t *testing.T) {
+		last := ""
+		for i := 0; i < 100; i++ {
+			next := rank.After(last)
+			assert.Greater(t, next, last)
+			last = next
+		}
+		assert.LessOrEqual(t, len(last), 7)
+	})
+
+	t.Run("Sequence is ascending", func(t *testing.T) {
+		ranks := rank.Sequence(100)
+		for i := 1; i < len(ranks); i++ {
+			assert.Less(t, ranks[i-1], ranks[i])
+		}
+	})
+}
@@ -0,0 +1,228 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/grpcapi"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Sends a request from a client with the given headers and remote address
+func doClientRequest(t *testing.T, server *api.TodoServer, method, url, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(method, url, nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+// Tests the rate limit middleware
+func TestRateLimit(t *testing.T) {
+	hourly := ratelimit.Limit{Requests: 2, Per: time.Hour}
+
+	t.Run("Requests over the limit return http.StatusTooManyRequests", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithRateLimit("/", hourly))
+
+		w := doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1800", w.Header().Get("RateLimit-Reset"))
+
+		doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", nil)
+		w = doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1800", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"message": "rate limit exceeded"}`, w.Body.String())
+
+		// Unknown routes count as well
+		w = doClientRequest(t, server, "GET", "/unknown", "192.0.2.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Clients are told apart by IP address", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithRateLimit("/", hourly))
+
+		for _, remoteAddr := range []string{"192.0.2.1:1234", "192.0.2.2:1234"} {
+			for i := 0; i < 2; i++ {
+				w := doClientRequest(t, server, "GET", "/projects/", remoteAddr, nil)
+				assert.Equal(t, http.StatusOK, w.Code, remoteAddr)
+			}
+		}
+		w := doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:5678", nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Rotating headers don't bypass the limit", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithRateLimit("/", hourly))
+		headers := []map[string]string{
+			{"X-User": "alice"},
+			{"X-User": "bob"},
+			{"X-User": "carol"},
+			{"Authorization": "Bearer secret"},
+			{"X-Forwarded-For": "198.51.100.1"},
+		}
+
+		codes := []int{}
+		for _, header := range headers {
+			codes = append(codes, doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", header).Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+	})
+
+	t.Run("Clients behind trusted proxies are told apart by X-Forwarded-For", func(t *testing.T) {
+		proxies, err := ratelimit.ParseProxies("10.0.0.1, 192.168.0.0/16")
+		assert.NoError(t, err)
+		server, _ := setupDatabaseServer(t, api.WithRateLimit("/", hourly), api.WithTrustedProxies(proxies...))
+
+		for _, forwarded := range []string{"198.51.100.1", "198.51.100.2, 192.168.1.1", "203.0.113.9, 198.51.100.1"} {
+			w := doClientRequest(t, server, "GET", "/projects/", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": forwarded})
+			assert.Equal(t, http.StatusOK, w.Code, forwarded)
+		}
+		// The third request came from 198.51.100.1, the address before it was sent by the client
+		w := doClientRequest(t, server, "GET", "/projects/", "192.168.5.5:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		// Other peers can't choose their address
+		for i := 0; i < 2; i++ {
+			w = doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.3"})
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		w = doClientRequest(t, server, "GET", "/projects/", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.4"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Route groups have their own limits", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t,
+			api.WithRateLimit("/", ratelimit.Limit{Requests: 5, Per: time.Hour}),
+			api.WithRateLimit("POST /projects/", ratelimit.Limit{Requests: 1, Per: time.Hour}))
+
+		w := doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+		w = doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "shopping"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		// Both POST requests count for the group of all routes
+		w = doJSONRequest(t, server, "GET", "/projects/", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "5", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("Clients are told apart by the key of the server", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithRateLimit("/", hourly),
+			api.WithRateLimitKey(func(r *http.Request) string { return r.Header.Get("X-Verified-User") }))
+
+		for _, remoteAddr := range []string{"192.0.2.1:1234", "192.0.2.2:1234"} {
+			w := doClientRequest(t, server, "GET", "/projects/", remoteAddr, map[string]string{"X-Verified-User": "alice"})
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		w := doClientRequest(t, server, "GET", "/projects/", "192.0.2.3:1234", map[string]string{"X-Verified-User": "alice"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		// Requests without key fall back to the IP address
+		w = doClientRequest(t, server, "GET", "/projects/", "192.0.2.3:1234", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("gRPC calls are limited as well", func(t *testing.T) {
+		todo, _ := setupGRPC(t, api.WithRateLimit("POST /todo.v1.TodoService/CreateProject", ratelimit.Limit{Requests: 1, Per: time.Hour}))
+		ctx := context.Background()
+
+		var header metadata.MD
+		_, err := todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "homework"}, grpc.Header(&header))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+
+		_, err = todo.CreateProject(ctx, &grpcapi.CreateProjectRequest{Name: "shopping"}, grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, []string{"3600"}, header.Get("retry-after"))
+
+		// Other methods are not limited by the group
+		_, err = todo.ListProjects(ctx, &grpcapi.ListProjectsRequest{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Routes without limits send no headers", func(t *testing.T) {
+		server, _ := setupDatabaseServer(t, api.WithRateLimit("/admin", hourly))
+		w := doJSONRequest(t, server, "GET", "/projects/", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+}
+
+// Tests the refill of token buckets
+func TestLimiter(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{Requests: 2, Per: time.Minute})
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, limiter.Allow("alice", now).Allowed)
+	assert.True(t, limiter.Allow("alice", now).Allowed)
+
+	result := limiter.Allow("alice", now.Add(10*time.Second))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+	assert.Equal(t, 50*time.Second, result.Reset)
+
+	result = limiter.Allow("alice", now.Add(30*time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Buckets never hold more than the limit
+	result = limiter.Allow("alice", now.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.Reset)
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("60/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 60, Per: time.Minute}, limit)
+
+	limit, err = ratelimit.ParseLimit("10/s")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 10, Per: time.Second}, limit)
+
+	for _, value := range []string{"", "60", "0/1m", "x/1m", "60/", "60/0s", "60/-1m"} {
+		_, err := ratelimit.ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	proxies, err := ratelimit.ParseProxies("10.0.0.1, 192.168.0.0/16,::1")
+	assert.NoError(t, err)
+	if assert.Len(t, proxies, 3) {
+		assert.Equal(t, "10.0.0.1/32", proxies[0].String())
+		assert.Equal(t, "192.168.0.0/16", proxies[1].String())
+		assert.Equal(t, "::1/128", proxies[2].String())
+	}
+
+	proxies, err = ratelimit.ParseProxies("")
+	assert.NoError(t, err)
+	assert.Empty(t, proxies)
+
+	for _, value := range []string{"localhost", "10.0.0.0/33", "10.0.0.1,x"} {
+		_, err := ratelimit.ParseProxies(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,134 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for route GET /search
+func TestSearch(t *testing.T) {
+	server, _ := setupDatabaseServer(t)
+
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "accounting"})
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "household"})
+	doJSONRequest(t, server, "POST", "/projects/accounting/tasks", map[string]string{
+		"name":     "send invoices",
+		"priority": "1",
+		"deadline": "2021-06-10 12:00:00 +0000 UTC",
+		"notes":    "all customers of last month",
+	})
+	doJSONRequest(t, server, "POST", "/projects/household/tasks", map[string]string{
+		"name":     "pay bills",
+		"priority": "2",
+		"deadline": "2021-07-10 12:00:00 +0000 UTC",
+		"notes":    "electricity invoice is overdue",
+	})
+	doJSONRequest(t, server, "PUT", "/projects/household/tasks/pay bills/complete", nil)
+
+	search := func(t *testing.T, query string) []model.SearchResult {
+		t.Helper()
+		w := doJSONRequest(t, server, "GET", "/search?"+query, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		results := []model.SearchResult{}
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Fatalf("Error parsing search results: %s", err)
+		}
+		return results
+	}
+
+	t.Run("Find tasks by name and notes ranked by relevance", func(t *testing.T) {
+		results := search(t, "q=invoice")
+
+		if assert.Len(t, results, 2) {
+			assert.Equal(t, "send invoices", results[0].Name)
+			assert.Equal(t, "accounting", results[0].Project)
+			assert.Contains(t, results[0].Highlight, "<mark>")
+			assert.Equal(t, "pay bills", results[1].Name)
+			assert.Contains(t, results[1].Snippet, "<mark>invoice</mark>")
+		}
+	})
+
+	t.Run("Prefix matching finds projects", func(t *testing.T) {
+		results := search(t, "q=acc&type=project")
+
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "project", results[0].Type)
+			assert.Equal(t, "accounting", results[0].Name)
+		}
+	})
+
+	t.Run("Filter by project", func(t *testing.T) {
+		results := search(t, "q=invoice&project=household")
+
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "pay bills", results[0].Name)
+		}
+	})
+
+	t.Run("Filter by done state", func(t *testing.T) {
+		results := search(t, "q=invoice&done=false")
+
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "send invoices", results[0].Name)
+		}
+	})
+
+	t.Run("Filter by deadline", func(t *testing.T) {
+		results := search(t, "q=invoice&due_after=2021-07-01")
+
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "pay bills", results[0].Name)
+		}
+	})
+
+	t.Run("Index follows updates and deletes", func(t *testing.T) {
+		doJSONRequest(t, server, "PUT", "/projects/accounting/tasks/send invoices", map[string]string{
+			"name":     "send reminders",
+			"priority": "1",
+			"deadline": "2021-06-10 12:00:00 +0000 UTC",
+		})
+		doJSONRequest(t, server, "DELETE", "/projects/household/tasks/pay bills", nil)
+
+		assert.Len(t, search(t, "q=invoice"), 0)
+		assert.Len(t, search(t, "q=reminders"), 1)
+	})
+
+	t.Run("Missing query returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/search", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid filter returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/search?q=invoice&done=maybe", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// Databases written by a build with FTS5 stay writable without it
+func TestSearchWithoutIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), testdbfile)
+	db := store.NewDatabaseConnection(path)
+	db.PostProject("accounting")
+
+	// Without FTS5 the trigger of the index fails every insert
+	err := db.DB.Exec(`CREATE TRIGGER IF NOT EXISTS search_tasks_insert AFTER INSERT ON tasks BEGIN
+		INSERT INTO search_index(name, notes, kind, ref_id, project_id)
+		SELECT new.name, new.notes, 'task', new.id, new.project_id;
+	END`).Error
+	assert.NoError(t, err)
+
+	db = store.NewDatabaseConnection(path)
+	err = db.PostTask(model.Task{Name: "send invoices", Priority: "1", ProjectID: db.GetProject("accounting").ID})
+	assert.NoError(t, err)
+
+	results, err := db.Search(store.SearchQuery{Text: "invoices"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
@@ -0,0 +1,92 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/stats"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Tests route GET /projects/:projectName/stats
+func TestGetProjectStats(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	project := db.GetProject("homework")
+
+	day := func(date string) *time.Time {
+		parsed, _ := time.Parse("2006-01-02 15:04", date)
+		return &parsed
+	}
+	tomorrow := time.Now().Add(24 * time.Hour)
+	tasks := []model.Task{
+		{Name: "math", Priority: "1", Deadline: day("2021-06-03 12:00"), Done: true, CompletedAt: day("2021-06-02 10:00")},
+		{Name: "biology", Priority: "high", Deadline: day("2021-06-03 12:00")},
+		{Name: "history", Priority: "5", Deadline: &tomorrow, Done: true, CompletedAt: day("2021-06-03 18:00")},
+		{Name: "art", Priority: ""},
+	}
+	created := []string{"2021-06-01 08:00", "2021-06-01 09:00", "2021-06-02 09:00", "2021-06-03 23:59"}
+	for i, task := range tasks {
+		task.ProjectID = project.ID
+		task.Model = gorm.Model{CreatedAt: *day(created[i])}
+		db.PostTask(task)
+	}
+
+	getStats := func(t *testing.T, url string) stats.Stats {
+		t.Helper()
+		w := doJSONRequest(t, server, "GET", url, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		result := stats.Stats{}
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+
+	t.Run("Counts", func(t *testing.T) {
+		result := getStats(t, "/projects/homework/stats")
+
+		assert.Equal(t, stats.Counts{Total: 4, Open: 2, Done: 2, Overdue: 1}, result.Counts)
+		assert.Equal(t, 50.0, result.CompletionPercent)
+		assert.Equal(t, []stats.PriorityStats{
+			{Priority: 0, Counts: stats.Counts{Total: 1, Open: 1}},
+			{Priority: 1, Counts: stats.Counts{Total: 2, Open: 1, Done: 1, Overdue: 1}},
+			{Priority: 5, Counts: stats.Counts{Total: 1, Done: 1}},
+		}, result.ByPriority)
+	})
+
+	t.Run("Burndown", func(t *testing.T) {
+		result := getStats(t, "/projects/homework/stats?to=2021-06-04")
+
+		assert.Equal(t, []stats.Day{
+			{Date: "2021-06-01", Scope: 2, Done: 0, Remaining: 2},
+			{Date: "2021-06-02", Scope: 3, Done: 1, Remaining: 2},
+			{Date: "2021-06-03", Scope: 4, Done: 2, Remaining: 2},
+			{Date: "2021-06-04", Scope: 4, Done: 2, Remaining: 2},
+		}, result.Burndown)
+	})
+
+	t.Run("Burndown with range", func(t *testing.T) {
+		result := getStats(t, "/projects/homework/stats?from=2021-06-02&to=2021-06-02")
+		assert.Equal(t, []stats.Day{{Date: "2021-06-02", Scope: 3, Done: 1, Remaining: 2}}, result.Burndown)
+	})
+
+	t.Run("Invalid ranges return http.StatusBadRequest", func(t *testing.T) {
+		for _, url := range []string{
+			"/projects/homework/stats?from=2021-06-03&to=2021-06-01",
+			"/projects/homework/stats?from=2020-01-01&to=2021-06-01",
+			"/projects/homework/stats?from=yesterday",
+		} {
+			w := doJSONRequest(t, server, "GET", url, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		}
+	})
+
+	t.Run("Unknown project returns http.StatusNotFound", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/garden/stats", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -19,7 +19,7 @@ func setupTaskTests() (server *api.TodoServer, store *StubTodoStore) {
 	deletedAT := gorm.DeletedAt{}
 
 	store = &StubTodoStore{
-		[]model.Project{{
+		Projects: []model.Project{{
 			Model: gorm.Model{
 				ID:        uint(1),
 				CreatedAt: time,
@@ -50,7 +50,7 @@ func setupTaskTests() (server *api.TodoServer, store *StubTodoStore) {
 				Archived: false,
 				Tasks:    []model.Task{},
 			}},
-		[]model.Task{{
+		Tasks: []model.Task{{
 			Model: gorm.Model{
 				ID:        uint(1),
 				CreatedAt: time,
@@ -0,0 +1,181 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/timetrack"
+	"github.com/stretchr/testify/assert"
+)
+
+// Sends a request with an optional JSON body as user
+func doUserRequest(t *testing.T, server *api.TodoServer, user, method, url string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		requestBody, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Error parsing request body to json: %s", err)
+		}
+		reader = bytes.NewBuffer(requestBody)
+	}
+
+	req, _ := http.NewRequest(method, url, reader)
+	req.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func parseTimeReport(t *testing.T, body []byte) timetrack.Report {
+	t.Helper()
+	report := timetrack.Report{}
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatalf("Error parsing time report: %s", err)
+	}
+	return report
+}
+
+// Tests routes POST and DELETE /projects/:projectName/tasks/:taskName/timer and GET /timer
+func TestTimer(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	project := db.GetProject("homework")
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: project.ID})
+	db.PostTask(model.Task{Name: "biology", Priority: "1", ProjectID: project.ID})
+
+	t.Run("Start a timer", func(t *testing.T) {
+		w := doUserRequest(t, server, "alice", "POST", "/projects/homework/tasks/math/timer", map[string]string{"notes": "exercise 1"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w = doUserRequest(t, server, "alice", "GET", "/timer", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		entry := timetrack.Entry{}
+		json.Unmarshal(w.Body.Bytes(), &entry)
+		assert.Equal(t, "math", entry.Task)
+		assert.Equal(t, "exercise 1", entry.Notes)
+		assert.True(t, entry.Running)
+	})
+
+	t.Run("Second timer of a user returns http.StatusConflict", func(t *testing.T) {
+		w := doUserRequest(t, server, "alice", "POST", "/projects/homework/tasks/biology/timer", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Other users have their own timer", func(t *testing.T) {
+		w := doUserRequest(t, server, "bob", "POST", "/projects/homework/tasks/math/timer", nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Stop a timer", func(t *testing.T) {
+		w := doUserRequest(t, server, "alice", "DELETE", "/projects/homework/tasks/biology/timer", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doUserRequest(t, server, "alice", "DELETE", "/projects/homework/tasks/math/timer", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		entry := model.TimeEntry{}
+		json.Unmarshal(w.Body.Bytes(), &entry)
+		assert.NotNil(t, entry.End)
+
+		w = doUserRequest(t, server, "alice", "GET", "/timer", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// Tests routes for time entries and reports
+func TestTimeEntries(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	for _, name := range []string{"homework", "cleaning"} {
+		db.PostProject(name)
+	}
+	db.PostTask(model.Task{Name: "math", Priority: "1", ProjectID: db.GetProject("homework").ID})
+	db.PostTask(model.Task{Name: "biology", Priority: "1", ProjectID: db.GetProject("homework").ID})
+	db.PostTask(model.Task{Name: "kitchen", Priority: "1", ProjectID: db.GetProject("cleaning").ID})
+
+	entries := []struct{ user, project, task, start, end string }{
+		{"alice", "homework", "math", "2021-06-01T09:00:00Z", "2021-06-01T10:00:00Z"},
+		{"alice", "homework", "biology", "2021-06-01T23:30:00Z", "2021-06-02T00:30:00Z"},
+		{"bob", "homework", "math", "2021-06-02T14:00:00Z", "2021-06-02T14:15:00Z"},
+		{"bob", "cleaning", "kitchen", "2021-06-03T08:00:00Z", "2021-06-03T08:30:00Z"},
+	}
+	for _, entry := range entries {
+		w := doUserRequest(t, server, entry.user, "POST", "/projects/"+entry.project+"/tasks/"+entry.task+"/time",
+			map[string]string{"start": entry.start, "end": entry.end, "notes": "billed"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	t.Run("End before start returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/homework/tasks/math/time",
+			map[string]string{"start": "2021-06-01T10:00:00Z", "end": "2021-06-01T09:00:00Z"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Task totals", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/tasks/math/time", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := parseTimeReport(t, w.Body.Bytes())
+		assert.Equal(t, int64(4500), report.Seconds)
+		assert.Len(t, report.Entries, 2)
+		assert.Equal(t, []timetrack.UserTotal{{User: "alice", Seconds: 3600}, {User: "bob", Seconds: 900}}, report.Users)
+	})
+
+	t.Run("Project totals", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/time?user=alice", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := parseTimeReport(t, w.Body.Bytes())
+		assert.Equal(t, int64(7200), report.Projects[0].Seconds)
+		assert.Equal(t, []timetrack.TaskTotal{{Task: "math", Seconds: 3600}, {Task: "biology", Seconds: 3600}}, report.Projects[0].Tasks)
+	})
+
+	t.Run("Reports clip entries to the range", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/reports/time?from=2021-06-02&to=2021-06-03", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := parseTimeReport(t, w.Body.Bytes())
+		assert.Equal(t, int64(1800+900), report.Seconds)
+		assert.Len(t, report.Entries, 2)
+		assert.Equal(t, int64(0), report.Projects[1].Seconds)
+	})
+
+	t.Run("Invalid range returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/reports/time?from=2021-06-03&to=2021-06-02", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Delete a time entry", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/cleaning/tasks/kitchen/time",
+			map[string]string{"start": "2021-06-04T08:00:00Z", "end": "2021-06-04T08:30:00Z"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		created := model.TimeEntry{}
+		json.Unmarshal(w.Body.Bytes(), &created)
+		assert.NotZero(t, created.ID)
+		assert.False(t, created.CreatedAt.IsZero())
+		id := created.ID
+
+		w = doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/math/time/"+fmt.Sprint(id), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doJSONRequest(t, server, "DELETE", "/projects/cleaning/tasks/kitchen/time/"+fmt.Sprint(id), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(1800), parseTimeReport(t, doJSONRequest(t, server, "GET", "/projects/cleaning/time", nil).Body.Bytes()).Seconds)
+	})
+
+	t.Run("Deleting a task deletes its time entries", func(t *testing.T) {
+		doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/biology", nil)
+
+		w := doJSONRequest(t, server, "GET", "/reports/time?user=alice", nil)
+		assert.Equal(t, int64(3600), parseTimeReport(t, w.Body.Bytes()).Seconds)
+	})
+}
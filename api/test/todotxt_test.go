@@ -0,0 +1,114 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/todotxt"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for the todo.txt export and import routes
+func TestTodoTxt(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "home office"})
+	doJSONRequest(t, server, "POST", "/projects/home office/tasks", map[string]string{
+		"name":     "call the bank",
+		"priority": "1",
+		"deadline": "2021-06-15 00:00:00 +0000 UTC",
+	})
+	doJSONRequest(t, server, "POST", "/projects/home office/tasks", map[string]string{
+		"name":     "file taxes",
+		"priority": "3",
+		"deadline": "2021-07-31 00:00:00 +0000 UTC",
+	})
+	doJSONRequest(t, server, "PUT", "/projects/home office/tasks/file taxes/complete", nil)
+
+	importLines := func(t *testing.T, target, lines string) todotxt.ImportReport {
+		t.Helper()
+		req, _ := http.NewRequest("POST", target, strings.NewReader(lines))
+		req.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := todotxt.ImportReport{}
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return report
+	}
+
+	t.Run("Completing a task records the completion time", func(t *testing.T) {
+		task := db.GetTask("home office", "file taxes")
+		assert.NotNil(t, task.CompletedAt)
+		assert.Nil(t, db.GetTask("home office", "call the bank").CompletedAt)
+	})
+
+	t.Run("Export a project", func(t *testing.T) {
+		bank := db.GetTask("home office", "call the bank")
+		taxes := db.GetTask("home office", "file taxes")
+
+		w := doJSONRequest(t, server, "GET", "/projects/home office/todo.txt", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t,
+			"(A) "+bank.CreatedAt.Format("2006-01-02")+" call the bank +home_office due:2021-06-15\n"+
+				"x "+taxes.CompletedAt.Format("2006-01-02")+" "+taxes.CreatedAt.Format("2006-01-02")+
+				" file taxes +home_office due:2021-07-31 pri:C\n",
+			w.Body.String())
+	})
+
+	t.Run("Import todo.txt lines", func(t *testing.T) {
+		report := importLines(t, "/todo.txt?project=inbox",
+			"(B) 2021-05-01 call the bank +home_office @phone due:2021-06-20\n"+
+				"x 2021-06-02 2021-05-30 water plants +garden @home\n"+
+				"buy milk @shop\n"+
+				"\n"+
+				"(C) +garden\n"+
+				"mow the lawn +garden due:soon\n")
+
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		if assert.Len(t, report.Skipped, 2) {
+			assert.Equal(t, 5, report.Skipped[0].Line)
+			assert.Equal(t, "invalid due date due:soon", report.Skipped[1].Reason)
+		}
+
+		bank := db.GetTask("home office", "call the bank")
+		assert.Equal(t, "2", bank.Priority)
+		assert.Equal(t, "phone", bank.Tags)
+		assert.Equal(t, "2021-06-20", bank.Deadline.Format("2006-01-02"))
+
+		plants := db.GetTask("garden", "water plants")
+		assert.True(t, plants.Done)
+		assert.Equal(t, "2021-06-02", plants.CompletedAt.Format("2006-01-02"))
+		assert.Equal(t, "2021-05-30", plants.CreatedAt.Format("2006-01-02"))
+
+		assert.Equal(t, "shop", db.GetTask("inbox", "buy milk").Tags)
+	})
+
+	t.Run("Lines without project are skipped without default project", func(t *testing.T) {
+		report := importLines(t, "/todo.txt", "buy bread\n")
+
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, "task has no project", report.Skipped[0].Reason)
+	})
+
+	t.Run("Import into a project", func(t *testing.T) {
+		report := importLines(t, "/projects/garden/todo.txt", "x water plants\nplant tomatoes\n")
+
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, "plant tomatoes", db.GetTask("garden", "plant tomatoes").Name)
+	})
+
+	t.Run("Export everything", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/todo.txt", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), 5)
+	})
+}
@@ -0,0 +1,180 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/transfer"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseTransferReport(t *testing.T, body []byte) transfer.Report {
+	t.Helper()
+	report := transfer.Report{}
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatalf("Error parsing transfer report: %s", err)
+	}
+	return report
+}
+
+// Creates a task with tags in the project
+func postTransferTask(t *testing.T, db *store.Database, project, name, tags string, parentID *uint) model.Task {
+	t.Helper()
+	db.PostTask(model.Task{Name: name, Priority: "1", Tags: tags, ParentID: parentID, ProjectID: db.GetProject(project).ID})
+	return db.GetTask(project, name)
+}
+
+// Tests routes POST /projects/:projectName/tasks/:taskName/move and /copy
+func TestTransferTask(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	db.PostProject("school")
+	math := postTransferTask(t, db, "homework", "math", "", nil)
+	exercise := postTransferTask(t, db, "homework", "exercise 1", "", &math.ID)
+
+	t.Run("Move keeps the task and detaches subtasks", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/homework/tasks/math/move", map[string]string{"project": "school", "name": "algebra"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := parseTransferReport(t, w.Body.Bytes())
+		assert.Equal(t, []transfer.TransferredTask{{ID: math.ID, Name: "math", NewName: "algebra"}}, report.Tasks)
+
+		assert.Equal(t, math.ID, db.GetTask("school", "algebra").ID)
+		assert.Equal(t, "", db.GetTask("homework", "math").Name)
+		assert.Nil(t, db.GetTask("homework", exercise.Name).ParentID)
+	})
+
+	t.Run("Copy creates a new task", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy", map[string]string{"project": "homework"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		copied := db.GetTask("homework", "algebra")
+		assert.NotEqual(t, math.ID, copied.ID)
+		assert.Equal(t, math.ID, db.GetTask("school", "algebra").ID)
+	})
+
+	t.Run("Name conflict returns http.StatusConflict", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy", map[string]string{"project": "homework"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Name conflict with rename", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy",
+			map[string]string{"project": "homework", "on_conflict": "rename"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "algebra (2)", parseTransferReport(t, w.Body.Bytes()).Tasks[0].NewName)
+	})
+
+	t.Run("Copy within a project conflicts with the original", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy", map[string]string{"project": "school"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "algebra (2)", parseTransferReport(t, w.Body.Bytes()).Tasks[0].NewName)
+		assert.Equal(t, math.ID, db.GetTask("school", "algebra").ID)
+		assert.NotEqual(t, math.ID, db.GetTask("school", "algebra (2)").ID)
+
+		w = doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy",
+			map[string]string{"project": "school", "on_conflict": "fail"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy",
+			map[string]string{"project": "school", "on_conflict": "skip"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"algebra"}, parseTransferReport(t, w.Body.Bytes()).Skipped)
+		db.DeleteTask(db.GetTask("school", "algebra (2)"))
+	})
+
+	t.Run("Name conflict with replace", func(t *testing.T) {
+		existing := db.GetTask("homework", "algebra")
+
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy",
+			map[string]string{"project": "homework", "on_conflict": "replace"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, existing.ID, db.GetTask("homework", "algebra").ID)
+	})
+
+	t.Run("Archived projects", func(t *testing.T) {
+		db.PostProject("old")
+		old := db.GetProject("old")
+		old.ArchiveProject()
+		db.UpdateProject(old)
+
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/move", map[string]string{"project": "old"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/copy", map[string]string{"project": "old"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, math.ID, db.GetTask("school", "algebra").ID)
+	})
+
+	t.Run("Invalid on_conflict returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/move",
+			map[string]string{"project": "homework", "on_conflict": "merge"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unknown target project returns http.StatusNotFound", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/projects/school/tasks/algebra/move", map[string]string{"project": "garden"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// Tests routes POST /tasks/move and /tasks/copy
+func TestTransferTasks(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	db.PostProject("school")
+	db.PostProject("backup")
+	math := postTransferTask(t, db, "homework", "math", "school", nil)
+	postTransferTask(t, db, "homework", "exercise 1", "school", &math.ID)
+	postTransferTask(t, db, "homework", "shopping", "", nil)
+	postTransferTask(t, db, "school", "exercise 1", "", nil)
+
+	t.Run("Copy keeps subtasks linked", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/tasks/copy", map[string]interface{}{
+			"from": "homework", "to": "backup", "filter": map[string]string{"tag": "school"},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, parseTransferReport(t, w.Body.Bytes()).Tasks, 2)
+
+		copied := db.GetTask("backup", "math")
+		assert.Equal(t, copied.ID, *db.GetTask("backup", "exercise 1").ParentID)
+		assert.Equal(t, "", db.GetTask("backup", "shopping").Name)
+	})
+
+	t.Run("Conflict without option changes nothing", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/tasks/move", map[string]interface{}{
+			"from": "homework", "to": "school", "filter": map[string]string{"tag": "school"},
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, math.ID, db.GetTask("homework", "math").ID)
+	})
+
+	t.Run("Move skips conflicts", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/tasks/move", map[string]interface{}{
+			"from": "homework", "to": "school", "filter": map[string]string{"tag": "school"}, "on_conflict": "skip",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		report := parseTransferReport(t, w.Body.Bytes())
+		assert.Equal(t, []string{"exercise 1"}, report.Skipped)
+		assert.Equal(t, math.ID, db.GetTask("school", "math").ID)
+		assert.Nil(t, db.GetTask("homework", "exercise 1").ParentID)
+	})
+
+	t.Run("Filter by names", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/tasks/move", map[string]interface{}{
+			"from": "homework", "to": "school", "filter": map[string][]string{"names": {"shopping"}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "shopping", db.GetTask("school", "shopping").Name)
+	})
+
+	t.Run("Invalid filter returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/tasks/move", map[string]interface{}{
+			"from": "homework", "to": "school", "filter": map[string]string{"due_before": "tomorrow"},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,157 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+var reviewWorkflow = map[string]interface{}{
+	"states": []map[string]interface{}{
+		{"name": "backlog", "category": "open"},
+		{"name": "doing", "category": "active", "wip_limit": 1},
+		{"name": "review", "category": "active"},
+		{"name": "released", "category": "closed"},
+	},
+}
+
+func getBoard(t *testing.T, body []byte) workflow.Board {
+	t.Helper()
+	board := workflow.Board{}
+	if err := json.Unmarshal(body, &board); err != nil {
+		t.Fatalf("Error parsing board: %s", err)
+	}
+	return board
+}
+
+// Tests routes GET and PUT /projects/:projectName/workflow
+func TestWorkflow(t *testing.T) {
+	server, _ := setupDatabaseServer(t)
+	doJSONRequest(t, server, "POST", "/projects/", map[string]string{"name": "homework"})
+
+	t.Run("Projects use the default workflow", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/workflow", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"states": [
+			{"name": "todo", "category": "open", "wip_limit": 0},
+			{"name": "in progress", "category": "active", "wip_limit": 0},
+			{"name": "done", "category": "closed", "wip_limit": 0}]}`, w.Body.String())
+	})
+
+	t.Run("Replace the workflow", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/workflow", reviewWorkflow)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSONRequest(t, server, "GET", "/projects/homework/workflow", nil)
+		assert.Contains(t, w.Body.String(), `{"name":"doing","category":"active","wip_limit":1}`)
+	})
+
+	t.Run("Invalid workflows return http.StatusBadRequest", func(t *testing.T) {
+		invalid := []map[string]interface{}{
+			{"states": []map[string]interface{}{{"name": "todo", "category": "open"}}},
+			{"states": []map[string]interface{}{{"name": "todo", "category": "open"}, {"name": "todo", "category": "closed"}}},
+			{"states": []map[string]interface{}{{"name": "todo", "category": "waiting"}, {"name": "done", "category": "closed"}}},
+			{"states": []map[string]interface{}{{"name": "todo", "category": "open", "wip_limit": -1}, {"name": "done", "category": "closed"}}},
+		}
+		for _, body := range invalid {
+			w := doJSONRequest(t, server, "PUT", "/projects/homework/workflow", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+
+	t.Run("Unknown project returns http.StatusNotFound", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/garden/workflow", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// Tests routes PUT /projects/:projectName/tasks/:taskName/state and GET /projects/:projectName/board
+func TestTaskStates(t *testing.T) {
+	server, db := setupDatabaseServer(t)
+	db.PostProject("homework")
+	project := db.GetProject("homework")
+	for _, name := range []string{"math", "biology", "history"} {
+		db.PostTask(model.Task{Name: name, Priority: "1", ProjectID: project.ID})
+	}
+	doJSONRequest(t, server, "PUT", "/projects/homework/workflow", reviewWorkflow)
+
+	t.Run("Tasks start in the first open state", func(t *testing.T) {
+		w := doJSONRequest(t, server, "GET", "/projects/homework/board", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		board := getBoard(t, w.Body.Bytes())
+		assert.Len(t, board.Columns, 4)
+		assert.Equal(t, "backlog", board.Columns[0].State)
+		assert.Equal(t, 3, board.Columns[0].Count)
+		assert.Equal(t, "backlog", board.Columns[0].Tasks[0].State)
+	})
+
+	t.Run("Transition into an active state", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/state", map[string]string{"state": "doing"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		math := db.GetTask("homework", "math")
+		assert.Equal(t, "doing", math.State)
+		assert.False(t, math.Done)
+	})
+
+	t.Run("WIP limit returns http.StatusConflict", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/biology/state", map[string]string{"state": "doing"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "", db.GetTask("homework", "biology").State)
+	})
+
+	t.Run("Closed states complete the task", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/state", map[string]string{"state": "released"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		math := db.GetTask("homework", "math")
+		assert.True(t, math.Done)
+		assert.NotNil(t, math.CompletedAt)
+
+		w = doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/state", map[string]string{"state": "review"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, db.GetTask("homework", "math").Done)
+	})
+
+	t.Run("Complete moves the task into the closed state", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/history/complete", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		board := getBoard(t, doJSONRequest(t, server, "GET", "/projects/homework/board", nil).Body.Bytes())
+		assert.Equal(t, "history", board.Columns[3].Tasks[0].Name)
+
+		w = doJSONRequest(t, server, "DELETE", "/projects/homework/tasks/history/complete", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		board = getBoard(t, doJSONRequest(t, server, "GET", "/projects/homework/board", nil).Body.Bytes())
+		assert.Equal(t, 2, board.Columns[0].Count)
+		assert.Equal(t, 0, board.Columns[3].Count)
+	})
+
+	t.Run("Batch and GraphQL completions use the workflow", func(t *testing.T) {
+		w := doJSONRequest(t, server, "POST", "/batch", map[string]interface{}{
+			"operations": []map[string]interface{}{
+				{"op": "complete_task", "project": "homework", "name": "biology"},
+			},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "released", db.GetTask("homework", "biology").State)
+
+		code, response := doGraphQLRequest(t, server, `mutation {
+			completeTask(project: "homework", name: "biology", done: false) { done }
+		}`, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, "backlog", db.GetTask("homework", "biology").State)
+	})
+
+	t.Run("Unknown state returns http.StatusBadRequest", func(t *testing.T) {
+		w := doJSONRequest(t, server, "PUT", "/projects/homework/tasks/math/state", map[string]string{"state": "blocked"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
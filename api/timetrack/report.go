@@ -0,0 +1,132 @@
+package timetrack
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Selects the entries of a report. Empty fields match all entries
+type ReportQuery struct {
+	Projects []model.Project
+	// Only the task of this name, requires a single project
+	Task string
+	User string
+	From *time.Time
+	To   *time.Time
+}
+
+// Time spent in a range. Entries overlapping the range
+// only count with their time inside of it
+type Report struct {
+	From     *time.Time     `json:"from,omitempty"`
+	To       *time.Time     `json:"to,omitempty"`
+	Seconds  int64          `json:"seconds"`
+	Projects []ProjectTotal `json:"projects"`
+	Users    []UserTotal    `json:"users"`
+	Entries  []Entry        `json:"entries"`
+}
+
+type ProjectTotal struct {
+	Project string      `json:"project"`
+	Seconds int64       `json:"seconds"`
+	Tasks   []TaskTotal `json:"tasks"`
+}
+
+type TaskTotal struct {
+	Task    string `json:"task"`
+	Seconds int64  `json:"seconds"`
+}
+
+type UserTotal struct {
+	User    string `json:"user"`
+	Seconds int64  `json:"seconds"`
+}
+
+// A time entry with the names of its project and task
+type Entry struct {
+	model.TimeEntry
+	Project string `json:"project"`
+	Task    string `json:"task"`
+	Running bool   `json:"running"`
+	Seconds int64  `json:"seconds"`
+}
+
+// Sums up the entries matching the query. Running entries count until now
+func NewReport(t store.TodoStore, query ReportQuery, now time.Time) Report {
+	report := Report{From: query.From, To: query.To, Projects: []ProjectTotal{}, Users: []UserTotal{}, Entries: []Entry{}}
+
+	// Projects and names of the tasks by ID
+	type taskRef struct {
+		project int
+		name    string
+	}
+	tasks := map[uint]taskRef{}
+	taskIDs := []uint{}
+	for _, project := range query.Projects {
+		report.Projects = append(report.Projects, ProjectTotal{Project: project.Name, Tasks: []TaskTotal{}})
+		for _, task := range t.GetAllProjectTasks(project) {
+			if query.Task != "" && task.Name != query.Task {
+				continue
+			}
+			tasks[task.ID] = taskRef{project: len(report.Projects) - 1, name: task.Name}
+			taskIDs = append(taskIDs, task.ID)
+		}
+	}
+
+	entries := t.GetTimeEntries(store.TimeEntryQuery{TaskIDs: taskIDs, User: query.User, From: query.From, To: query.To})
+
+	taskSeconds := map[uint]int64{}
+	userSeconds := map[string]int64{}
+	for _, entry := range entries {
+		seconds := int64(clippedDuration(entry, query.From, query.To, now) / time.Second)
+		ref := tasks[entry.TaskID]
+
+		report.Entries = append(report.Entries, Entry{
+			TimeEntry: entry,
+			Project:   report.Projects[ref.project].Project,
+			Task:      ref.name,
+			Running:   entry.End == nil,
+			Seconds:   seconds,
+		})
+		report.Seconds += seconds
+		report.Projects[ref.project].Seconds += seconds
+		taskSeconds[entry.TaskID] += seconds
+		userSeconds[entry.User] += seconds
+	}
+
+	for _, id := range taskIDs {
+		if seconds, ok := taskSeconds[id]; ok {
+			project := &report.Projects[tasks[id].project]
+			project.Tasks = append(project.Tasks, TaskTotal{Task: tasks[id].name, Seconds: seconds})
+		}
+	}
+	for user, seconds := range userSeconds {
+		report.Users = append(report.Users, UserTotal{User: user, Seconds: seconds})
+	}
+	sort.Slice(report.Users, func(i, j int) bool { return report.Users[i].User < report.Users[j].User })
+
+	return report
+}
+
+// Returns the duration of the entry inside the range from to
+func clippedDuration(entry model.TimeEntry, from, to *time.Time, now time.Time) time.Duration {
+	start := entry.Start
+	end := now
+	if entry.End != nil {
+		end = *entry.End
+	}
+
+	if from != nil && from.After(start) {
+		start = *from
+	}
+	if to != nil && to.Before(end) {
+		end = *to
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
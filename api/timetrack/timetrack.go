@@ -0,0 +1,61 @@
+// Package timetrack records the time users spend on tasks.
+//
+// Time is tracked with timers, of which every user can run one at a
+// time, or with entries added afterwards. Reports sum up the entries
+// of a time range per project, task and user.
+package timetrack
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+var (
+	// The user already has a running timer
+	ErrTimerRunning = errors.New("timer already running")
+	// The user has no running timer on the task
+	ErrNoTimer = errors.New("no timer running")
+	// The end of an entry is not after its start
+	ErrInvalidRange = errors.New("end must be after start")
+)
+
+// Starts a timer of the user on the task
+func Start(t store.TodoStore, task model.Task, user, notes string, now time.Time) (model.TimeEntry, error) {
+	entry := model.TimeEntry{}
+	err := t.Transaction(func(tx store.TodoStore) error {
+		if running := tx.GetRunningTimeEntry(user); running.ID != 0 {
+			return fmt.Errorf("%w since %s", ErrTimerRunning, running.Start.Format(time.RFC3339))
+		}
+
+		var err error
+		entry, err = tx.PostTimeEntry(model.TimeEntry{TaskID: task.ID, User: user, Start: now.UTC(), Notes: notes})
+		return err
+	})
+	return entry, err
+}
+
+// Stops the running timer of the user on the task
+func Stop(t store.TodoStore, task model.Task, user string, now time.Time) (model.TimeEntry, error) {
+	entry := t.GetRunningTimeEntry(user)
+	if entry.ID == 0 || entry.TaskID != task.ID {
+		return entry, ErrNoTimer
+	}
+
+	end := now.UTC()
+	entry.End = &end
+	return entry, t.UpdateTimeEntry(entry)
+}
+
+// Adds a finished entry of the user on the task
+func Add(t store.TodoStore, task model.Task, user string, start, end time.Time, notes string) (model.TimeEntry, error) {
+	if !end.After(start) {
+		return model.TimeEntry{}, ErrInvalidRange
+	}
+
+	end = end.UTC()
+	return t.PostTimeEntry(model.TimeEntry{TaskID: task.ID, User: user, Start: start.UTC(), End: &end, Notes: notes})
+}
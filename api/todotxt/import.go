@@ -0,0 +1,107 @@
+package todotxt
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Result of an import
+type ImportReport struct {
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped []SkippedLine `json:"skipped"`
+}
+
+// A line that was not imported
+type SkippedLine struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// Imports todo.txt lines. Tasks go into the project of their first
+// +project tag, lines without one into defaultProject.
+// Missing projects are created and existing tasks are matched by name.
+// All lines are imported in one transaction, completed lines of
+// tasks with open blockers are skipped
+func Import(t store.TodoStore, defaultProject string, r io.Reader) (ImportReport, error) {
+	report := ImportReport{Skipped: []SkippedLine{}}
+
+	err := t.Transaction(func(tx store.TodoStore) error {
+		scanner := bufio.NewScanner(r)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+
+			item, err := Parse(text)
+			if err != nil {
+				report.Skipped = append(report.Skipped, SkippedLine{Line: line, Text: text, Reason: err.Error()})
+				continue
+			}
+
+			projectName := defaultProject
+			if len(item.Projects) > 0 {
+				projectName = item.Projects[0]
+			}
+			if projectName == "" {
+				report.Skipped = append(report.Skipped, SkippedLine{Line: line, Text: text, Reason: "task has no project"})
+				continue
+			}
+
+			project, err := findOrCreateProject(tx, projectName)
+			if err != nil {
+				return err
+			}
+
+			task := tx.GetTask(project.Name, item.Name)
+			if task.ID != 0 && !task.Done && item.Done {
+				if err := dependency.CheckDone(tx, task); err != nil {
+					report.Skipped = append(report.Skipped, SkippedLine{Line: line, Text: text, Reason: err.Error()})
+					continue
+				}
+			}
+			item.Apply(&task)
+
+			if task.ID != 0 {
+				err = tx.UpdateTask(task)
+				report.Updated++
+			} else {
+				task.ProjectID = project.ID
+				err = tx.PostTask(task)
+				report.Created++
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+
+	if err != nil {
+		report.Created, report.Updated = 0, 0
+	}
+	return report, err
+}
+
+// Finds the project of a +project tag. Underscores in tags may stand
+// for spaces in the project name
+func findOrCreateProject(t store.TodoStore, name string) (model.Project, error) {
+	if project := t.GetProject(name); project.Name != "" {
+		return project, nil
+	}
+	if project := t.GetProject(strings.ReplaceAll(name, "_", " ")); project.Name != "" {
+		return project, nil
+	}
+
+	if err := t.PostProject(name); err != nil {
+		return model.Project{}, err
+	}
+	return t.GetProject(name), nil
+}
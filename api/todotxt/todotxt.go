@@ -0,0 +1,171 @@
+// Package todotxt converts tasks from and to the todo.txt format
+// described at https://github.com/todotxt/todo.txt.
+//
+// A line looks like
+//
+//	x 2021-06-12 2021-06-01 (A) call the bank +finance @phone due:2021-06-15
+//
+// The priority letters A to I map to the priority levels 1 to 9,
+// +project tags to projects, @context tags to the tags of a task
+// and the due key to its deadline.
+package todotxt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Layout of dates in todo.txt
+const dateLayout = "2006-01-02"
+
+// A parsed todo.txt line
+type Item struct {
+	Name        string
+	Done        bool
+	Priority    int
+	CreatedAt   *time.Time
+	CompletedAt *time.Time
+	Due         *time.Time
+	Projects    []string
+	Contexts    []string
+}
+
+// Formats a task of a project as todo.txt line
+func Format(project model.Project, task model.Task) string {
+	parts := []string{}
+
+	if task.Done {
+		parts = append(parts, "x")
+		if task.CompletedAt != nil {
+			parts = append(parts, task.CompletedAt.Format(dateLayout))
+		}
+	}
+
+	// Completed tasks keep their priority as pri key
+	level := task.PriorityLevel()
+	if level > 0 && !task.Done {
+		parts = append(parts, fmt.Sprintf("(%c)", 'A'+level-1))
+	}
+
+	if !task.CreatedAt.IsZero() {
+		parts = append(parts, task.CreatedAt.Format(dateLayout))
+	}
+
+	parts = append(parts, task.Name, "+"+tag(project.Name))
+	for _, context := range task.TagList() {
+		parts = append(parts, "@"+tag(context))
+	}
+
+	if task.Deadline != nil {
+		parts = append(parts, "due:"+task.Deadline.Format(dateLayout))
+	}
+	if level > 0 && task.Done {
+		parts = append(parts, fmt.Sprintf("pri:%c", 'A'+level-1))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Parses a todo.txt line
+func Parse(line string) (Item, error) {
+	item := Item{}
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		item.Done = true
+		words = words[1:]
+
+		// A completion date may be followed by a creation date
+		if date, ok := parseDate(words); ok {
+			item.CompletedAt = &date
+			words = words[1:]
+		}
+	}
+
+	if len(words) > 0 && isPriority(words[0]) {
+		item.Priority = int(words[0][1]-'A') + 1
+		words = words[1:]
+	}
+
+	if date, ok := parseDate(words); ok {
+		item.CreatedAt = &date
+		words = words[1:]
+	}
+
+	name := []string{}
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			item.Projects = append(item.Projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			item.Contexts = append(item.Contexts, word[1:])
+		case strings.HasPrefix(word, "due:"):
+			due, err := time.Parse(dateLayout, word[len("due:"):])
+			if err != nil {
+				return item, fmt.Errorf("invalid due date %s", word)
+			}
+			item.Due = &due
+		case strings.HasPrefix(word, "pri:") && isPriority("("+word[len("pri:"):]+")"):
+			item.Priority = int(word[len("pri:")]-'A') + 1
+		default:
+			name = append(name, word)
+		}
+	}
+
+	item.Name = strings.Join(name, " ")
+	if item.Name == "" {
+		return item, fmt.Errorf("task has no description")
+	}
+	return item, nil
+}
+
+// Copies the properties of an item into a task
+func (i Item) Apply(task *model.Task) {
+	task.Name = i.Name
+	task.Deadline = i.Due
+	task.SetTags(i.Contexts)
+
+	// Levels beyond 9 are the lowest priority
+	task.Priority = ""
+	if i.Priority > 9 {
+		task.Priority = "9"
+	} else if i.Priority > 0 {
+		task.Priority = strconv.Itoa(i.Priority)
+	}
+
+	if i.CreatedAt != nil && task.CreatedAt.IsZero() {
+		task.CreatedAt = *i.CreatedAt
+	}
+
+	if !i.Done {
+		task.ReopenTask()
+		return
+	}
+	task.CompleteTask()
+	if i.CompletedAt != nil {
+		task.CompletedAt = i.CompletedAt
+	}
+}
+
+// Tags can not contain spaces
+func tag(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// Checks for a priority like (A)
+func isPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[2] == ')' && word[1] >= 'A' && word[1] <= 'Z'
+}
+
+// Parses the first word as date
+func parseDate(words []string) (time.Time, bool) {
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(dateLayout, words[0])
+	return date, err == nil
+}
@@ -0,0 +1,275 @@
+// Package transfer moves and copies tasks between projects.
+//
+// Moved tasks keep their ID, history and completion state. Copies are
+// new tasks with the same properties. Archived projects are read only:
+// tasks can not be moved out of or into them, only copied out of them.
+package transfer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/rank"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Actions
+const (
+	Move = "move"
+	Copy = "copy"
+)
+
+// Handling of tasks with a name that already exists in the target project
+const (
+	// Abort the transfer with ErrConflict
+	ConflictFail = "fail"
+	// Leave the task where it is
+	ConflictSkip = "skip"
+	// Append a number to the name, e.g. "math (2)"
+	ConflictRename = "rename"
+	// Delete the task of the target project
+	ConflictReplace = "replace"
+)
+
+var (
+	// A task with the same name exists in the target project
+	ErrConflict = errors.New("task already exists in the target project")
+	// The source of a move or the target is archived
+	ErrArchived = errors.New("project is archived")
+)
+
+// Options of a transfer
+type Options struct {
+	// Move or Copy
+	Action string
+	// One of the Conflict constants. If empty ConflictRename for copies
+	// within a project, else ConflictFail
+	OnConflict string
+	// New name of a single task, keeps the name if empty
+	Name string
+}
+
+// Result of a transfer
+type Report struct {
+	Action  string            `json:"action"`
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Tasks   []TransferredTask `json:"tasks"`
+	Skipped []string          `json:"skipped"`
+}
+
+type TransferredTask struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	NewName string `json:"new_name"`
+}
+
+// Checks the options
+func (o Options) Validate() error {
+	if o.Action != Move && o.Action != Copy {
+		return fmt.Errorf("action must be %s or %s", Move, Copy)
+	}
+	switch o.OnConflict {
+	case "", ConflictFail, ConflictSkip, ConflictRename, ConflictReplace:
+		return nil
+	}
+	return fmt.Errorf("on_conflict must be %s, %s, %s or %s", ConflictFail, ConflictSkip, ConflictRename, ConflictReplace)
+}
+
+// Moves or copies tasks of project from into project to in one transaction
+func Transfer(t store.TodoStore, from, to model.Project, tasks []model.Task, options Options) (Report, error) {
+	report := Report{Action: options.Action, From: from.Name, To: to.Name, Tasks: []TransferredTask{}, Skipped: []string{}}
+
+	if err := options.Validate(); err != nil {
+		return report, err
+	}
+	if to.Archived || (from.Archived && options.Action == Move) {
+		return report, ErrArchived
+	}
+	if options.Name != "" && len(tasks) != 1 {
+		return report, errors.New("only single tasks can be renamed")
+	}
+	// Copies within a project always conflict with their original
+	if options.OnConflict == "" && options.Action == Copy && from.ID == to.ID {
+		options.OnConflict = ConflictRename
+	}
+
+	err := t.Transaction(func(tx store.TodoStore) error {
+		// Skipped tasks stay, so their subtasks are detached when moving
+		transferred := map[uint]bool{}
+		for _, task := range tasks {
+			existing := tx.GetTask(to.Name, targetName(task, options))
+			skipped := options.OnConflict == ConflictSkip && conflicts(existing, task, options)
+			transferred[task.ID] = !skipped
+		}
+
+		// IDs of copies by ID of the original, to link subtasks
+		copies := map[uint]uint{}
+
+		for _, task := range parentsFirst(tasks) {
+			name, skip, err := resolveConflict(tx, to, task, targetName(task, options), options)
+			if err != nil {
+				return err
+			}
+			if skip {
+				report.Skipped = append(report.Skipped, task.Name)
+				continue
+			}
+
+			var id uint
+			if options.Action == Move {
+				id, err = move(tx, from, to, task, name, transferred)
+			} else {
+				id, err = copyTask(tx, to, task, name, copies)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %v", task.Name, err)
+			}
+			report.Tasks = append(report.Tasks, TransferredTask{ID: id, Name: task.Name, NewName: name})
+		}
+		return nil
+	})
+	if err != nil {
+		report.Tasks = []TransferredTask{}
+		report.Skipped = []string{}
+	}
+	return report, err
+}
+
+// Returns the name of the task in the target project
+func targetName(task model.Task, options Options) string {
+	if options.Name != "" {
+		return options.Name
+	}
+	return task.Name
+}
+
+// Handles a task with the name in the target project.
+// Returns the name to use or whether the task is skipped
+func resolveConflict(t store.TodoStore, to model.Project, task model.Task, name string, options Options) (string, bool, error) {
+	existing := t.GetTask(to.Name, name)
+	if !conflicts(existing, task, options) {
+		return name, false, nil
+	}
+
+	switch options.OnConflict {
+	case ConflictSkip:
+		return name, true, nil
+	case ConflictRename:
+		for i := 2; ; i++ {
+			candidate := fmt.Sprintf("%s (%d)", name, i)
+			if t.GetTask(to.Name, candidate).Name == "" {
+				return candidate, false, nil
+			}
+		}
+	case ConflictReplace:
+		return name, false, t.DeleteTask(existing)
+	}
+	return name, false, fmt.Errorf("%w: %s", ErrConflict, name)
+}
+
+// Reports whether the task conflicts with the existing task of the
+// target project. A task moved within its project only conflicts with
+// other tasks, a copy conflicts with its original as well
+func conflicts(existing, task model.Task, options Options) bool {
+	return existing.Name != "" && (existing.ID != task.ID || options.Action == Copy)
+}
+
+// Moves a task. Its parent and subtasks stay linked if they are moved as well
+func move(t store.TodoStore, from, to model.Project, task model.Task, name string, transferred map[uint]bool) (uint, error) {
+	if from.ID != to.ID {
+		// Subtasks that stay become top level tasks
+		for _, subtask := range t.GetAllProjectTasks(from) {
+			if subtask.ParentID != nil && *subtask.ParentID == task.ID && !transferred[subtask.ID] {
+				subtask.ParentID = nil
+				if err := t.UpdateTask(subtask); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if task.ParentID != nil && !transferred[*task.ParentID] {
+			task.ParentID = nil
+		}
+
+		// Moved tasks are added at the end of the project
+		task.Rank = rank.After(lastRank(t, to))
+	}
+
+	task.Name = name
+	task.ProjectID = to.ID
+	return task.ID, t.UpdateTask(task)
+}
+
+// Copies a task. The copy of a subtask belongs to the copy of its parent,
+// if the parent was copied before
+func copyTask(t store.TodoStore, to model.Project, task model.Task, name string, copies map[uint]uint) (uint, error) {
+	original := task.ID
+
+	task.Model = model.Task{}.Model
+	task.Name = name
+	task.ProjectID = to.ID
+	// UIDs of imported calendars identify the original
+	task.ExternalUID = ""
+	// Copies are added at the end of the project
+	task.Rank = ""
+
+	parent, ok := copies[derefID(task.ParentID)]
+	task.ParentID = nil
+	if ok {
+		task.ParentID = &parent
+	}
+
+	if err := t.PostTask(task); err != nil {
+		return 0, err
+	}
+
+	id := t.GetTask(to.Name, name).ID
+	copies[original] = id
+	return id, nil
+}
+
+// Orders tasks so that parents come before their subtasks
+func parentsFirst(tasks []model.Task) []model.Task {
+	byID := map[uint]model.Task{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	ordered := []model.Task{}
+	added := map[uint]bool{}
+	var add func(task model.Task)
+	add = func(task model.Task) {
+		if added[task.ID] {
+			return
+		}
+		added[task.ID] = true
+		if parent, ok := byID[derefID(task.ParentID)]; ok {
+			add(parent)
+		}
+		ordered = append(ordered, task)
+	}
+
+	for _, task := range tasks {
+		add(task)
+	}
+	return ordered
+}
+
+// Returns the highest rank of the tasks of a project
+func lastRank(t store.TodoStore, project model.Project) string {
+	last := ""
+	for _, task := range t.GetAllProjectTasks(project) {
+		if task.Rank > last {
+			last = task.Rank
+		}
+	}
+	return last
+}
+
+func derefID(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Kanban board of a project with one column per state
+type Board struct {
+	Project string   `json:"project"`
+	Columns []Column `json:"columns"`
+}
+
+// The tasks of a state in the order of their ranks
+type Column struct {
+	State    string       `json:"state"`
+	Category string       `json:"category"`
+	WIPLimit int          `json:"wip_limit"`
+	Count    int          `json:"count"`
+	Tasks    []model.Task `json:"tasks"`
+}
+
+// Groups the tasks of a project by state
+func NewBoard(t store.TodoStore, project model.Project) Board {
+	states := States(t, project)
+
+	board := Board{Project: project.Name, Columns: []Column{}}
+	columns := map[string]int{}
+	for i, state := range states {
+		board.Columns = append(board.Columns, Column{
+			State:    state.Name,
+			Category: state.Category,
+			WIPLimit: state.WIPLimit,
+			Tasks:    []model.Task{},
+		})
+		columns[state.Name] = i
+	}
+
+	tasks := t.GetAllProjectTasks(project)
+	dependency.MarkBlocked(t, tasks)
+	for _, task := range tasks {
+		task.State = StateOf(states, task).Name
+		column := &board.Columns[columns[task.State]]
+		column.Tasks = append(column.Tasks, task)
+		column.Count++
+	}
+	return board
+}
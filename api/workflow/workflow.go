@@ -0,0 +1,160 @@
+// Package workflow manages the workflow states of projects and
+// the transitions of tasks between them.
+//
+// Projects without own states use DefaultStates. The Done field of a
+// task follows the category of its state: tasks in closed states are
+// done. Tasks without state, or with a state the project no longer has,
+// are in the first open or closed state, depending on Done.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/dependency"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Workflow of projects without own states
+var DefaultStates = []model.WorkflowState{
+	{Name: "todo", Category: model.StateOpen},
+	{Name: "in progress", Category: model.StateActive},
+	{Name: "done", Category: model.StateClosed},
+}
+
+var (
+	// The project has no state with that name
+	ErrUnknownState = errors.New("unknown state")
+	// The state already has as many tasks as its WIP limit allows
+	ErrWIPLimit = errors.New("WIP limit reached")
+)
+
+// Returns the states of a project in their order
+func States(t store.TodoStore, project model.Project) []model.WorkflowState {
+	if states := t.GetWorkflowStates(project.ID); len(states) > 0 {
+		return states
+	}
+	return DefaultStates
+}
+
+// Checks the states of a workflow. A workflow needs
+// unique names and at least one open and one closed state
+func Validate(states []model.WorkflowState) error {
+	names := map[string]bool{}
+	categories := map[string]bool{}
+
+	for _, state := range states {
+		switch {
+		case state.Name == "":
+			return errors.New("states need a name")
+		case names[state.Name]:
+			return fmt.Errorf("state %s is duplicated", state.Name)
+		case state.Category != model.StateOpen && state.Category != model.StateActive && state.Category != model.StateClosed:
+			return fmt.Errorf("category of state %s must be %s, %s or %s", state.Name, model.StateOpen, model.StateActive, model.StateClosed)
+		case state.WIPLimit < 0:
+			return fmt.Errorf("WIP limit of state %s must not be negative", state.Name)
+		}
+		names[state.Name] = true
+		categories[state.Category] = true
+	}
+
+	if !categories[model.StateOpen] || !categories[model.StateClosed] {
+		return errors.New("a workflow needs at least one open and one closed state")
+	}
+	return nil
+}
+
+// Returns the state of a task in the workflow
+func StateOf(states []model.WorkflowState, task model.Task) model.WorkflowState {
+	for _, state := range states {
+		if state.Name == task.State && (state.Category == model.StateClosed) == task.Done {
+			return state
+		}
+	}
+
+	if task.Done {
+		return first(states, model.StateClosed)
+	}
+	return first(states, model.StateOpen)
+}
+
+// Moves a task of the project into a state. Entering a closed state
+// completes the task, leaving it reopens the task. Tasks with open
+// blockers are only completed if force is true
+func Transition(t store.TodoStore, project model.Project, task model.Task, name string, force bool) (model.Task, error) {
+	states := States(t, project)
+
+	target := model.WorkflowState{}
+	for _, state := range states {
+		if state.Name == name {
+			target = state
+		}
+	}
+	if target.Name == "" {
+		return task, fmt.Errorf("%w %s", ErrUnknownState, name)
+	}
+
+	if StateOf(states, task).Name == target.Name {
+		return task, nil
+	}
+
+	if target.Category == model.StateClosed && !task.Done && !force {
+		if err := dependency.CheckDone(t, task); err != nil {
+			return task, err
+		}
+	}
+
+	if target.WIPLimit > 0 && count(t, project, states, target) >= target.WIPLimit {
+		return task, fmt.Errorf("%w: %s allows %d tasks", ErrWIPLimit, target.Name, target.WIPLimit)
+	}
+
+	if target.Category == model.StateClosed {
+		task.CompleteTask()
+	} else {
+		task.ReopenTask()
+	}
+	task.State = target.Name
+
+	return task, t.UpdateTask(task)
+}
+
+// Completes a task by moving it into the first closed state,
+// or reopens it by moving it into the first open state.
+// Tasks already in a state of that category keep their state.
+// Tasks with open blockers are only completed if force is true
+func Complete(t store.TodoStore, project model.Project, task model.Task, done, force bool) (model.Task, error) {
+	if task.Done == done {
+		if done {
+			task.CompleteTask()
+		}
+		return task, t.UpdateTask(task)
+	}
+
+	category := model.StateOpen
+	if done {
+		category = model.StateClosed
+	}
+	return Transition(t, project, task, first(States(t, project), category).Name, force)
+}
+
+// Returns the first state of the category
+func first(states []model.WorkflowState, category string) model.WorkflowState {
+	for _, state := range states {
+		if state.Category == category {
+			return state
+		}
+	}
+	return model.WorkflowState{}
+}
+
+// Counts the tasks of the project in the state
+func count(t store.TodoStore, project model.Project, states []model.WorkflowState, state model.WorkflowState) int {
+	n := 0
+	for _, task := range t.GetAllProjectTasks(project) {
+		if StateOf(states, task).Name == state.Name {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,233 @@
+// Package client is a Go client for the todo API.
+//
+//	c := client.New("http://localhost:5000")
+//	err := c.CreateProject(ctx, "homework")
+//	projects, err := c.GetAllProjects(ctx)
+//
+// Errors answered by the server are returned as *Error. Idempotent
+// requests (GET, PUT and DELETE) are retried with exponential backoff
+// on network errors, 429 and 5xx responses.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout of task deadlines expected by the server
+const deadlineLayout = "2006-01-02 15:04:05 +0000 UTC"
+
+// Defaults for retries of idempotent requests
+const (
+	defaultRetries = 2
+	defaultBackoff = 100 * time.Millisecond
+)
+
+// Client of the todo API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures optional behaviour of a Client
+type Option func(*Client)
+
+// Uses the given http.Client instead of http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Retries idempotent requests up to retries times. The wait before
+// the first retry is backoff and doubles with every further retry
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// Sends the header with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// Sends the token as bearer token, e.g. for the admin routes
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// Creates a client of the API at baseURL, e.g. "http://localhost:5000"
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     http.Header{},
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Error answered by the server
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("todo api: %d %s", e.StatusCode, e.Message)
+}
+
+// Reports whether err is an Error with status 404
+func IsNotFound(err error) bool {
+	var apiError *Error
+	return errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound
+}
+
+// Sends a request and decodes the JSON response into out, if out is not nil.
+// in is sent as JSON body, if it is not nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	retries := 0
+	if method != http.MethodPost {
+		retries = c.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := c.send(ctx, method, target, body)
+		if attempt < retries && retryable(response, err) {
+			wait := c.backoff << attempt
+			if response != nil {
+				wait = retryAfter(response, wait)
+				drain(response)
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return decodeResponse(response, out)
+	}
+}
+
+// Sends one request
+func (c *Client) send(ctx context.Context, method, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.httpClient.Do(request)
+}
+
+// Decodes the response into out or into an Error
+func decodeResponse(response *http.Response, out interface{}) error {
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		apiError := &Error{StatusCode: response.StatusCode}
+		message := struct {
+			Message string `json:"message"`
+		}{}
+		if err := json.NewDecoder(response.Body).Decode(&message); err == nil && message.Message != "" {
+			apiError.Message = message.Message
+		} else {
+			apiError.Message = http.StatusText(response.StatusCode)
+		}
+		return apiError
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// Network errors, 429 and 5xx responses are retried, unless the context is done
+func retryable(response *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError
+}
+
+// Uses the seconds of a Retry-After header instead of the backoff
+func retryAfter(response *http.Response, wait time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return wait
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Reads and closes the body, so the connection can be reused
+func drain(response *http.Response) {
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+}
+
+// Waits for d or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Escapes names for use in paths
+func escape(name string) string {
+	return url.PathEscape(name)
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Request body of POST and PUT /projects
+type projectInput struct {
+	Name string `json:"name"`
+}
+
+// Gets all projects
+func (c *Client) GetAllProjects(ctx context.Context) ([]model.Project, error) {
+	projects := []model.Project{}
+	err := c.do(ctx, http.MethodGet, "/projects/", nil, nil, &projects)
+	return projects, err
+}
+
+// Gets a project
+func (c *Client) GetProject(ctx context.Context, name string) (model.Project, error) {
+	project := model.Project{}
+	err := c.do(ctx, http.MethodGet, "/projects/"+escape(name), nil, nil, &project)
+	return project, err
+}
+
+// Creates a new project
+func (c *Client) CreateProject(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/projects/", nil, projectInput{Name: name}, nil)
+}
+
+// Renames a project
+func (c *Client) RenameProject(ctx context.Context, name, newName string) error {
+	return c.do(ctx, http.MethodPut, "/projects/"+escape(name), nil, projectInput{Name: newName}, nil)
+}
+
+// Deletes a project
+func (c *Client) DeleteProject(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+escape(name), nil, nil, nil)
+}
+
+// Archives a project
+func (c *Client) ArchiveProject(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPut, "/projects/"+escape(name)+"/archive", nil, nil, nil)
+}
+
+// Restores an archived project
+func (c *Client) UnarchiveProject(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+escape(name)+"/archive", nil, nil, nil)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Filters of a search, only Text is required
+type SearchQuery struct {
+	Text    string
+	Project string
+	// "project" or "task"
+	Type      string
+	Done      *bool
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Limit     int
+}
+
+// Searches projects and tasks
+func (c *Client) Search(ctx context.Context, query SearchQuery) ([]model.SearchResult, error) {
+	values := url.Values{"q": {query.Text}}
+	if query.Project != "" {
+		values.Set("project", query.Project)
+	}
+	if query.Type != "" {
+		values.Set("type", query.Type)
+	}
+	if query.Done != nil {
+		values.Set("done", strconv.FormatBool(*query.Done))
+	}
+	if query.DueBefore != nil {
+		values.Set("due_before", query.DueBefore.Format(time.RFC3339))
+	}
+	if query.DueAfter != nil {
+		values.Set("due_after", query.DueAfter.Format(time.RFC3339))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	results := []model.SearchResult{}
+	err := c.do(ctx, http.MethodGet, "/search", values, nil, &results)
+	return results, err
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+)
+
+// Properties of a created or updated task
+type TaskInput struct {
+	Name     string
+	Priority string
+	// Tasks without deadline if nil
+	Deadline *time.Time
+	Notes    string
+	// RRULE of recurring tasks, e.g. FREQ=WEEKLY
+	Recurrence string
+}
+
+// Request body of POST and PUT /projects/:projectName/tasks
+type taskInput struct {
+	Name       string `json:"name"`
+	Priority   string `json:"priority"`
+	Deadline   string `json:"deadline,omitempty"`
+	Notes      string `json:"notes"`
+	Recurrence string `json:"recurrence"`
+}
+
+func (i TaskInput) body() taskInput {
+	body := taskInput{
+		Name:       i.Name,
+		Priority:   i.Priority,
+		Notes:      i.Notes,
+		Recurrence: i.Recurrence,
+	}
+	if i.Deadline != nil {
+		body.Deadline = i.Deadline.UTC().Format(deadlineLayout)
+	}
+	return body
+}
+
+// Path of the tasks of a project
+func tasksPath(project string) string {
+	return "/projects/" + escape(project) + "/tasks"
+}
+
+// Gets all tasks of a project
+func (c *Client) GetAllTasks(ctx context.Context, project string) ([]model.Task, error) {
+	tasks := []model.Task{}
+	err := c.do(ctx, http.MethodGet, tasksPath(project), nil, nil, &tasks)
+	return tasks, err
+}
+
+// Gets a task of a project
+func (c *Client) GetTask(ctx context.Context, project, name string) (model.Task, error) {
+	task := model.Task{}
+	err := c.do(ctx, http.MethodGet, tasksPath(project)+"/"+escape(name), nil, nil, &task)
+	return task, err
+}
+
+// Creates a new task in a project
+func (c *Client) CreateTask(ctx context.Context, project string, task TaskInput) error {
+	return c.do(ctx, http.MethodPost, tasksPath(project), nil, task.body(), nil)
+}
+
+// Replaces the properties of a task
+func (c *Client) UpdateTask(ctx context.Context, project, name string, task TaskInput) error {
+	return c.do(ctx, http.MethodPut, tasksPath(project)+"/"+escape(name), nil, task.body(), nil)
+}
+
+// Deletes a task
+func (c *Client) DeleteTask(ctx context.Context, project, name string) error {
+	return c.do(ctx, http.MethodDelete, tasksPath(project)+"/"+escape(name), nil, nil, nil)
+}
+
+// Completes a task
+func (c *Client) CompleteTask(ctx context.Context, project, name string) error {
+	return c.do(ctx, http.MethodPut, tasksPath(project)+"/"+escape(name)+"/complete", nil, nil, nil)
+}
+
+// Reopens a completed task
+func (c *Client) ReopenTask(ctx context.Context, project, name string) error {
+	return c.do(ctx, http.MethodDelete, tasksPath(project)+"/"+escape(name)+"/complete", nil, nil, nil)
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/model"
+	"github.com/mpfen/Go-Todo-REST-API-V2/client"
+)
+
+// Layouts accepted for due dates
+var dueLayouts = []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339}
+
+// Layout of due dates in tables
+const tableDateLayout = "2006-01-02 15:04"
+
+// Command projects [-q]
+func (c *cli) listProjects(args []string) error {
+	flags := newFlagSet("projects")
+	quiet := flags.Bool("q", false, "only print project names")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	projects, err := c.client.GetAllProjects(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		for _, project := range projects {
+			fmt.Fprintln(c.out, project.Name)
+		}
+		return nil
+	}
+	if c.format == "json" {
+		return c.printJSON(projects)
+	}
+
+	table := c.table("NAME", "ARCHIVED")
+	for _, project := range projects {
+		fmt.Fprintf(table, "%s\t%t\n", project.Name, project.Archived)
+	}
+	return table.Flush()
+}
+
+// Command project create|rename|archive|unarchive|delete
+func (c *cli) project(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	subcommand, args := args[0], args[1:]
+	var err error
+	var message string
+	switch {
+	case subcommand == "create" && len(args) == 1:
+		err = c.client.CreateProject(c.ctx, args[0])
+		message = "project created"
+	case subcommand == "rename" && len(args) == 2:
+		err = c.client.RenameProject(c.ctx, args[0], args[1])
+		message = "project renamed"
+	case subcommand == "archive" && len(args) == 1:
+		err = c.client.ArchiveProject(c.ctx, args[0])
+		message = "project archived"
+	case subcommand == "unarchive" && len(args) == 1:
+		err = c.client.UnarchiveProject(c.ctx, args[0])
+		message = "project unarchived"
+	case subcommand == "delete" && len(args) == 1:
+		err = c.client.DeleteProject(c.ctx, args[0])
+		message = "project deleted"
+	default:
+		return errUsage
+	}
+
+	if err != nil {
+		return err
+	}
+	return c.printMessage(message)
+}
+
+// Command tasks project
+func (c *cli) listTasks(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	tasks, err := c.client.GetAllTasks(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printTasks(args[0], tasks)
+}
+
+// Command task add|edit|complete|reopen|delete
+func (c *cli) task(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	subcommand, args := args[0], args[1:]
+	switch subcommand {
+	case "add", "edit":
+		return c.saveTask(subcommand, args)
+	}
+
+	if len(args) != 2 {
+		return errUsage
+	}
+	project, name := args[0], args[1]
+
+	var err error
+	var message string
+	switch subcommand {
+	case "complete":
+		err = c.client.CompleteTask(c.ctx, project, name)
+		message = "task completed"
+	case "reopen":
+		err = c.client.ReopenTask(c.ctx, project, name)
+		message = "task reopened"
+	case "delete":
+		err = c.client.DeleteTask(c.ctx, project, name)
+		message = "task deleted"
+	default:
+		return errUsage
+	}
+
+	if err != nil {
+		return err
+	}
+	return c.printMessage(message)
+}
+
+// Commands task add and task edit. Edit only changes the given properties
+func (c *cli) saveTask(subcommand string, args []string) error {
+	flags := newFlagSet("task " + subcommand)
+	newName := flags.String("name", "", "new name of the task")
+	priority := flags.String("priority", "", "priority, 1 (highest) to 9 (lowest)")
+	due := flags.String("due", "", "deadline, e.g. 2021-06-10 or \"2021-06-10 14:00\", \"\" removes it")
+	notes := flags.String("notes", "", "notes")
+	recurrence := flags.String("recurrence", "", "RRULE of recurring tasks, e.g. FREQ=WEEKLY")
+	if err := parseFlags(flags, args, 2); err != nil {
+		return err
+	}
+	project, name := flags.Arg(0), flags.Arg(1)
+
+	input := client.TaskInput{Name: name, Priority: "5"}
+	if subcommand == "edit" {
+		task, err := c.client.GetTask(c.ctx, project, name)
+		if err != nil {
+			return err
+		}
+		input = client.TaskInput{
+			Name:       task.Name,
+			Priority:   task.Priority,
+			Notes:      task.Notes,
+			Recurrence: task.Recurrence,
+		}
+		if hasDeadline(task) {
+			input.Deadline = task.Deadline
+		}
+	}
+
+	// Only flags given on the command line change the task
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			input.Name = *newName
+		case "priority":
+			input.Priority = *priority
+		case "due":
+			input.Deadline, err = parseDue(*due)
+		case "notes":
+			input.Notes = *notes
+		case "recurrence":
+			input.Recurrence = *recurrence
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if subcommand == "add" {
+		err = c.client.CreateTask(c.ctx, project, input)
+	} else {
+		err = c.client.UpdateTask(c.ctx, project, name, input)
+	}
+	if err != nil {
+		return err
+	}
+
+	if subcommand == "add" {
+		return c.printMessage("task created")
+	}
+	return c.printMessage("task updated")
+}
+
+// Command overdue [-project name]
+func (c *cli) overdue(args []string) error {
+	flags := newFlagSet("overdue")
+	projectName := flags.String("project", "", "only list tasks of this project")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	projects := []string{*projectName}
+	if *projectName == "" {
+		all, err := c.client.GetAllProjects(c.ctx)
+		if err != nil {
+			return err
+		}
+		projects = projects[:0]
+		for _, project := range all {
+			if !project.Archived {
+				projects = append(projects, project.Name)
+			}
+		}
+	}
+
+	now := time.Now()
+	overdue := []overdueTask{}
+	for _, project := range projects {
+		tasks, err := c.client.GetAllTasks(c.ctx, project)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if !task.Done && hasDeadline(task) && task.Deadline.Before(now) {
+				overdue = append(overdue, overdueTask{Project: project, Task: task})
+			}
+		}
+	}
+
+	// Longest overdue first
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].Task.Deadline.Before(*overdue[j].Task.Deadline)
+	})
+
+	if c.format == "json" {
+		return c.printJSON(overdue)
+	}
+
+	table := c.table("PROJECT", "NAME", "PRIORITY", "DUE")
+	for _, item := range overdue {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", item.Project, item.Task.Name, item.Task.Priority,
+			item.Task.Deadline.Local().Format(tableDateLayout))
+	}
+	return table.Flush()
+}
+
+// A task past its deadline
+type overdueTask struct {
+	Project string     `json:"project"`
+	Task    model.Task `json:"task"`
+}
+
+// Prints the tasks of a project
+func (c *cli) printTasks(project string, tasks []model.Task) error {
+	if c.format == "json" {
+		return c.printJSON(tasks)
+	}
+
+	table := c.table("NAME", "PRIORITY", "DUE", "DONE", "TAGS")
+	for _, task := range tasks {
+		due := ""
+		if hasDeadline(task) {
+			due = task.Deadline.Local().Format(tableDateLayout)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%t\t%s\n", task.Name, task.Priority, due, task.Done, task.Tags)
+	}
+	return table.Flush()
+}
+
+// Prints the result of a command without output
+func (c *cli) printMessage(message string) error {
+	if c.format == "json" {
+		return c.printJSON(map[string]string{"message": message})
+	}
+	_, err := fmt.Fprintln(c.out, message)
+	return err
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Creates a table writer and writes the header row
+func (c *cli) table(columns ...string) *tabwriter.Writer {
+	table := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(columns, "\t"))
+	return table
+}
+
+// Tasks created without deadline by older clients have the zero time
+func hasDeadline(task model.Task) bool {
+	return task.Deadline != nil && task.Deadline.Year() > 1
+}
+
+// Parses a due date in local time, an empty value is no due date
+func parseDue(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range dueLayouts {
+		if due, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &due, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid due date %s, use 2021-06-10 or \"2021-06-10 14:00\"", value)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// Parses the flags of a command and checks the number of arguments
+func parseFlags(flags *flag.FlagSet, args []string, arguments int) error {
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%s: %v", flags.Name(), err)
+	}
+	if flags.NArg() != arguments {
+		return errUsage
+	}
+	return nil
+}
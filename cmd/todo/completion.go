@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Completes commands, subcommands and project names
+const bashCompletion = `_todo() {
+	local cur=${COMP_WORDS[COMP_CWORD]}
+	local i=1 words=()
+	# Skip the global flags and their values
+	while [[ $i -lt $COMP_CWORD ]]; do
+		case ${COMP_WORDS[i]} in
+			-server|-token|-o|-config) i=$((i+2)) ;;
+			-*) i=$((i+1)) ;;
+			*) words+=("${COMP_WORDS[i]}"); i=$((i+1)) ;;
+		esac
+	done
+
+	case "${#words[@]} ${words[0]}" in
+		"0 ") COMPREPLY=($(compgen -W "projects project tasks task overdue completion" -- "$cur")) ;;
+		"1 project") COMPREPLY=($(compgen -W "create rename archive unarchive delete" -- "$cur")) ;;
+		"1 task") COMPREPLY=($(compgen -W "add edit complete reopen delete" -- "$cur")) ;;
+		"1 completion") COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+		"1 tasks"|"2 project"|"2 task") COMPREPLY=($(compgen -W "$(todo projects -q 2>/dev/null)" -- "$cur")) ;;
+	esac
+}
+complete -F _todo todo
+`
+
+// zsh runs the bash completion
+const zshCompletion = `autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+const fishCompletion = `complete -c todo -f
+complete -c todo -n __fish_use_subcommand -a "projects project tasks task overdue completion"
+complete -c todo -n "__fish_seen_subcommand_from project; and not __fish_seen_subcommand_from create rename archive unarchive delete" -a "create rename archive unarchive delete"
+complete -c todo -n "__fish_seen_subcommand_from task; and not __fish_seen_subcommand_from add edit complete reopen delete" -a "add edit complete reopen delete"
+complete -c todo -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c todo -n "__fish_seen_subcommand_from tasks create rename archive unarchive delete add edit complete reopen" -a "(todo projects -q 2>/dev/null)"
+`
+
+// Command completion bash|zsh|fish
+func completion(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	scripts := map[string]string{
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+	script, ok := scripts[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown shell %s, supported are bash, zsh and fish", args[0])
+	}
+
+	_, err := io.WriteString(out, script)
+	return err
+}
@@ -0,0 +1,168 @@
+// Command todo manages projects and tasks of a todo server from the terminal.
+//
+//	todo [-server url] [-token token] [-o table|json] command [arguments]
+//
+// The server URL and token are read from the flags, the environment
+// variables TODO_SERVER and TODO_TOKEN or the config file
+// ~/.config/todo/config.json, in that order:
+//
+//	{"server": "http://localhost:5000", "token": "secret"}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/client"
+)
+
+// Server used without flag, environment variable or config file
+const defaultServer = "http://localhost:5000"
+
+const usage = `usage: todo [-server url] [-token token] [-o table|json] [-config file] command [arguments]
+
+commands:
+  projects [-q]                        list projects, -q prints only names
+  project create name                  create a project
+  project rename name new-name         rename a project
+  project archive name                 archive a project
+  project unarchive name               restore an archived project
+  project delete name                  delete a project
+  tasks project                        list the tasks of a project
+  task add [flags] project name        add a task
+  task edit [flags] project name       change a task
+  task complete project name           complete a task
+  task reopen project name             reopen a completed task
+  task delete project name             delete a task
+  overdue [-project name]              list open tasks past their deadline
+  completion bash|zsh|fish             print a shell completion script
+`
+
+// Errors of this type are usage errors
+var errUsage = errors.New("invalid usage")
+
+// Settings of the config file
+type config struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+}
+
+// State shared by all commands
+type cli struct {
+	client *client.Client
+	ctx    context.Context
+	out    io.Writer
+	// table or json
+	format string
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todo:", err)
+		os.Exit(1)
+	}
+}
+
+// Parses the global flags and runs a command
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	server := flags.String("server", "", "URL of the server")
+	token := flags.String("token", "", "bearer token sent with every request")
+	format := flags.String("o", "table", "output format, table or json")
+	configFile := flags.String("config", defaultConfigFile(), "config file")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown output format %s", *format)
+	}
+
+	settings, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	settings.Server = firstNonEmpty(*server, os.Getenv("TODO_SERVER"), settings.Server, defaultServer)
+	settings.Token = firstNonEmpty(*token, os.Getenv("TODO_TOKEN"), settings.Token)
+
+	options := []client.Option{}
+	if settings.Token != "" {
+		options = append(options, client.WithToken(settings.Token))
+	}
+
+	c := &cli{
+		client: client.New(settings.Server, options...),
+		ctx:    context.Background(),
+		out:    out,
+		format: *format,
+	}
+
+	if flags.NArg() == 0 {
+		return errUsage
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "projects":
+		return c.listProjects(args)
+	case "project":
+		return c.project(args)
+	case "tasks":
+		return c.listTasks(args)
+	case "task":
+		return c.task(args)
+	case "overdue":
+		return c.overdue(args)
+	case "completion":
+		return completion(args, out)
+	}
+	return errUsage
+}
+
+// ~/.config/todo/config.json or the same below XDG_CONFIG_HOME
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "todo", "config.json")
+}
+
+// Reads the config file. A missing file is an empty config
+func loadConfig(file string) (config, error) {
+	settings := config{}
+	if file == "" {
+		return settings, nil
+	}
+
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	} else if err != nil {
+		return settings, err
+	}
+
+	if err := json.Unmarshal(content, &settings); err != nil {
+		return settings, fmt.Errorf("invalid config file %s: %v", file, err)
+	}
+	return settings, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
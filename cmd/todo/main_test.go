@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// Starts a server with a new database and returns a function running todo against it
+func setupCLI(t *testing.T) func(args ...string) (string, error) {
+	t.Helper()
+	db := store.NewDatabaseConnection(filepath.Join(t.TempDir(), "test.db"))
+	server := httptest.NewServer(api.NewTodoServer(db).Router)
+	t.Cleanup(server.Close)
+
+	config := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(config, []byte(`{"server": "`+server.URL+`"}`), 0600)
+
+	return func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		err := run(append([]string{"-config", config}, args...), out)
+		return out.String(), err
+	}
+}
+
+func TestProjectCommands(t *testing.T) {
+	todo := setupCLI(t)
+
+	out, err := todo("project", "create", "homework")
+	assert.NoError(t, err)
+	assert.Equal(t, "project created\n", out)
+	todo("project", "create", "cleaning")
+	todo("project", "archive", "cleaning")
+	todo("project", "rename", "homework", "school")
+
+	out, _ = todo("projects")
+	assert.Equal(t, "NAME      ARCHIVED\nschool    false\ncleaning  true\n", out)
+
+	out, _ = todo("projects", "-q")
+	assert.Equal(t, "school\ncleaning\n", out)
+
+	t.Run("Errors of the server", func(t *testing.T) {
+		_, err := todo("tasks", "garden")
+		assert.EqualError(t, err, "todo api: 404 project not found")
+	})
+
+	t.Run("Invalid usage", func(t *testing.T) {
+		_, err := todo("project", "rename", "school")
+		assert.ErrorIs(t, err, errUsage)
+	})
+}
+
+func TestTaskCommands(t *testing.T) {
+	todo := setupCLI(t)
+	todo("project", "create", "homework")
+
+	_, err := todo("task", "add", "-priority", "1", "-due", "2021-06-10", "homework", "math")
+	assert.NoError(t, err)
+	todo("task", "add", "homework", "biology")
+	todo("task", "edit", "-notes", "page 42", "-name", "algebra", "homework", "math")
+	todo("task", "complete", "homework", "biology")
+
+	out, _ := todo("-o", "json", "tasks", "homework")
+	tasks := []struct {
+		Name     string `json:"name"`
+		Priority string `json:"priority"`
+		Notes    string `json:"notes"`
+		Done     bool   `json:"done"`
+	}{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &tasks))
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "algebra", tasks[0].Name)
+	assert.Equal(t, "1", tasks[0].Priority)
+	assert.Equal(t, "page 42", tasks[0].Notes)
+	assert.True(t, tasks[1].Done)
+
+	t.Run("Overdue tasks", func(t *testing.T) {
+		out, _ := todo("overdue")
+		assert.Contains(t, out, "homework  algebra  1         2021-06-10 00:00")
+		assert.NotContains(t, out, "biology")
+	})
+
+	t.Run("Tasks without due date are not overdue", func(t *testing.T) {
+		todo("task", "add", "homework", "physics")
+		out, _ := todo("overdue")
+		assert.NotContains(t, out, "physics")
+
+		out, _ = todo("-o", "json", "tasks", "homework")
+		assert.Contains(t, out, `"name": "physics"`)
+		assert.NotContains(t, out, "0001-01-01")
+
+		todo("task", "edit", "-due", "", "homework", "algebra")
+		out, _ = todo("overdue")
+		assert.NotContains(t, out, "algebra")
+	})
+
+	t.Run("Invalid due date", func(t *testing.T) {
+		_, err := todo("task", "edit", "-due", "tomorrow", "homework", "algebra")
+		assert.Error(t, err)
+	})
+
+	todo("task", "delete", "homework", "algebra")
+	out, _ = todo("tasks", "homework")
+	assert.NotContains(t, out, "algebra")
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out := &bytes.Buffer{}
+		assert.NoError(t, run([]string{"completion", shell}, out))
+		assert.Contains(t, out.String(), "todo")
+	}
+}
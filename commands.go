@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/attachment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/backup"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ical"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+)
+
+// Command import-ics [-db file] -project name file.ics
+func importICS(args []string) {
+	flags := flag.NewFlagSet("import-ics", flag.ExitOnError)
+	dbFile := flags.String("db", "database.db", "database file")
+	projectName := flags.String("project", "", "target project, created if it does not exist")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: import-ics [-db file] -project name file.ics")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *projectName == "" || flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	db := store.NewDatabaseConnection(*dbFile)
+
+	// Create the target project if needed
+	project := db.GetProject(*projectName)
+	if project.Name == "" {
+		if err := db.PostProject(*projectName); err != nil {
+			log.Fatalf("could not create project %s: %v", *projectName, err)
+		}
+		project = db.GetProject(*projectName)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("could not open %s: %v", flags.Arg(0), err)
+	}
+	defer file.Close()
+
+	report, err := ical.Import(db, project, file)
+	if err != nil {
+		log.Fatalf("import failed: %v", err)
+	}
+
+	fmt.Printf("created %d, updated %d, skipped %d tasks\n", report.Created, report.Updated, len(report.Skipped))
+	for _, skipped := range report.Skipped {
+		fmt.Printf("line %d: %q (%s): %s\n", skipped.Line, skipped.Summary, skipped.UID, skipped.Reason)
+	}
+}
+
+// Command backup [-db file] [-o backup.json]
+func backupDatabase(args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	dbFile := flags.String("db", "database.db", "database file")
+	output := flags.String("o", "", "backup file, stdout if empty")
+	flags.Parse(args)
+
+	db := store.NewDatabaseConnection(*dbFile)
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("could not create %s: %v", *output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := backup.Write(db, out); err != nil {
+		log.Fatalf("backup failed: %v", err)
+	}
+}
+
+// Command restore [-db file] [-attachments dir] [-mode merge|replace] backup.json
+func restoreDatabase(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	dbFile := flags.String("db", "database.db", "database file")
+	attachmentsDir := flags.String("attachments", envOr("TODO_ATTACHMENTS_DIR", "attachments"), "directory with the contents of attachments")
+	mode := flags.String("mode", backup.ModeMerge, "merge with or replace the existing data")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: restore [-db file] [-attachments dir] [-mode merge|replace] backup.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("could not open %s: %v", flags.Arg(0), err)
+	}
+	defer file.Close()
+
+	dump, err := backup.Read(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blobs, err := blob.NewFileStore(*attachmentsDir)
+	if err != nil {
+		log.Fatalf("could not open attachment directory %v", err)
+	}
+
+	// Replacing deletes the contents of attachments that are not restored
+	db := attachment.NewStore(store.NewDatabaseConnection(*dbFile), blobs)
+	report, err := backup.Restore(db, dump, *mode)
+	if err != nil {
+		log.Fatalf("restore failed: %v", err)
+	}
+
+	fmt.Printf("restored %d projects, %d tasks, %d attachments and %d feed tokens\n", report.Projects, report.Tasks, report.Attachments, report.FeedTokens)
+}
@@ -2,18 +2,109 @@ package main
 
 import (
 	"log"
+	"net"
+	"net/http"
+	"os"
 
 	"github.com/mpfen/Go-Todo-REST-API-V2/api"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/attachment"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/blob"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/events"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/grpcapi"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/logging"
+	"github.com/mpfen/Go-Todo-REST-API-V2/api/ratelimit"
 	"github.com/mpfen/Go-Todo-REST-API-V2/api/store"
+	"google.golang.org/grpc"
 )
 
+// Runs the server or one of the commands:
+//
+// import-ics: import VTODO components of an iCalendar file into a project
+// backup:     write all data as JSON
+// restore:    restore a JSON backup
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-ics":
+			importICS(os.Args[2:])
+			return
+		case "backup":
+			backupDatabase(os.Args[2:])
+			return
+		case "restore":
+			restoreDatabase(os.Args[2:])
+			return
+		}
+	}
+
+	serve()
+}
+
+// Starts the server on port 5000 with API documentation at /docs.
+// The admin routes are enabled by setting TODO_ADMIN_TOKEN.
+// Attachments are stored in the directory attachments, or TODO_ATTACHMENTS_DIR if set.
+// Every client may send 600 requests per minute to all APIs, TODO_RATE_LIMIT changes the limit.
+// Logs are written to stderr as JSON lines of at least level info or TODO_LOG_LEVEL.
+// The gRPC API is served on the same port, or on TODO_GRPC_ADDR if set
+func serve() {
+	level, err := logging.ParseLevel(envOr("TODO_LOG_LEVEL", "info"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.SetDefault(logging.New(os.Stderr, level))
+
 	db := store.NewDatabaseConnection("database.db")
-	server := api.NewTodoServer(db)
 
-	err := server.Router.Run(":5000")
+	blobs, err := blob.NewFileStore(envOr("TODO_ATTACHMENTS_DIR", "attachments"))
+	if err != nil {
+		log.Fatalf("could not create attachment directory %v", err)
+	}
+
+	rateLimit, err := ratelimit.ParseLimit(envOr("TODO_RATE_LIMIT", "600/1m"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	proxies, err := ratelimit.ParseProxies(os.Getenv("TODO_TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Both APIs publish task changes to the gRPC WatchTasks streams
+	// and delete the attachments of deleted tasks
+	broker := events.NewBroker()
+	todoStore := events.NewStore(attachment.NewStore(db, blobs), broker)
+
+	server := api.NewTodoServer(todoStore,
+		api.WithAdminToken(os.Getenv("TODO_ADMIN_TOKEN")),
+		api.WithDocsUI(),
+		api.WithBlobStore(blobs),
+		api.WithRateLimit("/", rateLimit),
+		api.WithTrustedProxies(proxies...))
+
+	grpcServer := grpc.NewServer(server.GRPCOptions()...)
+	grpcapi.RegisterTodoServiceServer(grpcServer, grpcapi.NewServer(todoStore, broker))
+
+	if grpcAddr := os.Getenv("TODO_GRPC_ADDR"); grpcAddr != "" {
+		listener, listenErr := net.Listen("tcp", grpcAddr)
+		if listenErr != nil {
+			log.Fatalf("could not listen on %s %v", grpcAddr, listenErr)
+		}
+		go grpcServer.Serve(listener)
+
+		err = server.Router.Run(":5000")
+	} else {
+		err = http.ListenAndServe(":5000", grpcapi.Multiplex(grpcServer, server.Router))
+	}
 
 	if err != nil {
 		log.Fatalf("could not listen on port 5000 %v", err)
 	}
 }
+
+// Returns the environment variable or fallback if it is not set
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}